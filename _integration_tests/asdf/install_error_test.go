@@ -1,6 +1,7 @@
 package asdf
 
 import (
+	"context"
 	"testing"
 
 	"github.com/bitrise-io/toolprovider/provider"
@@ -24,7 +25,7 @@ func TestNoMatchingVersionError(t *testing.T) {
 		UnparsedVersion:    "22",
 		ResolutionStrategy: provider.ResolutionStrategyStrict,
 	}
-	_, err = asdfProvider.InstallTool(request)
+	_, err = asdfProvider.InstallTool(context.Background(), request)
 	require.Error(t, err)
 
 	var installErr provider.ToolInstallError
@@ -52,7 +53,7 @@ func TestNewToolPluginError(t *testing.T) {
 		UnparsedVersion:    "1.0.0",
 		ResolutionStrategy: provider.ResolutionStrategyStrict,
 	}
-	_, err = asdfProvider.InstallTool(request)
+	_, err = asdfProvider.InstallTool(context.Background(), request)
 	require.Error(t, err)
 
 	var installErr provider.ToolInstallError
@@ -1,6 +1,7 @@
 package asdf
 
 import (
+	"context"
 	"testing"
 
 	"github.com/bitrise-io/toolprovider/provider"
@@ -37,7 +38,7 @@ func TestAsdfInstallFlutterNoPlugin(t *testing.T) {
 				ResolutionStrategy: tt.resolutionStrategy,
 				PluginIdentifier:   &tt.plugin,
 			}
-			result, err := asdfProvider.InstallTool(request)
+			result, err := asdfProvider.InstallTool(context.Background(), request)
 			require.NoError(t, err)
 			require.Equal(t, "flutter", result.ToolName)
 			require.Equal(t, tt.expectedVersion, result.ConcreteVersion)
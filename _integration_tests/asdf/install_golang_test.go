@@ -1,6 +1,7 @@
 package asdf
 
 import (
+	"context"
 	"testing"
 
 	"github.com/bitrise-io/toolprovider/provider"
@@ -39,7 +40,7 @@ func TestAsdfInstallGolangVersion(t *testing.T) {
 				UnparsedVersion:    tt.requestedVersion,
 				ResolutionStrategy: tt.resolutionStrategy,
 			}
-			result, err := asdfProvider.InstallTool(request)
+			result, err := asdfProvider.InstallTool(context.Background(), request)
 			require.NoError(t, err)
 			require.Equal(t, "golang", result.ToolName)
 			require.Equal(t, tt.expectedVersion, result.ConcreteVersion)
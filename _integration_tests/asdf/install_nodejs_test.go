@@ -1,6 +1,7 @@
 package asdf
 
 import (
+	"context"
 	"testing"
 
 	"github.com/bitrise-io/toolprovider/provider"
@@ -37,7 +38,7 @@ func TestAsdfInstallNodeVersion(t *testing.T) {
 				UnparsedVersion:    tt.requestedVersion,
 				ResolutionStrategy: tt.resolutionStrategy,
 			}
-			result, err := asdfProvider.InstallTool(request)
+			result, err := asdfProvider.InstallTool(context.Background(), request)
 			require.NoError(t, err)
 			require.Equal(t, "nodejs", result.ToolName)
 			require.Equal(t, tt.expectedVersion, result.ConcreteVersion)
@@ -61,7 +62,7 @@ func TestCorepackWithNewNodeInstall(t *testing.T) {
 		ToolName:        "nodejs",
 		UnparsedVersion: "22.17.0",
 	}
-	result, err := asdfProvider.InstallTool(request)
+	result, err := asdfProvider.InstallTool(context.Background(), request)
 	require.NoError(t, err)
 	require.Equal(t, "nodejs", result.ToolName)
 	require.Equal(t, "22.17.0", result.ConcreteVersion)
@@ -1,6 +1,7 @@
 package asdf
 
 import (
+	"context"
 	"testing"
 
 	"github.com/bitrise-io/toolprovider/provider"
@@ -24,7 +25,7 @@ func TestAsdfInstallClassic(t *testing.T) {
 		ToolName:        "nodejs",
 		UnparsedVersion: "18.16.0",
 	}
-	result, err := asdfProvider.InstallTool(request)
+	result, err := asdfProvider.InstallTool(context.Background(), request)
 	require.NoError(t, err)
 	require.Equal(t, "nodejs", result.ToolName)
 	require.Equal(t, "18.16.0", result.ConcreteVersion)
@@ -47,7 +48,7 @@ func TestAsdfInstallRewrite(t *testing.T) {
 		ToolName:        "nodejs",
 		UnparsedVersion: "18.16.0",
 	}
-	result, err := asdfProvider.InstallTool(request)
+	result, err := asdfProvider.InstallTool(context.Background(), request)
 	require.NoError(t, err)
 	require.Equal(t, "nodejs", result.ToolName)
 	require.Equal(t, "18.16.0", result.ConcreteVersion)
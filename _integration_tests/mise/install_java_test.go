@@ -1,6 +1,7 @@
 package mise
 
 import (
+	"context"
 	"testing"
 
 	"github.com/bitrise-io/toolprovider/provider"
@@ -47,7 +48,7 @@ func TestMiseInstallJavaVersion(t *testing.T) {
 		miseProvider, err := mise.NewToolProvider(miseInstallDir, miseDataDir)
 		require.NoError(t, err)
 
-		err = miseProvider.Bootstrap()
+		err = miseProvider.Bootstrap(context.Background())
 		require.NoError(t, err)
 
 		t.Run(tt.name, func(t *testing.T) {
@@ -56,7 +57,7 @@ func TestMiseInstallJavaVersion(t *testing.T) {
 				UnparsedVersion:    tt.requestedVersion,
 				ResolutionStrategy: tt.resolutionStrategy,
 			}
-			result, err := miseProvider.InstallTool(request)
+			result, err := miseProvider.InstallTool(context.Background(), request)
 			require.NoError(t, err)
 			require.Equal(t, "java", result.ToolName)
 			require.Equal(t, tt.expectedVersion, result.ConcreteVersion)
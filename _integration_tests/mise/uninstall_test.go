@@ -0,0 +1,38 @@
+package mise
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/bitrise-io/toolprovider/provider"
+	"github.com/bitrise-io/toolprovider/provider/mise"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiseUninstallNodeVersion(t *testing.T) {
+	miseProvider, err := mise.NewToolProvider(t.TempDir(), t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, miseProvider.Bootstrap(context.Background()))
+
+	request := provider.ToolRequest{
+		ToolName:           "node",
+		UnparsedVersion:    "18.16.0",
+		ResolutionStrategy: provider.ResolutionStrategyStrict,
+	}
+	_, err = miseProvider.InstallTool(context.Background(), request)
+	require.NoError(t, err)
+
+	installed, err := miseProvider.ListInstalled(context.Background())
+	require.NoError(t, err)
+	require.True(t, slices.ContainsFunc(installed, func(v provider.InstalledVersion) bool {
+		return v.ToolName == "node" && v.Version == "18.16.0" && v.Path != ""
+	}), "installed versions: %v", installed)
+
+	err = miseProvider.UninstallTool(context.Background(), "node", "18.16.0")
+	require.NoError(t, err)
+
+	installed, err = miseProvider.ListInstalled(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, installed)
+}
@@ -0,0 +1,49 @@
+package activation
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bitrise-io/toolprovider/provider"
+)
+
+// dotenvWriter prints KEY=value lines. $PATH can't be referenced in dotenv files,
+// so it contains the current $PATH with the contributed paths prepended.
+type dotenvWriter struct {
+	out io.Writer
+	// quote wraps values in double quotes. When false, values must be single-line (e.g. GitLab dotenv reports).
+	quote bool
+}
+
+func (w dotenvWriter) Write(activation provider.EnvironmentActivation) error {
+	var b strings.Builder
+	for _, k := range sortedEnvVars(activation) {
+		line, err := w.line(k, activation.ContributedEnvVars[k])
+		if err != nil {
+			return err
+		}
+		b.WriteString(line)
+	}
+	if len(activation.ContributedPaths) > 0 {
+		line, err := w.line("PATH", prependPath(os.Getenv("PATH"), strings.Join(activation.ContributedPaths, ":")))
+		if err != nil {
+			return err
+		}
+		b.WriteString(line)
+	}
+	_, err := io.WriteString(w.out, b.String())
+	return err
+}
+
+func (w dotenvWriter) line(key, value string) (string, error) {
+	if w.quote {
+		escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "$", `\$`).Replace(value)
+		return fmt.Sprintf("%s=\"%s\"\n", key, escaped), nil
+	}
+	if strings.ContainsAny(value, "\r\n") {
+		return "", fmt.Errorf("$%s: multi-line values are not supported in this format", key)
+	}
+	return fmt.Sprintf("%s=%s\n", key, value), nil
+}
@@ -0,0 +1,34 @@
+package activation
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/bitrise-io/toolprovider/provider"
+)
+
+type envmanWriter struct{}
+
+func (w envmanWriter) Write(activation provider.EnvironmentActivation) error {
+	if os.Getenv("CI") == "" {
+		_ = exec.Command("envman", "init").Run()
+	}
+
+	for _, k := range sortedEnvVars(activation) {
+		cmd := exec.Command("envman", "add", "--key", k, "--value", activation.ContributedEnvVars[k])
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("add $%s to env: %w\n%s", k, err, out)
+		}
+	}
+
+	if len(activation.ContributedPaths) > 0 {
+		newPath := prependPath(os.Getenv("PATH"), strings.Join(activation.ContributedPaths, ":"))
+		cmd := exec.Command("envman", "add", "--key", "PATH", "--value", newPath)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("update $PATH: %w\n%s", err, out)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package activation
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bitrise-io/toolprovider/provider"
+)
+
+// githubWriter appends to the files that GitHub Actions reads after each step.
+// https://docs.github.com/en/actions/writing-workflows/choosing-what-your-workflow-does/workflow-commands-for-github-actions#environment-files
+type githubWriter struct {
+	envFile  string
+	pathFile string
+}
+
+// Delimiter of multi-line values in $GITHUB_ENV
+const githubEnvDelimiter = "TOOLPROVIDER_EOF"
+
+func (w githubWriter) Write(activation provider.EnvironmentActivation) error {
+	var env strings.Builder
+	for _, k := range sortedEnvVars(activation) {
+		v := activation.ContributedEnvVars[k]
+		if !strings.ContainsAny(v, "\r\n") {
+			fmt.Fprintf(&env, "%s=%s\n", k, v)
+			continue
+		}
+		if strings.Contains(v, githubEnvDelimiter) {
+			return fmt.Errorf("$%s: value contains the delimiter %s", k, githubEnvDelimiter)
+		}
+		fmt.Fprintf(&env, "%s<<%s\n%s\n%s\n", k, githubEnvDelimiter, v, githubEnvDelimiter)
+	}
+	err := appendToFile(w.envFile, env.String())
+	if err != nil {
+		return fmt.Errorf("write $GITHUB_ENV: %w", err)
+	}
+
+	// Every line is prepended to $PATH, so the path with the highest precedence has to come last.
+	var paths strings.Builder
+	for i := len(activation.ContributedPaths) - 1; i >= 0; i-- {
+		fmt.Fprintln(&paths, activation.ContributedPaths[i])
+	}
+	err = appendToFile(w.pathFile, paths.String())
+	if err != nil {
+		return fmt.Errorf("write $GITHUB_PATH: %w", err)
+	}
+	return nil
+}
+
+func appendToFile(path, content string) error {
+	if content == "" {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,35 @@
+package activation
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/bitrise-io/toolprovider/provider"
+)
+
+type jsonWriter struct {
+	out io.Writer
+}
+
+type jsonActivation struct {
+	EnvVars map[string]string `json:"env_vars"`
+	// Paths are in decreasing order of precedence, they should be prepended to $PATH in this order.
+	Paths []string `json:"paths"`
+}
+
+func (w jsonWriter) Write(activation provider.EnvironmentActivation) error {
+	output := jsonActivation{
+		EnvVars: activation.ContributedEnvVars,
+		Paths:   activation.ContributedPaths,
+	}
+	if output.EnvVars == nil {
+		output.EnvVars = map[string]string{}
+	}
+	if output.Paths == nil {
+		output.Paths = []string{}
+	}
+
+	encoder := json.NewEncoder(w.out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+}
@@ -0,0 +1,55 @@
+package activation
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"al.essio.dev/pkg/shellescape"
+
+	"github.com/bitrise-io/toolprovider/provider"
+)
+
+// posixWriter prints a script that can be eval'd: eval "$(toolprovider env)"
+type posixWriter struct {
+	out io.Writer
+}
+
+func (w posixWriter) Write(activation provider.EnvironmentActivation) error {
+	var b strings.Builder
+	for _, k := range sortedEnvVars(activation) {
+		fmt.Fprintf(&b, "export %s=%s\n", k, shellescape.Quote(activation.ContributedEnvVars[k]))
+	}
+	if len(activation.ContributedPaths) > 0 {
+		fmt.Fprintf(&b, "export PATH=%s:\"$PATH\"\n", shellescape.Quote(strings.Join(activation.ContributedPaths, ":")))
+	}
+	_, err := io.WriteString(w.out, b.String())
+	return err
+}
+
+// fishWriter prints a script that can be sourced: toolprovider env --format fish | source
+type fishWriter struct {
+	out io.Writer
+}
+
+func (w fishWriter) Write(activation provider.EnvironmentActivation) error {
+	var b strings.Builder
+	for _, k := range sortedEnvVars(activation) {
+		fmt.Fprintf(&b, "set -gx %s %s\n", k, fishQuote(activation.ContributedEnvVars[k]))
+	}
+	if len(activation.ContributedPaths) > 0 {
+		// $PATH is a list in fish
+		quoted := make([]string, 0, len(activation.ContributedPaths))
+		for _, p := range activation.ContributedPaths {
+			quoted = append(quoted, fishQuote(p))
+		}
+		fmt.Fprintf(&b, "set -gx PATH %s $PATH\n", strings.Join(quoted, " "))
+	}
+	_, err := io.WriteString(w.out, b.String())
+	return err
+}
+
+// fishQuote quotes a value with single quotes. Only \ and ' need escaping inside single quotes in fish.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
@@ -0,0 +1,153 @@
+// Package activation writes the environment activation of the installed tools (see provider.EnvironmentActivation)
+// to the places where the following build steps or shell sessions pick it up.
+package activation
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"golang.org/x/exp/maps"
+
+	"github.com/bitrise-io/toolprovider/provider"
+)
+
+// Writer makes an environment activation available outside of the toolprovider process.
+type Writer interface {
+	Write(activation provider.EnvironmentActivation) error
+}
+
+type Format string
+
+const (
+	// FormatEnvman exposes the env vars to the next steps of a Bitrise build through `envman add`.
+	FormatEnvman Format = "envman"
+	// FormatPosix prints `export` statements for sh, bash and zsh.
+	FormatPosix Format = "posix"
+	// FormatFish prints `set -gx` statements for the fish shell.
+	FormatFish Format = "fish"
+	// FormatDotenv prints KEY="value" lines.
+	FormatDotenv Format = "dotenv"
+	// FormatJSON prints the env vars and the paths as a JSON object.
+	FormatJSON Format = "json"
+	// FormatGitHub appends to the $GITHUB_ENV and $GITHUB_PATH files of GitHub Actions.
+	FormatGitHub Format = "github"
+	// FormatGitLab prints a GitLab CI dotenv report (artifacts:reports:dotenv).
+	FormatGitLab Format = "gitlab"
+)
+
+// ErrEnvmanNotFound is returned by NewWriter() for the envman format outside of Bitrise builds.
+var ErrEnvmanNotFound = errors.New("envman is not installed or not in PATH")
+
+// Formats lists every supported format.
+var Formats = []Format{FormatEnvman, FormatPosix, FormatFish, FormatDotenv, FormatJSON, FormatGitHub, FormatGitLab}
+
+// NewWriter creates a writer for the format. The posix, fish, dotenv, json and gitlab formats write to out,
+// envman and github write to their own destinations.
+func NewWriter(format Format, out io.Writer) (Writer, error) {
+	switch format {
+	case FormatEnvman:
+		if _, err := exec.LookPath("envman"); err != nil {
+			return nil, fmt.Errorf("%s format: %w", format, ErrEnvmanNotFound)
+		}
+		return envmanWriter{}, nil
+	case FormatPosix:
+		return posixWriter{out: out}, nil
+	case FormatFish:
+		return fishWriter{out: out}, nil
+	case FormatDotenv:
+		return dotenvWriter{out: out, quote: true}, nil
+	case FormatJSON:
+		return jsonWriter{out: out}, nil
+	case FormatGitHub:
+		envFile, pathFile := os.Getenv("GITHUB_ENV"), os.Getenv("GITHUB_PATH")
+		if envFile == "" || pathFile == "" {
+			return nil, fmt.Errorf("%s format: $GITHUB_ENV and $GITHUB_PATH must be set, is this a GitHub Actions job?", format)
+		}
+		return githubWriter{envFile: envFile, pathFile: pathFile}, nil
+	case FormatGitLab:
+		// GitLab doesn't unquote dotenv values
+		return dotenvWriter{out: out, quote: false}, nil
+	default:
+		return nil, fmt.Errorf("unknown activation format: %s, expected one of: %s", format, formatList())
+	}
+}
+
+// DefaultFormat returns the format that fits the current CI environment: github on GitHub Actions, envman everywhere else.
+func DefaultFormat() Format {
+	if os.Getenv("GITHUB_ACTIONS") == "true" {
+		return FormatGitHub
+	}
+	return FormatEnvman
+}
+
+// WritesToOutput reports whether the format writes the activation to the output passed to NewWriter().
+func (f Format) WritesToOutput() bool {
+	return f != FormatEnvman && f != FormatGitHub
+}
+
+func formatList() string {
+	names := make([]string, 0, len(Formats))
+	for _, f := range Formats {
+		names = append(names, string(f))
+	}
+	return strings.Join(names, ", ")
+}
+
+// sortedEnvVars returns the env var names in a stable order, so that the output is the same across runs.
+func sortedEnvVars(activation provider.EnvironmentActivation) []string {
+	keys := maps.Keys(activation.ContributedEnvVars)
+	slices.Sort(keys)
+	return keys
+}
+
+// prependPath returns a $PATH value with newPath in front of pathEnv, removing the duplicate of newPath from pathEnv.
+// It's used by the formats that can't reference the current $PATH.
+func prependPath(pathEnv, newPath string) string {
+	if pathEnv == "" {
+		return newPath
+	}
+
+	pathItems := strings.Split(pathEnv, ":")
+	pathItems = slices.DeleteFunc(pathItems, func(p string) bool {
+		return p == newPath
+	})
+
+	if len(pathItems) == 0 {
+		return newPath
+	}
+
+	return fmt.Sprintf("%s:%s", newPath, strings.Join(pathItems, ":"))
+}
+
+// Environ returns a copy of environ (in the format of os.Environ()) with the activation applied, e.g. to run a command
+// with the installed tools. Env vars are overridden and the paths are prepended to $PATH the same way as by the envman format.
+func Environ(activation provider.EnvironmentActivation, environ []string) []string {
+	result := make([]string, 0, len(environ)+len(activation.ContributedEnvVars)+1)
+	pathEnv := ""
+	for _, kv := range environ {
+		key, value, _ := strings.Cut(kv, "=")
+		if key == "PATH" {
+			pathEnv = value
+			continue
+		}
+		if _, ok := activation.ContributedEnvVars[key]; ok {
+			continue
+		}
+		result = append(result, kv)
+	}
+	for _, k := range sortedEnvVars(activation) {
+		result = append(result, k+"="+activation.ContributedEnvVars[k])
+	}
+	if len(activation.ContributedPaths) > 0 {
+		pathEnv = prependPath(pathEnv, strings.Join(activation.ContributedPaths, ":"))
+	}
+	if pathEnv != "" {
+		return append(result, "PATH="+pathEnv)
+	}
+	return result
+}
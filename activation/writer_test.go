@@ -0,0 +1,213 @@
+package activation
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitrise-io/toolprovider/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testActivation = provider.EnvironmentActivation{
+	ContributedEnvVars: map[string]string{"JAVA_HOME": "/opt/java 21", "GOROOT": "/opt/go", "NOTE": "it's \"$x\"\nline2"},
+	ContributedPaths:   []string{"/opt/java 21/bin", "/opt/go/bin"},
+}
+
+func TestWriters(t *testing.T) {
+	t.Setenv("PATH", "/usr/bin:/bin")
+
+	tests := []struct {
+		format   Format
+		expected string
+		wantErr  string
+	}{
+		{
+			format: FormatPosix,
+			expected: `export GOROOT=/opt/go
+export JAVA_HOME='/opt/java 21'
+export NOTE='it'"'"'s "$x"
+line2'
+export PATH='/opt/java 21/bin:/opt/go/bin':"$PATH"
+`,
+		},
+		{
+			format: FormatFish,
+			expected: `set -gx GOROOT '/opt/go'
+set -gx JAVA_HOME '/opt/java 21'
+set -gx NOTE 'it\'s "$x"
+line2'
+set -gx PATH '/opt/java 21/bin' '/opt/go/bin' $PATH
+`,
+		},
+		{
+			format: FormatDotenv,
+			expected: `GOROOT="/opt/go"
+JAVA_HOME="/opt/java 21"
+NOTE="it's \"\$x\"\nline2"
+PATH="/opt/java 21/bin:/opt/go/bin:/usr/bin:/bin"
+`,
+		},
+		{
+			format: FormatJSON,
+			expected: `{
+  "env_vars": {
+    "GOROOT": "/opt/go",
+    "JAVA_HOME": "/opt/java 21",
+    "NOTE": "it's \"$x\"\nline2"
+  },
+  "paths": [
+    "/opt/java 21/bin",
+    "/opt/go/bin"
+  ]
+}
+`,
+		},
+		{
+			format:  FormatGitLab,
+			wantErr: "$NOTE: multi-line values are not supported in this format",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var out strings.Builder
+			writer, err := NewWriter(tt.format, &out)
+			require.NoError(t, err)
+
+			err = writer.Write(testActivation)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, out.String())
+		})
+	}
+}
+
+func TestGitLabWriter(t *testing.T) {
+	t.Setenv("PATH", "/usr/bin:/bin")
+
+	var out strings.Builder
+	writer, err := NewWriter(FormatGitLab, &out)
+	require.NoError(t, err)
+
+	err = writer.Write(provider.EnvironmentActivation{
+		ContributedEnvVars: map[string]string{"JAVA_HOME": "/opt/java 21"},
+		ContributedPaths:   []string{"/opt/java 21/bin"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "JAVA_HOME=/opt/java 21\nPATH=/opt/java 21/bin:/usr/bin:/bin\n", out.String())
+}
+
+func TestGitHubWriter(t *testing.T) {
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, "github_env")
+	pathFile := filepath.Join(dir, "github_path")
+	require.NoError(t, os.WriteFile(envFile, []byte("EXISTING=1\n"), 0644))
+	t.Setenv("GITHUB_ENV", envFile)
+	t.Setenv("GITHUB_PATH", pathFile)
+
+	writer, err := NewWriter(FormatGitHub, nil)
+	require.NoError(t, err)
+	require.NoError(t, writer.Write(testActivation))
+
+	env, err := os.ReadFile(envFile)
+	require.NoError(t, err)
+	assert.Equal(t, "EXISTING=1\nGOROOT=/opt/go\nJAVA_HOME=/opt/java 21\nNOTE<<TOOLPROVIDER_EOF\nit's \"$x\"\nline2\nTOOLPROVIDER_EOF\n", string(env))
+
+	paths, err := os.ReadFile(pathFile)
+	require.NoError(t, err)
+	assert.Equal(t, "/opt/go/bin\n/opt/java 21/bin\n", string(paths))
+
+	t.Setenv("GITHUB_PATH", "")
+	_, err = NewWriter(FormatGitHub, nil)
+	assert.Error(t, err)
+}
+
+func TestUnknownFormat(t *testing.T) {
+	_, err := NewWriter("powershell", nil)
+	assert.EqualError(t, err, "unknown activation format: powershell, expected one of: envman, posix, fish, dotenv, json, github, gitlab")
+}
+
+func TestPrependPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		pathEnv  string
+		newPath  string
+		expected string
+	}{
+		{
+			name:     "empty path env",
+			pathEnv:  "",
+			newPath:  "/usr/local/bin",
+			expected: "/usr/local/bin",
+		},
+		{
+			name:     "prepend to existing path",
+			pathEnv:  "/usr/bin:/bin",
+			newPath:  "/usr/local/bin",
+			expected: "/usr/local/bin:/usr/bin:/bin",
+		},
+		{
+			name:     "remove duplicate and prepend",
+			pathEnv:  "/usr/bin:/usr/local/bin:/bin",
+			newPath:  "/usr/local/bin",
+			expected: "/usr/local/bin:/usr/bin:/bin",
+		},
+		{
+			name:     "duplicate at end",
+			pathEnv:  "/usr/bin:/bin:/usr/local/bin",
+			newPath:  "/usr/local/bin",
+			expected: "/usr/local/bin:/usr/bin:/bin",
+		},
+		{
+			name:     "single path duplicate",
+			pathEnv:  "/usr/local/bin",
+			newPath:  "/usr/local/bin",
+			expected: "/usr/local/bin",
+		},
+		{
+			name:     "empty new path",
+			pathEnv:  "/usr/bin:/bin",
+			newPath:  "",
+			expected: ":/usr/bin:/bin",
+		},
+		{
+			name:     "multiple duplicates",
+			pathEnv:  "/usr/local/bin:/usr/bin:/usr/local/bin:/bin:/usr/local/bin",
+			newPath:  "/usr/local/bin",
+			expected: "/usr/local/bin:/usr/bin:/bin",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := prependPath(tt.pathEnv, tt.newPath)
+			if result != tt.expected {
+				t.Errorf("prependPath(%q, %q) = %q, want %q", tt.pathEnv, tt.newPath, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEnviron(t *testing.T) {
+	activation := provider.EnvironmentActivation{
+		ContributedEnvVars: map[string]string{"GOROOT": "/golang/1.22", "NODE_HOME": "/nodejs/20"},
+		ContributedPaths:   []string{"/golang/bin", "/nodejs/bin"},
+	}
+	environ := []string{"HOME=/home/user", "GOROOT=/usr/local/go", "PATH=/usr/bin:/nodejs/bin:/golang/bin:/bin", "EMPTY="}
+
+	assert.Equal(t, []string{
+		"HOME=/home/user",
+		"EMPTY=",
+		"GOROOT=/golang/1.22",
+		"NODE_HOME=/nodejs/20",
+		"PATH=/golang/bin:/nodejs/bin:/usr/bin:/nodejs/bin:/golang/bin:/bin",
+	}, Environ(activation, environ))
+
+	assert.Equal(t, []string{"HOME=/home/user"}, Environ(provider.EnvironmentActivation{}, []string{"HOME=/home/user"}))
+}
@@ -0,0 +1,197 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"golang.org/x/exp/maps"
+	"gopkg.in/yaml.v3"
+
+	"github.com/bitrise-io/toolprovider/provider"
+)
+
+const DefaultLockfileName = "bitrise.tools.lock"
+
+const lockfileHeader = "# This file is generated by toolprovider, do not edit it manually.\n"
+
+// Lockfile records the concrete versions that the tool declarations resolved to in a previous run,
+// so that subsequent runs can reproduce the exact same toolchain.
+// The declarations of a workflow are merged over the global ones (see ParseWorkflowToolDeclarations()),
+// so every workflow is locked separately from the global declarations and from each other.
+type Lockfile struct {
+	// Tools are locked by runs without a workflow.
+	Tools []LockedTool `yaml:"tools,omitempty"`
+	// Workflows are the tools locked by the runs of each workflow, by workflow ID.
+	Workflows map[string]WorkflowLock `yaml:"workflows,omitempty"`
+}
+
+type WorkflowLock struct {
+	Tools []LockedTool `yaml:"tools"`
+}
+
+// ToolsOf returns the tools locked by the runs of a workflow, or by runs without a workflow if workflowID is empty.
+func (l Lockfile) ToolsOf(workflowID string) []LockedTool {
+	if workflowID == "" {
+		return l.Tools
+	}
+	return l.Workflows[workflowID].Tools
+}
+
+// SetTools replaces the tools locked by the runs of a workflow, or by runs without a workflow if workflowID is empty.
+// The entries of other workflows are kept.
+func (l *Lockfile) SetTools(workflowID string, tools []LockedTool) {
+	if workflowID == "" {
+		l.Tools = tools
+		return
+	}
+	workflows := make(map[string]WorkflowLock, len(l.Workflows)+1)
+	maps.Copy(workflows, l.Workflows)
+	workflows[workflowID] = WorkflowLock{Tools: tools}
+	l.Workflows = workflows
+}
+
+// AllTools returns the locked tools of every workflow and of runs without a workflow.
+func (l Lockfile) AllTools() []LockedTool {
+	all := slices.Clone(l.Tools)
+	workflowIDs := maps.Keys(l.Workflows)
+	slices.Sort(workflowIDs)
+	for _, workflowID := range workflowIDs {
+		all = append(all, l.Workflows[workflowID].Tools...)
+	}
+	return all
+}
+
+type LockedTool struct {
+	ProviderID string `yaml:"provider"`
+	// ToolName is the canonical tool name, see provider.GetCanonicalToolName().
+	ToolName           string `yaml:"tool"`
+	RequestedVersion   string `yaml:"requested_version"`
+	ResolutionStrategy string `yaml:"resolution"`
+	ConcreteVersion    string `yaml:"version"`
+}
+
+// ErrStaleLockfile is returned when the tool declarations changed since the lockfile was written.
+type ErrStaleLockfile struct {
+	Problems []string
+}
+
+func (e ErrStaleLockfile) Error() string {
+	return fmt.Sprintf("lockfile is out of date with the tool declarations:\n- %s", strings.Join(e.Problems, "\n- "))
+}
+
+func NewLockedTool(providerID string, request provider.ToolRequest, result provider.ToolInstallResult) LockedTool {
+	return LockedTool{
+		ProviderID:         providerID,
+		ToolName:           provider.GetCanonicalToolName(request.ToolName),
+		RequestedVersion:   request.UnparsedVersion,
+		ResolutionStrategy: request.ResolutionStrategy.String(),
+		ConcreteVersion:    result.ConcreteVersion,
+	}
+}
+
+func ReadLockfile(path string) (Lockfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Lockfile{}, fmt.Errorf("read lockfile: %w", err)
+	}
+
+	var lockfile Lockfile
+	err = yaml.Unmarshal(data, &lockfile)
+	if err != nil {
+		return Lockfile{}, fmt.Errorf("parse lockfile %s: %w", path, err)
+	}
+
+	for _, t := range lockfile.AllTools() {
+		if t.ToolName == "" || t.ConcreteVersion == "" {
+			return Lockfile{}, fmt.Errorf("parse lockfile %s: tool and version are required in every entry", path)
+		}
+	}
+
+	return lockfile, nil
+}
+
+// WriteLockfile writes the lockfile with entries sorted by tool name, so that the file is stable across runs.
+func WriteLockfile(path string, lockfile Lockfile) error {
+	sorted := Lockfile{Tools: sortedByToolName(lockfile.Tools)}
+	for workflowID, workflowLock := range lockfile.Workflows {
+		sorted.SetTools(workflowID, sortedByToolName(workflowLock.Tools))
+	}
+
+	data, err := yaml.Marshal(sorted)
+	if err != nil {
+		return fmt.Errorf("serialize lockfile: %w", err)
+	}
+
+	err = os.WriteFile(path, append([]byte(lockfileHeader), data...), 0644)
+	if err != nil {
+		return fmt.Errorf("write lockfile: %w", err)
+	}
+	return nil
+}
+
+func sortedByToolName(tools []LockedTool) []LockedTool {
+	sorted := slices.Clone(tools)
+	slices.SortFunc(sorted, func(a, b LockedTool) int {
+		return strings.Compare(a.ToolName, b.ToolName)
+	})
+	return sorted
+}
+
+// FreezeToolRequests turns every tool declaration into a strict request for the version recorded in the lockfile
+// for the workflow (see Lockfile.ToolsOf()).
+// It returns ErrStaleLockfile if the declarations or their providers (see AssignProviders()) don't match the lockfile anymore.
+// A tool with a fallback chain of providers is pinned to the member of the chain that it's locked with.
+// An optional tool that isn't locked is kept as declared.
+func FreezeToolRequests(declarations map[string]provider.ToolRequest, lockfile Lockfile, workflowID string) (map[string]provider.ToolRequest, error) {
+	lockedTools := make(map[string]LockedTool, len(lockfile.ToolsOf(workflowID)))
+	for _, t := range lockfile.ToolsOf(workflowID) {
+		lockedTools[t.ToolName] = t
+	}
+
+	var problems []string
+	frozen := make(map[string]provider.ToolRequest, len(declarations))
+	declaredTools := make(map[string]bool, len(declarations))
+	for name, request := range declarations {
+		canonicalName := provider.GetCanonicalToolName(name)
+		declaredTools[canonicalName] = true
+
+		locked, ok := lockedTools[canonicalName]
+		if !ok && request.Optional {
+			// Optional tools that failed to install are left out of the lockfile, they are resolved from the declaration.
+			frozen[name] = request
+			continue
+		}
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s is declared but not locked", canonicalName))
+			continue
+		}
+		if locked.ProviderID != request.ProviderID && !slices.Contains(provider.FallbackChain(request.ProviderID), locked.ProviderID) {
+			problems = append(problems, fmt.Sprintf("%s is locked with provider %s, but the current provider is %s", canonicalName, locked.ProviderID, request.ProviderID))
+		}
+		if locked.RequestedVersion != request.UnparsedVersion || locked.ResolutionStrategy != request.ResolutionStrategy.String() {
+			problems = append(problems, fmt.Sprintf("%s is declared as %s (%s), but locked as %s (%s)",
+				canonicalName, request.UnparsedVersion, request.ResolutionStrategy, locked.RequestedVersion, locked.ResolutionStrategy))
+		}
+
+		frozenRequest := request
+		frozenRequest.UnparsedVersion = locked.ConcreteVersion
+		frozenRequest.ResolutionStrategy = provider.ResolutionStrategyStrict
+		frozenRequest.ProviderID = locked.ProviderID
+		frozen[name] = frozenRequest
+	}
+
+	for _, t := range lockfile.ToolsOf(workflowID) {
+		if !declaredTools[t.ToolName] {
+			problems = append(problems, fmt.Sprintf("%s is locked but not declared anymore", t.ToolName))
+		}
+	}
+
+	if len(problems) > 0 {
+		slices.Sort(problems)
+		return nil, ErrStaleLockfile{Problems: problems}
+	}
+
+	return frozen, nil
+}
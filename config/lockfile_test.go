@@ -0,0 +1,144 @@
+package config_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/toolprovider/config"
+	"github.com/bitrise-io/toolprovider/provider"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockfileRoundtrip(t *testing.T) {
+	lockfile := config.Lockfile{
+		Tools: []config.LockedTool{
+			config.NewLockedTool("asdf",
+				provider.ToolRequest{ToolName: "ruby", UnparsedVersion: "3.2", ResolutionStrategy: provider.ResolutionStrategyLatestReleased},
+				provider.ToolInstallResult{ToolName: "ruby", ConcreteVersion: "3.2.8"},
+			),
+			config.NewLockedTool("asdf",
+				provider.ToolRequest{ToolName: "node", UnparsedVersion: "20", ResolutionStrategy: provider.ResolutionStrategyLatestInstalled},
+				provider.ToolInstallResult{ToolName: "nodejs", ConcreteVersion: "20.19.3"},
+			),
+		},
+	}
+	lockfile.SetTools("deploy", []config.LockedTool{
+		{ProviderID: "mise", ToolName: "ruby", RequestedVersion: "3.3", ResolutionStrategy: "closest_released", ConcreteVersion: "3.3.6"},
+		{ProviderID: "mise", ToolName: "golang", RequestedVersion: "1.23", ResolutionStrategy: "closest_released", ConcreteVersion: "1.23.4"},
+	})
+	path := filepath.Join(t.TempDir(), config.DefaultLockfileName)
+
+	err := config.WriteLockfile(path, lockfile)
+	require.NoError(t, err)
+
+	parsed, err := config.ReadLockfile(path)
+	require.NoError(t, err)
+	require.Equal(t, []config.LockedTool{
+		{ProviderID: "asdf", ToolName: "nodejs", RequestedVersion: "20", ResolutionStrategy: "closest_installed", ConcreteVersion: "20.19.3"},
+		{ProviderID: "asdf", ToolName: "ruby", RequestedVersion: "3.2", ResolutionStrategy: "closest_released", ConcreteVersion: "3.2.8"},
+	}, parsed.ToolsOf(""))
+	require.Equal(t, []config.LockedTool{
+		{ProviderID: "mise", ToolName: "golang", RequestedVersion: "1.23", ResolutionStrategy: "closest_released", ConcreteVersion: "1.23.4"},
+		{ProviderID: "mise", ToolName: "ruby", RequestedVersion: "3.3", ResolutionStrategy: "closest_released", ConcreteVersion: "3.3.6"},
+	}, parsed.ToolsOf("deploy"))
+	require.Empty(t, parsed.ToolsOf("primary"))
+	require.Len(t, parsed.AllTools(), 4)
+}
+
+func TestFreezeToolRequests(t *testing.T) {
+	lockfile := config.Lockfile{
+		Tools: []config.LockedTool{
+			{ProviderID: "asdf", ToolName: "nodejs", RequestedVersion: "20", ResolutionStrategy: "closest_installed", ConcreteVersion: "20.19.3"},
+			{ProviderID: "asdf", ToolName: "ruby", RequestedVersion: "3.2", ResolutionStrategy: "closest_released", ConcreteVersion: "3.2.8"},
+		},
+		Workflows: map[string]config.WorkflowLock{
+			"deploy": {Tools: []config.LockedTool{
+				{ProviderID: "asdf", ToolName: "nodejs", RequestedVersion: "22", ResolutionStrategy: "closest_installed", ConcreteVersion: "22.1.0"},
+			}},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		workflowID   string
+		declarations map[string]provider.ToolRequest
+		expected     map[string]provider.ToolRequest
+		wantProblems []string
+	}{
+		{
+			name: "up to date",
+			declarations: map[string]provider.ToolRequest{
+				"node": {ToolName: "node", UnparsedVersion: "20", ResolutionStrategy: provider.ResolutionStrategyLatestInstalled, ProviderID: "asdf"},
+				"ruby": {ToolName: "ruby", UnparsedVersion: "3.2", ResolutionStrategy: provider.ResolutionStrategyLatestReleased, ProviderID: "asdf"},
+			},
+			expected: map[string]provider.ToolRequest{
+				"node": {ToolName: "node", UnparsedVersion: "20.19.3", ResolutionStrategy: provider.ResolutionStrategyStrict, ProviderID: "asdf"},
+				"ruby": {ToolName: "ruby", UnparsedVersion: "3.2.8", ResolutionStrategy: provider.ResolutionStrategyStrict, ProviderID: "asdf"},
+			},
+		},
+		{
+			name: "changed version, new and removed tool",
+			declarations: map[string]provider.ToolRequest{
+				"node":   {ToolName: "node", UnparsedVersion: "22", ResolutionStrategy: provider.ResolutionStrategyLatestInstalled, ProviderID: "asdf"},
+				"python": {ToolName: "python", UnparsedVersion: "3.12", ResolutionStrategy: provider.ResolutionStrategyStrict, ProviderID: "asdf"},
+			},
+			wantProblems: []string{
+				"nodejs is declared as 22 (closest_installed), but locked as 20 (closest_installed)",
+				"python is declared but not locked",
+				"ruby is locked but not declared anymore",
+			},
+		},
+		{
+			name: "fallback chain is pinned to the locked provider",
+			declarations: map[string]provider.ToolRequest{
+				"node": {ToolName: "node", UnparsedVersion: "20", ResolutionStrategy: provider.ResolutionStrategyLatestInstalled, ProviderID: "mise,asdf"},
+				"ruby": {ToolName: "ruby", UnparsedVersion: "3.2", ResolutionStrategy: provider.ResolutionStrategyLatestReleased, ProviderID: "asdf"},
+			},
+			expected: map[string]provider.ToolRequest{
+				"node": {ToolName: "node", UnparsedVersion: "20.19.3", ResolutionStrategy: provider.ResolutionStrategyStrict, ProviderID: "asdf"},
+				"ruby": {ToolName: "ruby", UnparsedVersion: "3.2.8", ResolutionStrategy: provider.ResolutionStrategyStrict, ProviderID: "asdf"},
+			},
+		},
+		{
+			name: "different provider",
+			declarations: map[string]provider.ToolRequest{
+				"nodejs": {ToolName: "nodejs", UnparsedVersion: "20", ResolutionStrategy: provider.ResolutionStrategyLatestInstalled, ProviderID: "mise"},
+				"ruby":   {ToolName: "ruby", UnparsedVersion: "3.2", ResolutionStrategy: provider.ResolutionStrategyLatestReleased, ProviderID: "mise,direct"},
+			},
+			wantProblems: []string{
+				"nodejs is locked with provider asdf, but the current provider is mise",
+				"ruby is locked with provider asdf, but the current provider is mise,direct",
+			},
+		},
+		{
+			name:       "workflow",
+			workflowID: "deploy",
+			declarations: map[string]provider.ToolRequest{
+				"node": {ToolName: "node", UnparsedVersion: "22", ResolutionStrategy: provider.ResolutionStrategyLatestInstalled, ProviderID: "asdf"},
+			},
+			expected: map[string]provider.ToolRequest{
+				"node": {ToolName: "node", UnparsedVersion: "22.1.0", ResolutionStrategy: provider.ResolutionStrategyStrict, ProviderID: "asdf"},
+			},
+		},
+		{
+			name:       "workflow that is not locked",
+			workflowID: "primary",
+			declarations: map[string]provider.ToolRequest{
+				"node": {ToolName: "node", UnparsedVersion: "20", ResolutionStrategy: provider.ResolutionStrategyLatestInstalled, ProviderID: "asdf"},
+			},
+			wantProblems: []string{"nodejs is declared but not locked"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frozen, err := config.FreezeToolRequests(tt.declarations, lockfile, tt.workflowID)
+			if tt.wantProblems != nil {
+				require.Equal(t, config.ErrStaleLockfile{Problems: tt.wantProblems}, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, frozen)
+		})
+	}
+}
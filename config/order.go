@@ -0,0 +1,127 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/bitrise-io/toolprovider/provider"
+)
+
+// DependencyCycleError is returned when tools depend on each other through depends_on.
+type DependencyCycleError struct {
+	// Cycle lists the tools of the cycle, starting and ending with the same tool.
+	Cycle []string
+}
+
+func (e DependencyCycleError) Error() string {
+	return fmt.Sprintf("dependency cycle between tools: %s", strings.Join(e.Cycle, " -> "))
+}
+
+// DeclarationOrder returns the canonical tool names in the order they are declared in bitrise.yml.
+// If workflowID is not empty, the global tools block comes first, followed by the blocks of ParseWorkflowToolDeclarations()
+// in the same order. A tool keeps the position of its first declaration even if a later block overrides it.
+func DeclarationOrder(bitriseYml BitriseYml, workflowID string) []string {
+	var order []string
+	addBlock := func(meta *yaml.Node) {
+		for _, entry := range mappingEntries(lookup(lookup(meta, keyExperimental), keyToolDeclarations)) {
+			toolName := provider.GetCanonicalToolName(entry.key.Value)
+			if !slices.Contains(order, toolName) {
+				order = append(order, toolName)
+			}
+		}
+	}
+
+	addBlock(lookup(bitriseYml.root, keyMeta))
+	if workflowID != "" {
+		workflows := lookup(bitriseYml.root, keyWorkflows)
+		for _, id := range toolBlockChain(bitriseYml.BitriseDataModel, workflowID) {
+			addBlock(lookup(lookup(workflows, id), keyMeta))
+		}
+	}
+	return order
+}
+
+// InstallOrder returns the tool requests in the order they should be installed and activated.
+// Dependencies (see ToolRequest.DependsOn) are always installed before their dependents, otherwise tools follow
+// declarationOrder (see DeclarationOrder()). Tools missing from declarationOrder (e.g. the ones coming from version files)
+// come after the declared ones, sorted by name.
+func InstallOrder(declarations map[string]provider.ToolRequest, declarationOrder []string) ([]provider.ToolRequest, error) {
+	requests := make(map[string]provider.ToolRequest, len(declarations))
+	for name, request := range declarations {
+		requests[provider.GetCanonicalToolName(name)] = request
+	}
+
+	var order []string
+	for _, name := range declarationOrder {
+		if _, ok := requests[name]; ok && !slices.Contains(order, name) {
+			order = append(order, name)
+		}
+	}
+	var undeclared []string
+	for name := range requests {
+		if !slices.Contains(order, name) {
+			undeclared = append(undeclared, name)
+		}
+	}
+	slices.Sort(undeclared)
+	order = append(order, undeclared...)
+
+	for _, name := range order {
+		for _, dependency := range requests[name].DependsOn {
+			if _, ok := requests[dependency]; !ok {
+				return nil, fmt.Errorf("%s depends on %s, but %s is not declared", name, dependency, dependency)
+			}
+		}
+	}
+
+	// Repeatedly pick the first tool whose dependencies are all installed already.
+	installed := make(map[string]bool, len(order))
+	sorted := make([]provider.ToolRequest, 0, len(order))
+	for len(sorted) < len(order) {
+		next := slices.IndexFunc(order, func(name string) bool {
+			if installed[name] {
+				return false
+			}
+			for _, dependency := range requests[name].DependsOn {
+				if !installed[dependency] {
+					return false
+				}
+			}
+			return true
+		})
+		if next == -1 {
+			return nil, DependencyCycleError{Cycle: findCycle(order, requests, installed)}
+		}
+		installed[order[next]] = true
+		sorted = append(sorted, requests[order[next]])
+	}
+	return sorted, nil
+}
+
+// findCycle walks the dependencies of the first tool that couldn't be installed until a tool repeats.
+// Every remaining tool has at least one remaining dependency, so the walk always runs into a cycle.
+func findCycle(order []string, requests map[string]provider.ToolRequest, installed map[string]bool) []string {
+	var path []string
+	for _, name := range order {
+		if !installed[name] {
+			path = append(path, name)
+			break
+		}
+	}
+	for {
+		current := path[len(path)-1]
+		for _, dependency := range requests[current].DependsOn {
+			if installed[dependency] {
+				continue
+			}
+			if start := slices.Index(path, dependency); start != -1 {
+				return append(path[start:], dependency)
+			}
+			path = append(path, dependency)
+			break
+		}
+	}
+}
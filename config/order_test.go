@@ -0,0 +1,125 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/toolprovider/config"
+	"github.com/bitrise-io/toolprovider/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeclarationOrder(t *testing.T) {
+	bitriseYml, err := config.ParseBitriseYml("testdata/dependencies.bitrise.yml")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"ruby", "flutter", "nodejs", "java"}, config.DeclarationOrder(bitriseYml, ""))
+	assert.Equal(t, []string{"ruby", "flutter", "nodejs", "java", "python"}, config.DeclarationOrder(bitriseYml, "test"))
+}
+
+func TestParseDependsOn(t *testing.T) {
+	bitriseYml, err := config.ParseBitriseYml("testdata/dependencies.bitrise.yml")
+	require.NoError(t, err)
+
+	toolDeclarations, err := config.ParseToolDeclarations(bitriseYml)
+	require.NoError(t, err)
+
+	// Dependencies are canonicalized, node becomes nodejs
+	assert.Equal(t, []string{"nodejs"}, toolDeclarations["ruby"].DependsOn)
+	assert.Equal(t, []string{"java"}, toolDeclarations["flutter"].DependsOn)
+	assert.Nil(t, toolDeclarations["java"].DependsOn)
+
+	requests, err := config.InstallOrder(toolDeclarations, config.DeclarationOrder(bitriseYml, ""))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"nodejs", "ruby", "java", "flutter"}, toolNames(requests))
+}
+
+func TestInstallOrder(t *testing.T) {
+	tests := []struct {
+		name             string
+		declarations     map[string]provider.ToolRequest
+		declarationOrder []string
+		expected         []string
+		wantErr          string
+	}{
+		{
+			name: "Declaration order without dependencies",
+			declarations: map[string]provider.ToolRequest{
+				"ruby":   {ToolName: "ruby"},
+				"nodejs": {ToolName: "nodejs"},
+				"golang": {ToolName: "golang"},
+			},
+			declarationOrder: []string{"ruby", "nodejs", "golang"},
+			expected:         []string{"ruby", "nodejs", "golang"},
+		},
+		{
+			name: "Undeclared tools come last sorted by name",
+			declarations: map[string]provider.ToolRequest{
+				"python": {ToolName: "python"},
+				"ruby":   {ToolName: "ruby"},
+				"golang": {ToolName: "golang"},
+			},
+			declarationOrder: []string{"ruby"},
+			expected:         []string{"ruby", "golang", "python"},
+		},
+		{
+			name: "Dependencies come first",
+			declarations: map[string]provider.ToolRequest{
+				"flutter": {ToolName: "flutter", DependsOn: []string{"java"}},
+				"ruby":    {ToolName: "ruby", DependsOn: []string{"nodejs"}},
+				"nodejs":  {ToolName: "nodejs", DependsOn: []string{"python"}},
+				"java":    {ToolName: "java"},
+				"python":  {ToolName: "python"},
+			},
+			declarationOrder: []string{"flutter", "ruby", "nodejs", "java", "python"},
+			expected:         []string{"java", "flutter", "python", "nodejs", "ruby"},
+		},
+		{
+			name: "Alias declarations are matched by canonical name",
+			declarations: map[string]provider.ToolRequest{
+				"ruby": {ToolName: "ruby", DependsOn: []string{"nodejs"}},
+				"node": {ToolName: "node"},
+			},
+			declarationOrder: []string{"ruby", "nodejs"},
+			expected:         []string{"node", "ruby"},
+		},
+		{
+			name: "Missing dependency",
+			declarations: map[string]provider.ToolRequest{
+				"flutter": {ToolName: "flutter", DependsOn: []string{"java"}},
+			},
+			wantErr: "flutter depends on java, but java is not declared",
+		},
+		{
+			name: "Dependency cycle",
+			declarations: map[string]provider.ToolRequest{
+				"golang": {ToolName: "golang"},
+				"ruby":   {ToolName: "ruby", DependsOn: []string{"nodejs"}},
+				"nodejs": {ToolName: "nodejs", DependsOn: []string{"python"}},
+				"python": {ToolName: "python", DependsOn: []string{"ruby"}},
+			},
+			declarationOrder: []string{"golang", "nodejs", "ruby", "python"},
+			wantErr:          "dependency cycle between tools: nodejs -> python -> ruby -> nodejs",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests, err := config.InstallOrder(tt.declarations, tt.declarationOrder)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, toolNames(requests))
+		})
+	}
+}
+
+func toolNames(requests []provider.ToolRequest) []string {
+	names := make([]string, 0, len(requests))
+	for _, r := range requests {
+		names = append(names, r.ToolName)
+	}
+	return names
+}
@@ -1,119 +1,451 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"regexp"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/bitrise-io/bitrise/v2/bitrise"
 	"github.com/bitrise-io/bitrise/v2/models"
+	"gopkg.in/yaml.v3"
+
 	"github.com/bitrise-io/toolprovider/provider"
 )
 
+const keyMeta = "meta"
+const keyWorkflows = "workflows"
 const keyExperimental = "experimental"
 const keyToolDeclarations = "tools"
 const keyToolConfig = "tool_config"
-const latestSyntaxPattern = `(.*):latest$`
-const installedSyntaxPattern = `(.*):installed$`
 
-func ParseBitriseYml(path string) (models.BitriseDataModel, error) {
+var latestSyntaxPattern = regexp.MustCompile(`(.*):latest$`)
+var installedSyntaxPattern = regexp.MustCompile(`(.*):installed$`)
+
+// ErrNoToolDeclarations is returned when bitrise.yml has no tool declaration block at all.
+var ErrNoToolDeclarations = errors.New("no tool declarations")
+
+// BitriseYml is a parsed bitrise.yml. Besides the data model, it keeps the raw YAML document,
+// so that problems can be reported with their position in the file.
+type BitriseYml struct {
+	models.BitriseDataModel
+
+	root *yaml.Node
+}
+
+func ParseBitriseYml(path string) (BitriseYml, error) {
 	model, _, err := bitrise.ReadBitriseConfig(path, bitrise.ValidationTypeMinimal)
 	if err != nil {
-		return models.BitriseDataModel{}, fmt.Errorf("parse bitrise.yml: %v", err)
+		return BitriseYml{}, fmt.Errorf("parse bitrise.yml: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return BitriseYml{}, fmt.Errorf("read bitrise.yml: %w", err)
+	}
+	var document yaml.Node
+	err = yaml.Unmarshal(content, &document)
+	if err != nil {
+		return BitriseYml{}, fmt.Errorf("parse bitrise.yml: %w", err)
 	}
 
-	return model, nil
+	return BitriseYml{BitriseDataModel: model, root: documentRoot(&document)}, nil
 }
 
-func ParseToolDeclarations(bitriseYml models.BitriseDataModel) (map[string]provider.ToolRequest, error) {
-	if bitriseYml.Meta == nil {
-		return nil, fmt.Errorf("parse bitrise.yml: meta block is not defined")
+// BitriseYmlFromModel is for callers that only have the data model, e.g. when the config was assembled in memory.
+// Validation errors don't have line and column information in this case, and numeric versions are only as precise
+// as the decoded model (e.g. python: 3.10 was already decoded as 3.1).
+func BitriseYmlFromModel(model models.BitriseDataModel) (BitriseYml, error) {
+	// Only the blocks that are relevant for tool declarations are converted.
+	workflowMetas := make(map[string]any, len(model.Workflows))
+	for id, workflow := range model.Workflows {
+		workflowMetas[id] = map[string]any{keyMeta: workflow.Meta}
 	}
 
-	metaBlock := bitriseYml.Meta[keyExperimental].(map[string]any)
-	if metaBlock == nil {
-		return nil, fmt.Errorf("parse bitrise.yml: meta.%s block is not defined", keyExperimental)
+	var document yaml.Node
+	err := document.Encode(map[string]any{
+		keyMeta:      model.Meta,
+		keyWorkflows: workflowMetas,
+	})
+	if err != nil {
+		return BitriseYml{}, fmt.Errorf("convert bitrise.yml model: %w", err)
 	}
 
-	toolBlock := metaBlock[keyToolDeclarations].(map[string]any)
-	if toolBlock == nil {
-		return nil, fmt.Errorf("parse bitrise.yml: meta.%s.%s block is not defined", keyExperimental, keyToolDeclarations)
+	return BitriseYml{BitriseDataModel: model, root: documentRoot(&document)}, nil
+}
+
+func documentRoot(document *yaml.Node) *yaml.Node {
+	if document.Kind == yaml.DocumentNode && len(document.Content) > 0 {
+		return document.Content[0]
 	}
+	return document
+}
 
-	latestSyntaxPattern, err := regexp.Compile(latestSyntaxPattern)
-	if err != nil {
-		return nil, fmt.Errorf("failed to compile regex pattern: %v", err)
+// ValidateToolBlocks checks the tools and tool_config blocks of the whole bitrise.yml (including workflow-level blocks)
+// and returns every problem at once as ValidationErrors.
+func ValidateToolBlocks(bitriseYml BitriseYml) error {
+	var errs ValidationErrors
+
+	experimental := experimentalBlock(lookup(bitriseYml.root, keyMeta), keyMeta, &errs)
+	globalToolsPath := fmt.Sprintf("%s.%s.%s", keyMeta, keyExperimental, keyToolDeclarations)
+	if toolBlock := lookup(experimental, keyToolDeclarations); !isNull(toolBlock) {
+		parseToolBlock(toolBlock, globalToolsPath, &errs)
 	}
-	preinstalledSyntaxPattern, err := regexp.Compile(installedSyntaxPattern)
-	if err != nil {
-		return nil, fmt.Errorf("failed to compile regex pattern: %v", err)
+	parseToolConfigBlock(lookup(experimental, keyToolConfig), fmt.Sprintf("%s.%s.%s", keyMeta, keyExperimental, keyToolConfig), &errs)
+
+	for _, workflow := range mappingEntries(lookup(bitriseYml.root, keyWorkflows)) {
+		metaPath := fmt.Sprintf("%s.%s.%s", keyWorkflows, workflow.key.Value, keyMeta)
+		experimental := experimentalBlock(lookup(workflow.value, keyMeta), metaPath, &errs)
+		if toolBlock := lookup(experimental, keyToolDeclarations); !isNull(toolBlock) {
+			parseToolBlock(toolBlock, fmt.Sprintf("%s.%s.%s", metaPath, keyExperimental, keyToolDeclarations), &errs)
+		}
+		if toolConfig := lookup(experimental, keyToolConfig); !isNull(toolConfig) {
+			errs.add(toolConfig, fmt.Sprintf("%s.%s.%s", metaPath, keyExperimental, keyToolConfig), "%s is only supported in the top-level meta block", keyToolConfig)
+		}
+	}
+
+	return errs.orNil()
+}
+
+func ParseToolDeclarations(bitriseYml BitriseYml) (map[string]provider.ToolRequest, error) {
+	var errs ValidationErrors
+	experimental := experimentalBlock(lookup(bitriseYml.root, keyMeta), keyMeta, &errs)
+	if len(errs) > 0 {
+		return nil, errs.orNil()
+	}
+
+	blockPath := fmt.Sprintf("%s.%s.%s", keyMeta, keyExperimental, keyToolDeclarations)
+	toolBlock := lookup(experimental, keyToolDeclarations)
+	if isNull(toolBlock) {
+		return nil, fmt.Errorf("parse bitrise.yml: %s block is not defined: %w", blockPath, ErrNoToolDeclarations)
+	}
+
+	toolDeclarations := parseToolBlock(toolBlock, blockPath, &errs)
+	if len(errs) > 0 {
+		return nil, errs.orNil()
+	}
+	return toolDeclarations, nil
+}
+
+// ParseWorkflowToolDeclarations returns the tool declarations that apply when running the given workflow.
+// The global meta.experimental.tools block is merged with the tools blocks of the workflows in the before_run
+// and after_run chains (in run order), and finally with the workflow's own block. Each block overrides the previous
+// ones tool by tool.
+func ParseWorkflowToolDeclarations(bitriseYml BitriseYml, workflowID string) (map[string]provider.ToolRequest, error) {
+	if _, ok := bitriseYml.Workflows[workflowID]; !ok {
+		return nil, fmt.Errorf("parse bitrise.yml: workflow %s is not defined", workflowID)
+	}
+
+	var errs ValidationErrors
+	declarations := map[string]provider.ToolRequest{}
+	hasDeclarations := false
+
+	experimental := experimentalBlock(lookup(bitriseYml.root, keyMeta), keyMeta, &errs)
+	if toolBlock := lookup(experimental, keyToolDeclarations); !isNull(toolBlock) {
+		declarations = parseToolBlock(toolBlock, fmt.Sprintf("%s.%s.%s", keyMeta, keyExperimental, keyToolDeclarations), &errs)
+		hasDeclarations = true
+	}
+
+	workflows := lookup(bitriseYml.root, keyWorkflows)
+	for _, id := range toolBlockChain(bitriseYml.BitriseDataModel, workflowID) {
+		metaPath := fmt.Sprintf("%s.%s.%s", keyWorkflows, id, keyMeta)
+		experimental := experimentalBlock(lookup(lookup(workflows, id), keyMeta), metaPath, &errs)
+		toolBlock := lookup(experimental, keyToolDeclarations)
+		if isNull(toolBlock) {
+			continue
+		}
+
+		workflowDeclarations := parseToolBlock(toolBlock, fmt.Sprintf("%s.%s.%s", metaPath, keyExperimental, keyToolDeclarations), &errs)
+		declarations = MergeToolDeclarations(workflowDeclarations, declarations)
+		hasDeclarations = true
+	}
+
+	if len(errs) > 0 {
+		return nil, errs.orNil()
+	}
+	if !hasDeclarations {
+		return nil, fmt.Errorf("parse bitrise.yml: no tools block is defined for workflow %s: %w", workflowID, ErrNoToolDeclarations)
+	}
+	return declarations, nil
+}
+
+// toolBlockChain returns the workflow IDs whose tool blocks apply to the given workflow, in increasing order of precedence.
+// The selected workflow comes last so that its own block has the highest precedence.
+func toolBlockChain(bitriseYml models.BitriseDataModel, workflowID string) []string {
+	runChain := slices.DeleteFunc(workflowRunChain(bitriseYml, workflowID, map[string]bool{}), func(id string) bool {
+		return id == workflowID
+	})
+	return append(runChain, workflowID)
+}
+
+// workflowRunChain returns the workflow IDs in the order they run, expanding before_run and after_run recursively.
+func workflowRunChain(bitriseYml models.BitriseDataModel, workflowID string, visited map[string]bool) []string {
+	if visited[workflowID] {
+		return nil
+	}
+	visited[workflowID] = true
+
+	workflow := bitriseYml.Workflows[workflowID]
+	var chain []string
+	for _, id := range workflow.BeforeRun {
+		chain = append(chain, workflowRunChain(bitriseYml, id, visited)...)
+	}
+	chain = append(chain, workflowID)
+	for _, id := range workflow.AfterRun {
+		chain = append(chain, workflowRunChain(bitriseYml, id, visited)...)
+	}
+	return chain
+}
+
+// experimentalBlock returns the experimental block of a meta block, or nil if it's not defined.
+func experimentalBlock(meta *yaml.Node, metaPath string, errs *ValidationErrors) *yaml.Node {
+	if isNull(meta) {
+		return nil
+	}
+	if meta.Kind != yaml.MappingNode {
+		errs.add(meta, metaPath, "expected a map, got %s", nodeTypeName(meta))
+		return nil
+	}
+
+	experimental := lookup(meta, keyExperimental)
+	if isNull(experimental) {
+		return nil
+	}
+	if experimental.Kind != yaml.MappingNode {
+		errs.add(experimental, metaPath+"."+keyExperimental, "expected a map, got %s", nodeTypeName(experimental))
+		return nil
+	}
+	return experimental
+}
+
+func parseToolBlock(toolBlock *yaml.Node, blockPath string, errs *ValidationErrors) map[string]provider.ToolRequest {
+	if toolBlock.Kind != yaml.MappingNode {
+		errs.add(toolBlock, blockPath, "expected a map of tools, got %s", nodeTypeName(toolBlock))
+		return nil
 	}
 
 	toolDeclarations := make(map[string]provider.ToolRequest)
-	for toolName, toolData := range toolBlock {
-		// TODO: string or int
-		var versionString string
-		var pluginIdentifier *string
-
-		switch v := toolData.(type) {
-		case string:
-			// If it's a string, it should be a version string only.
-			versionString = strings.TrimSpace(v)
-		case map[string]any:
-			// If it's a map, it should contain version and optionally plugin fields.
-			ver, ok := v["version"].(string)
-			if !ok {
-				if v["version"] == nil {
-					// User aims to provide the shortest form, skipping version field, so we decide later what to do with no version.
-					ver = ""
-				} else {
-					return nil, fmt.Errorf("parse bitrise.yml: meta.%s.%s.%s.version is not a string", keyExperimental, keyToolDeclarations, toolName)
-				}
-			}
-			versionString = strings.TrimSpace(ver)
-			if pluginVal, ok := v["plugin"]; ok && pluginVal != nil {
-				pluginStr, ok := pluginVal.(string)
-				if !ok {
-					return nil, fmt.Errorf("parse bitrise.yml: meta.%s.%s.%s.plugin is not a string", keyExperimental, keyToolDeclarations, toolName)
-				}
-				pluginIdentifier = &pluginStr
-			}
-		default:
-			return nil, fmt.Errorf("parse bitrise.yml: meta.%s.%s.%s is not a string or map", keyExperimental, keyToolDeclarations, toolName)
-		}
-
-		var resolutionStrategy provider.ResolutionStrategy
-		var plainVersion string
-		if latestSyntaxPattern.MatchString(versionString) {
-			resolutionStrategy = provider.ResolutionStrategyLatestReleased
-			matches := latestSyntaxPattern.FindStringSubmatch(versionString)
-			if len(matches) > 1 {
-				plainVersion = matches[1]
-			} else {
-				return nil, fmt.Errorf("parse bitrise.yml: meta.%s.%s.%s.version does not match latest syntax: %s", keyExperimental, keyToolDeclarations, toolName, versionString)
-			}
-		} else if preinstalledSyntaxPattern.MatchString(versionString) {
-			resolutionStrategy = provider.ResolutionStrategyLatestInstalled
-			matches := preinstalledSyntaxPattern.FindStringSubmatch(versionString)
-			if len(matches) > 1 {
-				plainVersion = matches[1]
-			} else {
-				return nil, fmt.Errorf("parse bitrise.yml: meta.%s.%s.%s.version does not match preinstalled syntax: %s", keyExperimental, keyToolDeclarations, toolName, versionString)
+	declaredAs := make(map[string]string)
+	for _, entry := range mappingEntries(toolBlock) {
+		toolName := entry.key.Value
+		// node and nodejs are the same tool, declaring both would make it random which version is installed.
+		canonicalName := provider.GetCanonicalToolName(toolName)
+		if previous, ok := declaredAs[canonicalName]; ok {
+			errs.add(entry.key, blockPath+"."+toolName, "%s is already declared as %s", toolName, previous)
+			continue
+		}
+		declaredAs[canonicalName] = toolName
+
+		request, ok := parseToolDeclaration(toolName, entry.value, blockPath+"."+toolName, errs)
+		if ok {
+			toolDeclarations[toolName] = request
+		}
+	}
+	return toolDeclarations
+}
+
+func parseToolDeclaration(toolName string, toolData *yaml.Node, toolPath string, errs *ValidationErrors) (provider.ToolRequest, bool) {
+	errCount := len(*errs)
+
+	var versionNode *yaml.Node
+	versionPath := toolPath
+	var pluginIdentifier *string
+	var providerID string
+	var postInstall []string
+	var dependsOn []string
+	var optional bool
+
+	switch {
+	case toolData.Kind == yaml.ScalarNode && !isNull(toolData):
+		// If it's a scalar, it should be a version string only.
+		versionNode = toolData
+	case toolData.Kind == yaml.MappingNode:
+		// If it's a map, it should contain version and optionally plugin fields.
+		// If version is missing, the user aims to provide the shortest form, so we decide later what to do with no version.
+		for _, field := range mappingEntries(toolData) {
+			fieldPath := toolPath + "." + field.key.Value
+			switch field.key.Value {
+			case "version":
+				versionNode = field.value
+				versionPath = fieldPath
+			case "plugin":
+				pluginIdentifier = parsePluginIdentifier(field.value, fieldPath, errs)
+			case "provider":
+				providerID = parseProviderID(field.value, fieldPath, errs)
+			case "post_install":
+				postInstall = parsePostInstall(field.value, fieldPath, errs)
+			case "depends_on":
+				dependsOn = parseDependsOn(toolName, field.value, fieldPath, errs)
+			case "optional":
+				optional = parseBool(field.value, fieldPath, errs)
+			default:
+				errs.add(field.key, fieldPath, "unknown key, expected one of: version, plugin, provider, post_install, depends_on, optional")
 			}
+		}
+	default:
+		errs.add(toolData, toolPath, "expected a version string or a map, got %s", nodeTypeName(toolData))
+		return provider.ToolRequest{}, false
+	}
+
+	var versionString string
+	versionNode = resolveAlias(versionNode)
+	if !isNull(versionNode) {
+		if !isVersionScalar(versionNode) {
+			errs.add(versionNode, versionPath, "expected a version string or number, got %s", nodeTypeName(versionNode))
 		} else {
-			resolutionStrategy = provider.ResolutionStrategyStrict
-			plainVersion = versionString
+			// The raw value is used instead of the decoded one, so that numbers keep what the user typed,
+			// e.g. 3.10 stays 3.10 instead of becoming 3.1 as a float.
+			versionString = strings.TrimSpace(versionNode.Value)
 		}
+	}
 
-		toolDeclarations[toolName] = provider.ToolRequest{
-			ToolName:           toolName,
-			UnparsedVersion:    plainVersion,
-			ResolutionStrategy: resolutionStrategy,
-			PluginIdentifier:   pluginIdentifier,
+	var resolutionStrategy provider.ResolutionStrategy
+	var plainVersion string
+	if provider.IsVersionConstraint(versionString) {
+		if latestSyntaxPattern.MatchString(versionString) || installedSyntaxPattern.MatchString(versionString) {
+			errs.add(versionNode, versionPath, "a version constraint can't be combined with :latest or :installed: %s", versionString)
+		} else if _, err := provider.ParseVersionConstraint(versionString); err != nil {
+			errs.add(versionNode, versionPath, "%s", err)
 		}
+		resolutionStrategy = provider.ResolutionStrategyConstraint
+		plainVersion = versionString
+	} else if matches := latestSyntaxPattern.FindStringSubmatch(versionString); matches != nil {
+		resolutionStrategy = provider.ResolutionStrategyLatestReleased
+		plainVersion = matches[1]
+	} else if matches := installedSyntaxPattern.FindStringSubmatch(versionString); matches != nil {
+		resolutionStrategy = provider.ResolutionStrategyLatestInstalled
+		plainVersion = matches[1]
+	} else {
+		resolutionStrategy = provider.ResolutionStrategyAuto
+		plainVersion = versionString
 	}
 
-	return toolDeclarations, nil
+	if len(*errs) > errCount {
+		return provider.ToolRequest{}, false
+	}
+	return provider.ToolRequest{
+		ToolName:           toolName,
+		UnparsedVersion:    plainVersion,
+		ResolutionStrategy: resolutionStrategy,
+		PluginIdentifier:   pluginIdentifier,
+		ProviderID:         providerID,
+		PostInstall:        postInstall,
+		DependsOn:          dependsOn,
+		Optional:           optional,
+	}, true
+}
+
+// isVersionScalar reports whether the node can be used as a version: a string, an integer (node: 20)
+// or a float (python: 3.10).
+func isVersionScalar(node *yaml.Node) bool {
+	if node.Kind != yaml.ScalarNode {
+		return false
+	}
+	return node.Tag == "!!str" || node.Tag == "!!int" || node.Tag == "!!float"
+}
+
+func parsePluginIdentifier(node *yaml.Node, path string, errs *ValidationErrors) *string {
+	node = resolveAlias(node)
+	if isNull(node) {
+		return nil
+	}
+	if node.Kind != yaml.ScalarNode || node.Tag != "!!str" {
+		errs.add(node, path, "expected a string, got %s", nodeTypeName(node))
+		return nil
+	}
+	if strings.TrimSpace(node.Value) == "" {
+		errs.add(node, path, "plugin must not be empty")
+		return nil
+	}
+	if _, _, err := provider.ParsePluginIdentifier(strings.TrimSpace(node.Value)); err != nil {
+		errs.add(node, path, "%s", err)
+		return nil
+	}
+
+	pluginIdentifier := node.Value
+	return &pluginIdentifier
+}
+
+// parsePostInstall accepts either a single script or a list of commands. A script becomes a single command.
+func parsePostInstall(node *yaml.Node, path string, errs *ValidationErrors) []string {
+	if isNull(node) {
+		return nil
+	}
+
+	var items []*yaml.Node
+	switch node.Kind {
+	case yaml.ScalarNode:
+		items = []*yaml.Node{node}
+	case yaml.SequenceNode:
+		items = node.Content
+	default:
+		errs.add(node, path, "expected a script or a list of commands, got %s", nodeTypeName(node))
+		return nil
+	}
+
+	var commands []string
+	for i, item := range items {
+		itemPath := path
+		if node.Kind == yaml.SequenceNode {
+			itemPath = fmt.Sprintf("%s[%d]", path, i)
+		}
+		item = resolveAlias(item)
+		if item.Kind != yaml.ScalarNode || item.Tag != "!!str" {
+			errs.add(item, itemPath, "expected a string, got %s", nodeTypeName(item))
+			continue
+		}
+		if strings.TrimSpace(item.Value) == "" {
+			errs.add(item, itemPath, "post_install command must not be empty")
+			continue
+		}
+		commands = append(commands, item.Value)
+	}
+	return commands
+}
+
+// parseDependsOn accepts a single tool name or a list of tool names. Names are canonicalized.
+// Whether the dependencies are declared at all is checked by InstallOrder(), as they can come from any declaration source.
+func parseDependsOn(toolName string, node *yaml.Node, path string, errs *ValidationErrors) []string {
+	if isNull(node) {
+		return nil
+	}
+
+	var items []*yaml.Node
+	switch node.Kind {
+	case yaml.ScalarNode:
+		items = []*yaml.Node{node}
+	case yaml.SequenceNode:
+		items = node.Content
+	default:
+		errs.add(node, path, "expected a tool name or a list of tool names, got %s", nodeTypeName(node))
+		return nil
+	}
+
+	var dependencies []string
+	for i, item := range items {
+		itemPath := path
+		if node.Kind == yaml.SequenceNode {
+			itemPath = fmt.Sprintf("%s[%d]", path, i)
+		}
+		item = resolveAlias(item)
+		if item.Kind != yaml.ScalarNode || item.Tag != "!!str" {
+			errs.add(item, itemPath, "expected a tool name, got %s", nodeTypeName(item))
+			continue
+		}
+		dependency := provider.GetCanonicalToolName(strings.TrimSpace(item.Value))
+		switch {
+		case dependency == "":
+			errs.add(item, itemPath, "tool name must not be empty")
+		case dependency == provider.GetCanonicalToolName(toolName):
+			errs.add(item, itemPath, "a tool can't depend on itself")
+		case !slices.Contains(dependencies, dependency):
+			dependencies = append(dependencies, dependency)
+		}
+	}
+	return dependencies
 }
 
 func defaultToolConfig() ToolConfig {
@@ -122,37 +454,196 @@ func defaultToolConfig() ToolConfig {
 	}
 }
 
-func ParseToolConfig(bitriseYml models.BitriseDataModel) (ToolConfig, error) {
-	if bitriseYml.Meta == nil {
-		return ToolConfig{}, fmt.Errorf("parse bitrise.yml: meta block is not defined")
+// ParseToolConfig returns the default config if the tool_config block is not defined.
+func ParseToolConfig(bitriseYml BitriseYml) (ToolConfig, error) {
+	var errs ValidationErrors
+	experimental := experimentalBlock(lookup(bitriseYml.root, keyMeta), keyMeta, &errs)
+	blockPath := fmt.Sprintf("%s.%s.%s", keyMeta, keyExperimental, keyToolConfig)
+	toolConfig := parseToolConfigBlock(lookup(experimental, keyToolConfig), blockPath, &errs)
+	if len(errs) > 0 {
+		return ToolConfig{}, errs.orNil()
 	}
+	return toolConfig, nil
+}
 
-	metaBlock := bitriseYml.Meta[keyExperimental].(map[string]any)
-	if metaBlock == nil {
-		return ToolConfig{}, fmt.Errorf("parse bitrise.yml: meta.%s block is not defined", keyExperimental)
+func parseToolConfigBlock(toolConfigBlock *yaml.Node, blockPath string, errs *ValidationErrors) ToolConfig {
+	toolConfig := defaultToolConfig()
+	if isNull(toolConfigBlock) {
+		return toolConfig // No explicit tool config, return default
+	}
+	if toolConfigBlock.Kind != yaml.MappingNode {
+		errs.add(toolConfigBlock, blockPath, "expected a map, got %s", nodeTypeName(toolConfigBlock))
+		return toolConfig
 	}
 
-	toolConfig := defaultToolConfig()
+	for _, field := range mappingEntries(toolConfigBlock) {
+		fieldPath := blockPath + "." + field.key.Value
+		switch field.key.Value {
+		case "provider":
+			if providerID := parseProviderID(field.value, fieldPath, errs); providerID != "" {
+				toolConfig.Provider = providerID
+			}
+		case "providers":
+			if field.value.Kind != yaml.MappingNode {
+				errs.add(field.value, fieldPath, "expected a map of tool names to providers, got %s", nodeTypeName(field.value))
+				continue
+			}
+			toolConfig.Providers = make(map[string]string)
+			for _, override := range mappingEntries(field.value) {
+				if providerID := parseProviderID(override.value, fieldPath+"."+override.key.Value, errs); providerID != "" {
+					toolConfig.Providers[override.key.Value] = providerID
+				}
+			}
+		case "continue_on_error":
+			toolConfig.ContinueOnError = parseBool(field.value, fieldPath, errs)
+		case "parallelism":
+			toolConfig.Parallelism = parsePositiveInt(field.value, fieldPath, errs)
+		case "use_native_tools":
+			toolConfig.UseNativeTools = parseBool(field.value, fieldPath, errs)
+		case "tool_timeout":
+			toolConfig.ToolTimeout = parsePositiveDuration(field.value, fieldPath, errs)
+		case "run_timeout":
+			toolConfig.RunTimeout = parsePositiveDuration(field.value, fieldPath, errs)
+		case "provider_options":
+			if field.value.Kind != yaml.MappingNode {
+				errs.add(field.value, fieldPath, "expected a map of providers to their options, got %s", nodeTypeName(field.value))
+				continue
+			}
+			toolConfig.ProviderOptions = make(map[string]provider.ProviderOptions)
+			for _, options := range mappingEntries(field.value) {
+				toolConfig.ProviderOptions[options.key.Value] = parseProviderOptions(options.value, fieldPath+"."+options.key.Value, errs)
+			}
+		default:
+			errs.add(field.key, fieldPath, "unknown key, expected one of: provider, providers, continue_on_error, parallelism, use_native_tools, tool_timeout, run_timeout, provider_options")
+		}
+	}
+
+	return toolConfig
+}
+
+func parseProviderOptions(node *yaml.Node, path string, errs *ValidationErrors) provider.ProviderOptions {
+	var opts provider.ProviderOptions
+	if node.Kind != yaml.MappingNode {
+		errs.add(node, path, "expected a map, got %s", nodeTypeName(node))
+		return opts
+	}
 
-	toolConfigBlock, exists := metaBlock[keyToolConfig]
-	if !exists {
-		return toolConfig, nil // No explicit tool config, return default
+	for _, field := range mappingEntries(node) {
+		fieldPath := path + "." + field.key.Value
+		switch field.key.Value {
+		case "data_dir":
+			opts.DataDir = parseOptionString(field.value, fieldPath, errs)
+		case "install_dir":
+			opts.InstallDir = parseOptionString(field.value, fieldPath, errs)
+		case "version":
+			opts.Version = parseOptionString(field.value, fieldPath, errs)
+		case "shell_init":
+			opts.ShellInit = parseOptionString(field.value, fieldPath, errs)
+		case "manifest":
+			opts.Manifest = parseOptionString(field.value, fieldPath, errs)
+		default:
+			errs.add(field.key, fieldPath, "unknown key, expected one of: data_dir, install_dir, version, shell_init, manifest")
+		}
 	}
+	return opts
+}
 
-	toolConfigMap, ok := toolConfigBlock.(map[string]any)
-	if !ok {
-		return ToolConfig{}, fmt.Errorf("parse bitrise.yml: meta.%s.%s block is not a map", keyExperimental, keyToolConfig)
+func parseBool(node *yaml.Node, path string, errs *ValidationErrors) bool {
+	node = resolveAlias(node)
+	if node.Kind != yaml.ScalarNode || node.Tag != "!!bool" {
+		errs.add(node, path, "expected true or false, got %s", nodeTypeName(node))
+		return false
 	}
-	if toolConfigMap == nil {
-		return toolConfig, nil
+	var value bool
+	if err := node.Decode(&value); err != nil {
+		errs.add(node, path, "%s", err)
 	}
+	return value
+}
 
-	for key, value := range toolConfigMap {
-		switch key {
-		case "provider":
-			toolConfig.Provider = value.(string)
+func parsePositiveInt(node *yaml.Node, path string, errs *ValidationErrors) int {
+	node = resolveAlias(node)
+	if node.Kind != yaml.ScalarNode || node.Tag != "!!int" {
+		errs.add(node, path, "expected a positive integer, got %s", nodeTypeName(node))
+		return 0
+	}
+	var value int
+	if err := node.Decode(&value); err != nil {
+		errs.add(node, path, "%s", err)
+		return 0
+	}
+	if value < 1 {
+		errs.add(node, path, "expected a positive integer, got %d", value)
+		return 0
+	}
+	return value
+}
+
+func parsePositiveDuration(node *yaml.Node, path string, errs *ValidationErrors) time.Duration {
+	node = resolveAlias(node)
+	if node.Kind != yaml.ScalarNode || node.Tag != "!!str" {
+		errs.add(node, path, "expected a duration like 10m or 1h30m, got %s", nodeTypeName(node))
+		return 0
+	}
+	value, err := time.ParseDuration(strings.TrimSpace(node.Value))
+	if err != nil || value <= 0 {
+		errs.add(node, path, "expected a positive duration like 10m or 1h30m, got %s", node.Value)
+		return 0
+	}
+	return value
+}
+
+// parseOptionString accepts a non-empty scalar, so that versions like 0.16 don't have to be quoted.
+func parseOptionString(node *yaml.Node, path string, errs *ValidationErrors) string {
+	node = resolveAlias(node)
+	if node.Kind != yaml.ScalarNode || isNull(node) || node.Tag == "!!bool" {
+		errs.add(node, path, "expected a string, got %s", nodeTypeName(node))
+		return ""
+	}
+	value := strings.TrimSpace(node.Value)
+	if value == "" {
+		errs.add(node, path, "must not be empty")
+	}
+	return value
+}
+
+// parseProviderID accepts a provider ID or an ordered list of them, which is returned as a fallback chain
+// (see provider.FallbackChainID()).
+func parseProviderID(node *yaml.Node, path string, errs *ValidationErrors) string {
+	if node.Kind != yaml.SequenceNode {
+		return parseSingleProviderID(node, path, "a string or a list of strings", errs)
+	}
+	if len(node.Content) == 0 {
+		errs.add(node, path, "provider list must not be empty")
+		return ""
+	}
+
+	var chain []string
+	for i, item := range node.Content {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		providerID := parseSingleProviderID(item, itemPath, "a string", errs)
+		switch {
+		case providerID == "":
+			continue
+		case len(provider.FallbackChain(providerID)) > 1:
+			errs.add(item, itemPath, "expected a single provider, got %s", providerID)
+		case slices.Contains(chain, providerID):
+			errs.add(item, itemPath, "provider %s is listed more than once", providerID)
+		default:
+			chain = append(chain, providerID)
 		}
 	}
+	return provider.FallbackChainID(chain)
+}
 
-	return toolConfig, nil
+func parseSingleProviderID(node *yaml.Node, path string, expected string, errs *ValidationErrors) string {
+	if node.Kind != yaml.ScalarNode || node.Tag != "!!str" {
+		errs.add(node, path, "expected %s, got %s", expected, nodeTypeName(node))
+		return ""
+	}
+	providerID := strings.TrimSpace(node.Value)
+	if providerID == "" {
+		errs.add(node, path, "provider must not be empty")
+	}
+	return providerID
 }
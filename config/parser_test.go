@@ -2,6 +2,7 @@ package config_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/bitrise-io/toolprovider/config"
 	"github.com/bitrise-io/toolprovider/provider"
@@ -25,7 +26,7 @@ func TestParseTools(t *testing.T) {
 				"golang": {
 					ToolName:           "golang",
 					UnparsedVersion:    "1.16.3",
-					ResolutionStrategy: provider.ResolutionStrategyStrict,
+					ResolutionStrategy: provider.ResolutionStrategyAuto,
 				},
 				"nodejs": {
 					ToolName:           "nodejs",
@@ -40,7 +41,7 @@ func TestParseTools(t *testing.T) {
 				"flutter": {
 					ToolName:           "flutter",
 					UnparsedVersion:    "3.32.5-stable",
-					ResolutionStrategy: provider.ResolutionStrategyStrict,
+					ResolutionStrategy: provider.ResolutionStrategyAuto,
 					PluginIdentifier:   &flutterPlugin,
 				},
 				"python": {
@@ -56,18 +57,18 @@ func TestParseTools(t *testing.T) {
 				"alias": {
 					ToolName:           "alias",
 					UnparsedVersion:    "latest",
-					ResolutionStrategy: provider.ResolutionStrategyStrict,
+					ResolutionStrategy: provider.ResolutionStrategyAuto,
 					PluginIdentifier:   &aliasPlugin,
 				},
 				"signal-cli": {
 					ToolName:           "signal-cli",
 					UnparsedVersion:    "",
-					ResolutionStrategy: provider.ResolutionStrategyStrict,
+					ResolutionStrategy: provider.ResolutionStrategyAuto,
 				},
 				"air": {
 					ToolName:           "air",
 					UnparsedVersion:    "installed",
-					ResolutionStrategy: provider.ResolutionStrategyStrict,
+					ResolutionStrategy: provider.ResolutionStrategyAuto,
 					PluginIdentifier:   &airPlugin,
 				},
 				"elixir": {
@@ -75,6 +76,16 @@ func TestParseTools(t *testing.T) {
 					UnparsedVersion:    "",
 					ResolutionStrategy: provider.ResolutionStrategyLatestInstalled,
 				},
+				"java": {
+					ToolName:           "java",
+					UnparsedVersion:    ">=17 <22",
+					ResolutionStrategy: provider.ResolutionStrategyConstraint,
+				},
+				"kotlin": {
+					ToolName:           "kotlin",
+					UnparsedVersion:    "~> 1.9",
+					ResolutionStrategy: provider.ResolutionStrategyConstraint,
+				},
 			},
 		},
 	}
@@ -134,6 +145,44 @@ func TestParseToolConfig(t *testing.T) {
 				Provider: "asdf",
 			},
 		},
+		{
+			name:    "Continue on error, parallelism, timeouts and provider options",
+			ymlPath: "testdata/optional.bitrise.yml",
+			expected: config.ToolConfig{
+				Provider:        "asdf",
+				ContinueOnError: true,
+				Parallelism:     4,
+				ToolTimeout:     10 * time.Minute,
+				RunTimeout:      time.Hour,
+				ProviderOptions: map[string]provider.ProviderOptions{
+					"mise":   {DataDir: "/opt/mise-data", Version: "2025.7.18"},
+					"asdf":   {ShellInit: ". /opt/asdf/asdf.sh"},
+					"direct": {Manifest: "tools.manifest.yml"},
+				},
+			},
+		},
+		{
+			name:    "Per-tool provider overrides",
+			ymlPath: "testdata/providers.bitrise.yml",
+			expected: config.ToolConfig{
+				Provider: "mise",
+				Providers: map[string]string{
+					"flutter": "asdf",
+					"node":    "asdf",
+					"python":  "asdf",
+				},
+			},
+		},
+		{
+			name:    "Fallback chains of providers",
+			ymlPath: "testdata/fallback.bitrise.yml",
+			expected: config.ToolConfig{
+				Provider: "mise,asdf",
+				Providers: map[string]string{
+					"ruby": "direct,mise",
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -148,3 +197,227 @@ func TestParseToolConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestParseWorkflowToolDeclarations(t *testing.T) {
+	tests := []struct {
+		name       string
+		workflowID string
+		expected   map[string]provider.ToolRequest
+		wantErr    bool
+	}{
+		{
+			name:       "Workflow without own block inherits from global and before_run",
+			workflowID: "test-node18",
+			expected: map[string]provider.ToolRequest{
+				"nodejs": {ToolName: "nodejs", UnparsedVersion: "18", ResolutionStrategy: provider.ResolutionStrategyLatestReleased},
+				"ruby":   {ToolName: "ruby", UnparsedVersion: "3.2", ResolutionStrategy: provider.ResolutionStrategyLatestInstalled},
+				"python": {ToolName: "python", UnparsedVersion: "3.12", ResolutionStrategy: provider.ResolutionStrategyAuto},
+			},
+		},
+		{
+			name:       "Workflow block overrides global and before_run",
+			workflowID: "test-node20",
+			expected: map[string]provider.ToolRequest{
+				"node":   {ToolName: "node", UnparsedVersion: "20", ResolutionStrategy: provider.ResolutionStrategyLatestInstalled},
+				"ruby":   {ToolName: "ruby", UnparsedVersion: "3.2", ResolutionStrategy: provider.ResolutionStrategyLatestInstalled},
+				"python": {ToolName: "python", UnparsedVersion: "3.13", ResolutionStrategy: provider.ResolutionStrategyAuto},
+			},
+		},
+		{
+			name:       "Undefined workflow",
+			workflowID: "nonexistent",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bitriseYml, err := config.ParseBitriseYml("testdata/workflows.bitrise.yml")
+			assert.NoError(t, err)
+
+			toolDeclarations, err := config.ParseWorkflowToolDeclarations(bitriseYml, tt.workflowID)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, toolDeclarations)
+		})
+	}
+}
+
+func TestValidateToolBlocks(t *testing.T) {
+	bitriseYml, err := config.ParseBitriseYml("testdata/invalid.bitrise.yml")
+	assert.NoError(t, err)
+
+	err = config.ValidateToolBlocks(bitriseYml)
+
+	expected := config.ValidationErrors{
+		{Path: "meta.experimental.tools.python", Line: 7, Column: 15, Message: "expected a version string or number, got boolean"},
+		{Path: "meta.experimental.tools.nodejs.version", Line: 9, Column: 18, Message: "expected a version string or number, got list"},
+		{Path: "meta.experimental.tools.nodejs.verison", Line: 10, Column: 9, Message: "unknown key, expected one of: version, plugin, provider, post_install, depends_on, optional"},
+		{Path: "meta.experimental.tools.flutter.plugin", Line: 12, Column: 17, Message: "plugin must not be empty"},
+		{Path: "meta.experimental.tools.air.plugin", Line: 14, Column: 17, Message: "plugin name cannot be empty in identifier: ::https://github.com/pdemagny/asdf-air"},
+		{Path: "meta.experimental.tools.alias.plugin", Line: 16, Column: 17, Message: "invalid plugin identifier format: alias::latest::https://github.com/andrewthauer/asdf-alias.git, expected format is 'pluginName::[gitCloneURL]'"},
+		{Path: "meta.experimental.tools.java", Line: 17, Column: 13, Message: "invalid version constraint: >=17 and <22"},
+		{Path: "meta.experimental.tools.ruby", Line: 18, Column: 13, Message: "expected a version string or a map, got list"},
+		{Path: "meta.experimental.tools.tuist.post_install[1]", Line: 20, Column: 39, Message: "expected a string, got integer"},
+		{Path: "meta.experimental.tool_config.provider", Line: 22, Column: 17, Message: "expected a string or a list of strings, got integer"},
+		{Path: "meta.experimental.tool_config.parallel", Line: 23, Column: 7, Message: "unknown key, expected one of: provider, providers, continue_on_error, parallelism, use_native_tools, tool_timeout, run_timeout, provider_options"},
+		{Path: "meta.experimental.tool_config.tool_timeout", Line: 24, Column: 21, Message: "expected a positive duration like 10m or 1h30m, got 0s"},
+		{Path: "meta.experimental.tool_config.provider_options.mise.dir", Line: 27, Column: 11, Message: "unknown key, expected one of: data_dir, install_dir, version, shell_init, manifest"},
+		{Path: "workflows.test.meta.experimental.tools", Line: 33, Column: 16, Message: "expected a map of tools, got number"},
+		{Path: "workflows.test.meta.experimental.tool_config", Line: 35, Column: 11, Message: "tool_config is only supported in the top-level meta block"},
+	}
+	assert.Equal(t, expected, err)
+}
+
+func TestParseMissingOrInvalidExperimentalBlock(t *testing.T) {
+	tests := []struct {
+		name                 string
+		ymlPath              string
+		wantNoDeclarations   bool
+		wantValidationErrors config.ValidationErrors
+	}{
+		{
+			name:               "No experimental block",
+			ymlPath:            "testdata/no_experimental.bitrise.yml",
+			wantNoDeclarations: true,
+		},
+		{
+			name:    "Scalar experimental block",
+			ymlPath: "testdata/scalar_experimental.bitrise.yml",
+			wantValidationErrors: config.ValidationErrors{
+				{Path: "meta.experimental", Line: 4, Column: 17, Message: "expected a map, got boolean"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bitriseYml, err := config.ParseBitriseYml(tt.ymlPath)
+			assert.NoError(t, err)
+
+			_, err = config.ParseToolDeclarations(bitriseYml)
+			if tt.wantNoDeclarations {
+				assert.ErrorIs(t, err, config.ErrNoToolDeclarations)
+			} else {
+				assert.Equal(t, tt.wantValidationErrors, err)
+			}
+
+			toolConfig, err := config.ParseToolConfig(bitriseYml)
+			if tt.wantValidationErrors != nil {
+				assert.Equal(t, tt.wantValidationErrors, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, config.ToolConfig{Provider: "asdf"}, toolConfig)
+			}
+		})
+	}
+}
+
+func TestBitriseYmlFromModel(t *testing.T) {
+	parsed, err := config.ParseBitriseYml("testdata/workflows.bitrise.yml")
+	assert.NoError(t, err)
+
+	bitriseYml, err := config.BitriseYmlFromModel(parsed.BitriseDataModel)
+	assert.NoError(t, err)
+
+	toolDeclarations, err := config.ParseWorkflowToolDeclarations(bitriseYml, "test-node20")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]provider.ToolRequest{
+		"node":   {ToolName: "node", UnparsedVersion: "20", ResolutionStrategy: provider.ResolutionStrategyLatestInstalled},
+		"ruby":   {ToolName: "ruby", UnparsedVersion: "3.2", ResolutionStrategy: provider.ResolutionStrategyLatestInstalled},
+		"python": {ToolName: "python", UnparsedVersion: "3.13", ResolutionStrategy: provider.ResolutionStrategyAuto},
+	}, toolDeclarations)
+}
+
+func TestParseNumericVersions(t *testing.T) {
+	bitriseYml, err := config.ParseBitriseYml("testdata/numeric.bitrise.yml")
+	assert.NoError(t, err)
+
+	toolDeclarations, err := config.ParseToolDeclarations(bitriseYml)
+	assert.NoError(t, err)
+
+	// Versions must be kept exactly as typed, no matter if YAML considers them an int, float or string.
+	assert.Equal(t, map[string]provider.ToolRequest{
+		"python": {ToolName: "python", UnparsedVersion: "3.10", ResolutionStrategy: provider.ResolutionStrategyAuto},
+		"node":   {ToolName: "node", UnparsedVersion: "20", ResolutionStrategy: provider.ResolutionStrategyAuto},
+		"golang": {ToolName: "golang", UnparsedVersion: "1.20", ResolutionStrategy: provider.ResolutionStrategyAuto},
+		"ruby":   {ToolName: "ruby", UnparsedVersion: "3.0", ResolutionStrategy: provider.ResolutionStrategyAuto},
+		"java":   {ToolName: "java", UnparsedVersion: "21.0", ResolutionStrategy: provider.ResolutionStrategyAuto},
+		"elixir": {ToolName: "elixir", UnparsedVersion: "1.10", ResolutionStrategy: provider.ResolutionStrategyAuto},
+		"kotlin": {ToolName: "kotlin", UnparsedVersion: "2.0", ResolutionStrategy: provider.ResolutionStrategyLatestReleased},
+	}, toolDeclarations)
+}
+
+func TestAssignProviders(t *testing.T) {
+	bitriseYml, err := config.ParseBitriseYml("testdata/providers.bitrise.yml")
+	assert.NoError(t, err)
+	toolConfig, err := config.ParseToolConfig(bitriseYml)
+	assert.NoError(t, err)
+	toolDeclarations, err := config.ParseToolDeclarations(bitriseYml)
+	assert.NoError(t, err)
+
+	toolDeclarations = config.AssignProviders(toolDeclarations, toolConfig)
+
+	providers := map[string]string{}
+	for name, request := range toolDeclarations {
+		providers[name] = request.ProviderID
+	}
+	assert.Equal(t, map[string]string{
+		"java":    "mise", // tool_config.provider
+		"flutter": "asdf", // tool_config.providers
+		"nodejs":  "asdf", // tool_config.providers with alias
+		"ruby":    "asdf", // provider field of the declaration
+		"python":  "mise", // provider field of the declaration wins over tool_config.providers
+	}, providers)
+}
+
+func TestParsePostInstall(t *testing.T) {
+	bitriseYml, err := config.ParseBitriseYml("testdata/post_install.bitrise.yml")
+	assert.NoError(t, err)
+
+	toolDeclarations, err := config.ParseToolDeclarations(bitriseYml)
+	assert.NoError(t, err)
+
+	expected := map[string][]string{
+		"nodejs": {"npm install -g yarn"},
+		"ruby":   {"gem install bundler", "gem install cocoapods"},
+		"python": {"pip install --upgrade pip\npip install pipenv\n"},
+		"golang": nil,
+	}
+	assert.Len(t, toolDeclarations, len(expected))
+	for toolName, postInstall := range expected {
+		assert.Equal(t, postInstall, toolDeclarations[toolName].PostInstall, toolName)
+	}
+}
+
+func TestParseOptional(t *testing.T) {
+	bitriseYml, err := config.ParseBitriseYml("testdata/optional.bitrise.yml")
+	assert.NoError(t, err)
+
+	toolDeclarations, err := config.ParseToolDeclarations(bitriseYml)
+	assert.NoError(t, err)
+
+	assert.False(t, toolDeclarations["nodejs"].Optional)
+	assert.True(t, toolDeclarations["ruby"].Optional)
+	assert.False(t, toolDeclarations["golang"].Optional)
+}
+
+func TestParseYamlAliases(t *testing.T) {
+	bitriseYml, err := config.ParseBitriseYml("testdata/aliases.bitrise.yml")
+	assert.NoError(t, err)
+
+	plugin := "nodejs::https://github.com/asdf-vm/asdf-nodejs.git"
+	toolDeclarations, err := config.ParseWorkflowToolDeclarations(bitriseYml, "node22")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]provider.ToolRequest{
+		"node": {ToolName: "node", UnparsedVersion: "22", ResolutionStrategy: provider.ResolutionStrategyAuto, PluginIdentifier: &plugin},
+		"ruby": {ToolName: "ruby", UnparsedVersion: "20", ResolutionStrategy: provider.ResolutionStrategyAuto},
+	}, toolDeclarations)
+
+	_, err = config.ParseWorkflowToolDeclarations(bitriseYml, "duplicate")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "nodejs is already declared as node")
+}
@@ -0,0 +1,324 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/bitrise-io/toolprovider/provider"
+)
+
+// Tool declarations can come from multiple sources. When the same tool is declared in more than one source,
+// the one with the highest precedence wins:
+//
+//  1. meta.experimental.tools in bitrise.yml
+//  2. mise.toml (or .mise.toml)
+//  3. .tool-versions
+//  4. Language-specific version files: .nvmrc, .node-version, .python-version, .ruby-version, .java-version
+//     and the toolchain directive of go.mod
+//
+// Version files are only looked up in the working directory, parent directories are not considered.
+const (
+	miseTomlFileName     = "mise.toml"
+	dotMiseTomlFileName  = ".mise.toml"
+	toolVersionsFileName = ".tool-versions"
+	goModFileName        = "go.mod"
+)
+
+type languageVersionFile struct {
+	fileName string
+	toolName string
+	// parse turns the trimmed first line of the file into a version. Returns an empty string if the file should be ignored.
+	parse func(line string) (string, error)
+}
+
+// Listed in increasing order of precedence, e.g. .node-version wins over .nvmrc.
+var languageVersionFiles = []languageVersionFile{
+	{fileName: ".nvmrc", toolName: "nodejs", parse: parseNvmrcVersion},
+	{fileName: ".node-version", toolName: "nodejs", parse: trimVersionPrefix("v")},
+	{fileName: ".python-version", toolName: "python", parse: trimVersionPrefix("")},
+	{fileName: ".ruby-version", toolName: "ruby", parse: trimVersionPrefix("ruby-")},
+	{fileName: ".java-version", toolName: "java", parse: trimVersionPrefix("")},
+}
+
+// ParseVersionFiles discovers the version files in dir and turns them into tool requests, keyed by canonical tool name.
+// Missing files are not an error, the result is empty if no version file is found.
+func ParseVersionFiles(dir string) (map[string]provider.ToolRequest, error) {
+	declarations := make(map[string]provider.ToolRequest)
+
+	for _, f := range languageVersionFiles {
+		line, err := readFirstLine(filepath.Join(dir, f.fileName))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		v, err := f.parse(line)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", f.fileName, err)
+		}
+		if v == "" {
+			continue
+		}
+		if err := checkSupportedVersion(v); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", f.fileName, err)
+		}
+		declarations[f.toolName] = provider.ToolRequest{
+			ToolName:           f.toolName,
+			UnparsedVersion:    v,
+			ResolutionStrategy: provider.ResolutionStrategyAuto,
+		}
+	}
+
+	goToolchain, err := parseGoModToolchain(filepath.Join(dir, goModFileName))
+	if err != nil {
+		return nil, err
+	}
+	if goToolchain != "" {
+		declarations["golang"] = provider.ToolRequest{
+			ToolName:           "golang",
+			UnparsedVersion:    goToolchain,
+			ResolutionStrategy: provider.ResolutionStrategyAuto,
+		}
+	}
+
+	toolVersions, err := parseToolVersionsFile(filepath.Join(dir, toolVersionsFileName))
+	if err != nil {
+		return nil, err
+	}
+	for name, request := range toolVersions {
+		declarations[name] = request
+	}
+
+	for _, fileName := range []string{dotMiseTomlFileName, miseTomlFileName} {
+		miseTools, err := parseMiseTomlFile(filepath.Join(dir, fileName))
+		if err != nil {
+			return nil, err
+		}
+		for name, request := range miseTools {
+			declarations[name] = request
+		}
+	}
+
+	return declarations, nil
+}
+
+// MergeToolDeclarations merges two sets of tool declarations, where primary takes precedence over secondary.
+// Tool names are compared by their canonical names, so `node` in primary overrides `nodejs` in secondary.
+func MergeToolDeclarations(primary, secondary map[string]provider.ToolRequest) map[string]provider.ToolRequest {
+	merged := make(map[string]provider.ToolRequest, len(primary)+len(secondary))
+	declaredInPrimary := make(map[string]bool, len(primary))
+	for name, request := range primary {
+		merged[name] = request
+		declaredInPrimary[provider.GetCanonicalToolName(name)] = true
+	}
+	for name, request := range secondary {
+		if declaredInPrimary[provider.GetCanonicalToolName(name)] {
+			continue
+		}
+		merged[name] = request
+	}
+	return merged
+}
+
+func readFirstLine(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(stripComment(line))
+		if line != "" {
+			return line, nil
+		}
+	}
+	return "", nil
+}
+
+func trimVersionPrefix(prefix string) func(string) (string, error) {
+	return func(line string) (string, error) {
+		// Some files (e.g. .python-version) may list multiple versions, the first one is the default.
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			return "", nil
+		}
+		return strings.TrimPrefix(fields[0], prefix), nil
+	}
+}
+
+func parseNvmrcVersion(line string) (string, error) {
+	switch {
+	case line == "":
+		return "", nil
+	case line == "node" || line == "stable":
+		return "latest", nil
+	case strings.HasPrefix(line, "lts/") || line == "iojs":
+		return "", fmt.Errorf("nvm alias %s is not supported, use a version number instead", line)
+	}
+	return strings.TrimPrefix(line, "v"), nil
+}
+
+// parseGoModToolchain returns the version from the `toolchain go1.x.y` directive, or an empty string if it's not set.
+func parseGoModToolchain(path string) (string, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(stripComment(scanner.Text()))
+		if len(fields) == 2 && fields[0] == "toolchain" {
+			v, ok := strings.CutPrefix(fields[1], "go")
+			if !ok || v == "" {
+				return "", fmt.Errorf("parse go.mod: invalid toolchain directive: %s", fields[1])
+			}
+			return v, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("read go.mod: %w", err)
+	}
+	return "", nil
+}
+
+// parseToolVersionsFile parses the asdf .tool-versions format: one `tool version [fallback versions...]` per line.
+func parseToolVersionsFile(path string) (map[string]provider.ToolRequest, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	declarations := make(map[string]provider.ToolRequest)
+	for i, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(stripComment(line))
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("parse %s: line %d: missing version for %s", toolVersionsFileName, i+1, fields[0])
+		}
+		name := provider.GetCanonicalToolName(fields[0])
+		if err := checkSupportedVersion(fields[1]); err != nil {
+			return nil, fmt.Errorf("parse %s: line %d: %s: %w", toolVersionsFileName, i+1, name, err)
+		}
+		// asdf would only accept exact versions here, Auto resolves those the same way and
+		// keeps partial versions like `20` consistent with the other version sources.
+		declarations[name] = provider.ToolRequest{
+			ToolName:           name,
+			UnparsedVersion:    fields[1],
+			ResolutionStrategy: provider.ResolutionStrategyAuto,
+		}
+	}
+	return declarations, nil
+}
+
+// parseMiseTomlFile reads the [tools] table of a mise config file. A tool version can be any of these forms:
+//
+//	node = "20"
+//	python = ["3.12", "3.11"]
+//	java = { version = "temurin-21" }
+//
+//	[tools.go]
+//	version = "1.23"
+func parseMiseTomlFile(path string) (map[string]provider.ToolRequest, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fileName := filepath.Base(path)
+	var miseConfig struct {
+		Tools map[string]any `toml:"tools"`
+	}
+	if _, err := toml.Decode(string(content), &miseConfig); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", fileName, err)
+	}
+
+	declarations := make(map[string]provider.ToolRequest)
+	for key, value := range miseConfig.Tools {
+		name := provider.GetCanonicalToolName(key)
+		v, err := parseMiseToolVersion(value)
+		if err == nil {
+			err = checkSupportedVersion(v)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %s: %w", fileName, name, err)
+		}
+
+		request := provider.ToolRequest{
+			ToolName:           name,
+			UnparsedVersion:    v,
+			ResolutionStrategy: provider.ResolutionStrategyAuto,
+		}
+		if prefix, ok := strings.CutPrefix(v, "prefix:"); ok {
+			request.UnparsedVersion = prefix
+			request.ResolutionStrategy = provider.ResolutionStrategyLatestReleased
+		}
+		declarations[name] = request
+	}
+	return declarations, nil
+}
+
+func parseMiseToolVersion(value any) (string, error) {
+	switch value := value.(type) {
+	case string:
+		return value, nil
+	case []any:
+		// The first version in the list is the one that's activated.
+		if len(value) == 0 {
+			return "", fmt.Errorf("empty version list")
+		}
+		return parseMiseToolVersion(value[0])
+	case []map[string]any:
+		if len(value) == 0 {
+			return "", fmt.Errorf("empty version list")
+		}
+		return parseMiseToolVersion(value[0])
+	case map[string]any:
+		v, ok := value["version"].(string)
+		if !ok {
+			return "", fmt.Errorf("version field is missing")
+		}
+		return v, nil
+	default:
+		return "", fmt.Errorf("unsupported value: %v", value)
+	}
+}
+
+// checkSupportedVersion rejects the asdf and mise versions that don't refer to a released version:
+// `system`, `ref:<git ref>` and `path:<dir>`.
+func checkSupportedVersion(v string) error {
+	switch {
+	case v == "system":
+		return fmt.Errorf("version system is not supported, set tool_config.use_native_tools to use the preinstalled tools")
+	case strings.HasPrefix(v, "ref:"):
+		return fmt.Errorf("version %s is not supported, building from a git ref is not possible, use a released version", v)
+	case strings.HasPrefix(v, "path:"):
+		return fmt.Errorf("version %s is not supported, use a released version instead of a local install", v)
+	}
+	return nil
+}
+
+func stripComment(line string) string {
+	before, _, _ := strings.Cut(line, "#")
+	return before
+}
@@ -0,0 +1,167 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/toolprovider/config"
+	"github.com/bitrise-io/toolprovider/provider"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersionFiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected map[string]provider.ToolRequest
+		wantErr  bool
+	}{
+		{
+			name:     "no version files",
+			files:    map[string]string{},
+			expected: map[string]provider.ToolRequest{},
+		},
+		{
+			name: "language-specific version files",
+			files: map[string]string{
+				".nvmrc":          "v20.11.1\n",
+				".python-version": "3.12.2\n3.11.8\n",
+				".ruby-version":   "ruby-3.2.2",
+				".java-version":   "temurin-21",
+				"go.mod":          "module example.com/foo\n\ngo 1.22.0\n\ntoolchain go1.22.3\n",
+			},
+			expected: map[string]provider.ToolRequest{
+				"nodejs": {ToolName: "nodejs", UnparsedVersion: "20.11.1", ResolutionStrategy: provider.ResolutionStrategyAuto},
+				"python": {ToolName: "python", UnparsedVersion: "3.12.2", ResolutionStrategy: provider.ResolutionStrategyAuto},
+				"ruby":   {ToolName: "ruby", UnparsedVersion: "3.2.2", ResolutionStrategy: provider.ResolutionStrategyAuto},
+				"java":   {ToolName: "java", UnparsedVersion: "temurin-21", ResolutionStrategy: provider.ResolutionStrategyAuto},
+				"golang": {ToolName: "golang", UnparsedVersion: "1.22.3", ResolutionStrategy: provider.ResolutionStrategyAuto},
+			},
+		},
+		{
+			name: "go.mod without toolchain directive",
+			files: map[string]string{
+				"go.mod": "module example.com/foo\n\ngo 1.22.0\n",
+			},
+			expected: map[string]provider.ToolRequest{},
+		},
+		{
+			name: "precedence between files",
+			files: map[string]string{
+				".nvmrc":         "18",
+				".node-version":  "20.1.0",
+				".ruby-version":  "3.1.0",
+				".tool-versions": "# comment\nnodejs 21.0.0\nruby 3.3.0 system\ngolang 1.21.0\n",
+				"mise.toml":      "[env]\nFOO = \"bar\"\n\n[tools]\nnode = \"22\"\n\"python\" = [\"3.12\", \"3.11\"]\njava = { version = \"temurin-21\" }\nruby = \"prefix:3.3\" # comment\n",
+			},
+			expected: map[string]provider.ToolRequest{
+				"nodejs": {ToolName: "nodejs", UnparsedVersion: "22", ResolutionStrategy: provider.ResolutionStrategyAuto},
+				"ruby":   {ToolName: "ruby", UnparsedVersion: "3.3", ResolutionStrategy: provider.ResolutionStrategyLatestReleased},
+				"golang": {ToolName: "golang", UnparsedVersion: "1.21.0", ResolutionStrategy: provider.ResolutionStrategyAuto},
+				"python": {ToolName: "python", UnparsedVersion: "3.12", ResolutionStrategy: provider.ResolutionStrategyAuto},
+				"java":   {ToolName: "java", UnparsedVersion: "temurin-21", ResolutionStrategy: provider.ResolutionStrategyAuto},
+			},
+		},
+		{
+			name: "mise.toml subtables and multi-line arrays",
+			files: map[string]string{
+				"mise.toml": "[tools]\npython = [\n  \"3.12\", # default\n  \"3.11\",\n]\n\n[tools.go]\nversion = \"1.23\"\n",
+			},
+			expected: map[string]provider.ToolRequest{
+				"python": {ToolName: "python", UnparsedVersion: "3.12", ResolutionStrategy: provider.ResolutionStrategyAuto},
+				"golang": {ToolName: "golang", UnparsedVersion: "1.23", ResolutionStrategy: provider.ResolutionStrategyAuto},
+			},
+		},
+		{
+			name: "non-string version in mise.toml",
+			files: map[string]string{
+				"mise.toml": "[tools]\nnode = 20\n",
+			},
+			wantErr: true,
+		},
+		{
+			name: "unsupported nvm alias",
+			files: map[string]string{
+				".nvmrc": "lts/iron",
+			},
+			wantErr: true,
+		},
+		{
+			name: "system version in .tool-versions",
+			files: map[string]string{
+				".tool-versions": "nodejs system\n",
+			},
+			wantErr: true,
+		},
+		{
+			name: "git ref version in .tool-versions",
+			files: map[string]string{
+				".tool-versions": "ruby ref:v3_3_0\n",
+			},
+			wantErr: true,
+		},
+		{
+			name: "git ref version in mise.toml",
+			files: map[string]string{
+				"mise.toml": "[tools]\nnode = \"ref:#abc\"\n",
+			},
+			wantErr: true,
+		},
+		{
+			name: "local path version in mise.toml",
+			files: map[string]string{
+				"mise.toml": "[tools.go]\nversion = \"path:~/go\"\n",
+			},
+			wantErr: true,
+		},
+		{
+			name: "system version in .python-version",
+			files: map[string]string{
+				".python-version": "system\n",
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing version in .tool-versions",
+			files: map[string]string{
+				".tool-versions": "nodejs\n",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+			}
+
+			declarations, err := config.ParseVersionFiles(dir)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, declarations)
+		})
+	}
+}
+
+func TestMergeToolDeclarations(t *testing.T) {
+	bitriseYmlDeclarations := map[string]provider.ToolRequest{
+		"node": {ToolName: "node", UnparsedVersion: "20", ResolutionStrategy: provider.ResolutionStrategyLatestInstalled},
+	}
+	versionFileDeclarations := map[string]provider.ToolRequest{
+		"nodejs": {ToolName: "nodejs", UnparsedVersion: "18.0.0", ResolutionStrategy: provider.ResolutionStrategyStrict},
+		"ruby":   {ToolName: "ruby", UnparsedVersion: "3.2.2", ResolutionStrategy: provider.ResolutionStrategyStrict},
+	}
+
+	merged := config.MergeToolDeclarations(bitriseYmlDeclarations, versionFileDeclarations)
+
+	require.Equal(t, map[string]provider.ToolRequest{
+		"node": {ToolName: "node", UnparsedVersion: "20", ResolutionStrategy: provider.ResolutionStrategyLatestInstalled},
+		"ruby": {ToolName: "ruby", UnparsedVersion: "3.2.2", ResolutionStrategy: provider.ResolutionStrategyStrict},
+	}, merged)
+}
@@ -1,5 +1,48 @@
 package config
 
+import (
+	"time"
+
+	"github.com/bitrise-io/toolprovider/provider"
+)
+
 type ToolConfig struct {
+	// Provider is the default provider for every tool.
 	Provider string `yaml:"provider"`
+	// Providers overrides the provider of individual tools, keyed by tool name.
+	Providers map[string]string `yaml:"providers"`
+	// ContinueOnError makes the install try every tool instead of stopping at the first failure.
+	ContinueOnError bool `yaml:"continue_on_error"`
+	// Parallelism is the maximum number of tools installed at the same time. Zero means one at a time.
+	Parallelism int `yaml:"parallelism"`
+	// UseNativeTools makes tools that are already on the system (e.g. preinstalled on the stack) count as installed
+	// if their version satisfies the request.
+	UseNativeTools bool `yaml:"use_native_tools"`
+	// ToolTimeout limits the time spent on installing and activating a single tool. Zero means no limit.
+	ToolTimeout time.Duration `yaml:"tool_timeout"`
+	// RunTimeout limits the time spent on the whole install. Zero means no limit.
+	RunTimeout time.Duration `yaml:"run_timeout"`
+	// ProviderOptions configure the providers, keyed by provider ID.
+	ProviderOptions map[string]provider.ProviderOptions `yaml:"provider_options"`
+}
+
+// AssignProviders sets the provider of every tool request. In decreasing order of precedence:
+// the `provider` field of the tool declaration, tool_config.providers, then tool_config.provider.
+func AssignProviders(declarations map[string]provider.ToolRequest, toolConfig ToolConfig) map[string]provider.ToolRequest {
+	overrides := make(map[string]string, len(toolConfig.Providers))
+	for toolName, providerID := range toolConfig.Providers {
+		overrides[provider.GetCanonicalToolName(toolName)] = providerID
+	}
+
+	assigned := make(map[string]provider.ToolRequest, len(declarations))
+	for name, request := range declarations {
+		if request.ProviderID == "" {
+			request.ProviderID = overrides[provider.GetCanonicalToolName(name)]
+		}
+		if request.ProviderID == "" {
+			request.ProviderID = toolConfig.Provider
+		}
+		assigned[name] = request
+	}
+	return assigned
 }
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ValidationError is a single problem found in bitrise.yml, pointing to the offending YAML node.
+type ValidationError struct {
+	// Path is the dot-separated YAML path, e.g. meta.experimental.tools.ruby.version
+	Path    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Line == 0 {
+		// The node is not coming from a file, see BitriseYmlFromModel()
+		return fmt.Sprintf("%s: %s", e.Path, e.Message)
+	}
+	return fmt.Sprintf("line %d, column %d: %s: %s", e.Line, e.Column, e.Path, e.Message)
+}
+
+// ValidationErrors collects every problem found in the tool related blocks, so that they can be fixed at once.
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	lines := make([]string, 0, len(e))
+	for _, err := range e {
+		lines = append(lines, "- "+err.Error())
+	}
+	return fmt.Sprintf("parse bitrise.yml: found %d problem(s):\n%s", len(e), strings.Join(lines, "\n"))
+}
+
+func (e *ValidationErrors) add(node *yaml.Node, path string, format string, args ...any) {
+	*e = append(*e, ValidationError{
+		Path:    path,
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// orNil returns nil if there are no errors, so that the result can be returned as a plain error.
+// Errors are ordered by their position in the file.
+func (e ValidationErrors) orNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+	slices.SortStableFunc(e, func(a, b ValidationError) int {
+		if a.Line != b.Line {
+			return a.Line - b.Line
+		}
+		return a.Column - b.Column
+	})
+	return e
+}
+
+type mappingEntry struct {
+	key   *yaml.Node
+	value *yaml.Node
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+// mappingEntries returns the key-value pairs of a mapping node in the order they are declared. Aliased keys and values
+// are resolved to the anchored node.
+func mappingEntries(node *yaml.Node) []mappingEntry {
+	node = resolveAlias(node)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	entries := make([]mappingEntry, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		entries = append(entries, mappingEntry{key: resolveAlias(node.Content[i]), value: resolveAlias(node.Content[i+1])})
+	}
+	return entries
+}
+
+// lookup returns the value node of a mapping key, or nil if the node is not a mapping or the key is missing.
+func lookup(node *yaml.Node, key string) *yaml.Node {
+	for _, entry := range mappingEntries(node) {
+		if entry.key.Value == key {
+			return entry.value
+		}
+	}
+	return nil
+}
+
+func isNull(node *yaml.Node) bool {
+	return node == nil || (node.Kind == yaml.ScalarNode && node.Tag == "!!null")
+}
+
+// nodeTypeName returns a user-friendly name of the node's type for error messages.
+func nodeTypeName(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "map"
+	case yaml.SequenceNode:
+		return "list"
+	case yaml.ScalarNode:
+		switch node.Tag {
+		case "!!str":
+			return "string"
+		case "!!int":
+			return "integer"
+		case "!!float":
+			return "number"
+		case "!!bool":
+			return "boolean"
+		case "!!null":
+			return "null"
+		}
+	}
+	return node.Tag
+}
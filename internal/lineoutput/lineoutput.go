@@ -0,0 +1,50 @@
+// Package lineoutput collects the output of a command and passes it on line by line while the command runs.
+package lineoutput
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Writer collects the combined output of a command and passes each line to onLine as soon as it's complete.
+type Writer struct {
+	output  bytes.Buffer
+	pending []byte
+	onLine  func(line string)
+}
+
+// NewWriter creates a Writer. A nil onLine only collects the output.
+func NewWriter(onLine func(line string)) *Writer {
+	return &Writer{onLine: onLine}
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	w.output.Write(p)
+	if w.onLine == nil {
+		return len(p), nil
+	}
+
+	w.pending = append(w.pending, p...)
+	for {
+		i := bytes.IndexByte(w.pending, '\n')
+		if i < 0 {
+			break
+		}
+		w.onLine(strings.TrimSuffix(string(w.pending[:i]), "\r"))
+		w.pending = w.pending[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush passes the last line to onLine if it has no trailing newline.
+func (w *Writer) Flush() {
+	if w.onLine != nil && len(w.pending) > 0 {
+		w.onLine(string(w.pending))
+		w.pending = nil
+	}
+}
+
+// Output returns everything that was written, including the lines passed to onLine.
+func (w *Writer) Output() []byte {
+	return w.output.Bytes()
+}
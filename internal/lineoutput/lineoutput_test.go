@@ -0,0 +1,25 @@
+package lineoutput_test
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/toolprovider/internal/lineoutput"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter(t *testing.T) {
+	var lines []string
+	w := lineoutput.NewWriter(func(line string) {
+		lines = append(lines, line)
+	})
+
+	for _, chunk := range []string{"Down", "loading...\r\nExtract", "ing...\n", "Done"} {
+		_, err := w.Write([]byte(chunk))
+		require.NoError(t, err)
+	}
+	require.Equal(t, []string{"Downloading...", "Extracting..."}, lines)
+
+	w.Flush()
+	require.Equal(t, []string{"Downloading...", "Extracting...", "Done"}, lines)
+	require.Equal(t, "Downloading...\r\nExtracting...\nDone", string(w.Output()))
+}
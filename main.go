@@ -1,185 +1,661 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"slices"
 	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
 
+	"github.com/urfave/cli"
+
+	"github.com/bitrise-io/toolprovider/activation"
 	"github.com/bitrise-io/toolprovider/config"
+	"github.com/bitrise-io/toolprovider/pipeline"
 	"github.com/bitrise-io/toolprovider/provider"
-	"github.com/bitrise-io/toolprovider/provider/asdf"
-	"github.com/bitrise-io/toolprovider/provider/asdf/execenv"
-	"github.com/bitrise-io/toolprovider/provider/mise"
+	// The providers register themselves in provider.DefaultRegistry
+	_ "github.com/bitrise-io/toolprovider/provider/asdf"
+	_ "github.com/bitrise-io/toolprovider/provider/direct"
+	_ "github.com/bitrise-io/toolprovider/provider/mise"
+	"github.com/bitrise-io/toolprovider/report"
+)
+
+// Set by GoReleaser, see .goreleaser.yaml
+var (
+	version = "dev"
+	commit  = "none"
+	date    = "unknown"
 )
 
+const (
+	exitCodeError    = 1
+	exitCodeUsage    = 2
+	exitCodeConfig   = 3
+	exitCodeLockfile = 4
+	exitCodeInstall  = 5
+	exitCodeActivate = 6
+)
+
+const (
+	flagConfig      = "config"
+	flagWorkflow    = "workflow"
+	flagProvider    = "provider"
+	flagLockfile    = "lockfile"
+	flagFrozen      = "frozen"
+	flagContinue    = "continue-on-error"
+	flagParallel    = "parallelism"
+	flagToolTimeout = "tool-timeout"
+	flagRunTimeout  = "run-timeout"
+	flagReport      = "report"
+	flagSummary     = "report-summary"
+
+	flagFormat     = "format"
+	flagOutputFile = "output-file"
+	flagTool       = "tool"
+	flagKeepLast   = "keep-last"
+	flagDryRun     = "dry-run"
+)
+
+// usageError is returned for invalid command line arguments.
+type usageError struct {
+	err error
+}
+
+func (e usageError) Error() string {
+	return e.err.Error()
+}
+
+// exitStatusError makes the process exit with the exit code of a command run by exec. err is nil if the command
+// ran, but exited with a non-zero code: that's not an error of toolprovider, so nothing is printed.
+type exitStatusError struct {
+	code int
+	err  error
+}
+
+func (e exitStatusError) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("exit status %d", e.code)
+	}
+	return e.err.Error()
+}
+
 func main() {
-	workdir, err := os.Getwd()
+	err := newApp().Run(os.Args)
 	if err != nil {
-		panic(err)
+		var exitErr exitStatusError
+		if !errors.As(err, &exitErr) || exitErr.err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		}
+		os.Exit(exitCode(err))
 	}
+}
 
-	bitriseModel, err := config.ParseBitriseYml(filepath.Join(workdir, "bitrise.yml"))
-	if err != nil {
-		panic(err)
+func newApp() *cli.App {
+	app := cli.NewApp()
+	app.Name = "toolprovider"
+	app.Usage = "Install and activate the tools declared in bitrise.yml"
+	app.Version = version
+	app.HideVersion = true
+	app.Description = `Tools are declared in the meta.experimental.tools block of bitrise.yml and in version files
+   (.tool-versions, mise.toml, .nvmrc, etc.) in the working directory.
+
+EXIT CODES:
+   0  success
+   1  unexpected error
+   2  invalid command line arguments
+   3  invalid tool declarations or tool_config
+   4  missing or out of date lockfile (--frozen)
+   5  failed to resolve or install a tool
+   6  failed to activate the environment
+
+   exec exits with the exit code of the command once the tools are set up (127 if the command is not found).`
+	app.Flags = []cli.Flag{
+		cli.StringFlag{Name: flagConfig + ", c", Value: "bitrise.yml", Usage: "Path of bitrise.yml"},
+		cli.StringFlag{Name: flagWorkflow + ", w", Usage: "Use the tool declarations of this workflow merged over the global ones"},
+		cli.StringFlag{Name: flagProvider + ", p", Usage: "Install every tool with this provider (" + strings.Join(provider.DefaultRegistry.IDs(), ", ") + ") or comma-separated fallback chain of providers, ignoring tool_config and per-tool providers"},
+		cli.StringFlag{Name: flagLockfile, Usage: "Path of the lockfile of resolved tool versions (default: " + config.DefaultLockfileName + " next to bitrise.yml)"},
+		cli.BoolFlag{Name: flagFrozen, Usage: "Install the exact versions from the lockfile and fail if it is out of date"},
+		cli.BoolFlag{Name: flagContinue, Usage: "Try every tool instead of stopping at the first failure and report the failures together (same as tool_config.continue_on_error)"},
+		cli.IntFlag{Name: flagParallel, Usage: "Install up to this many tools at the same time, 0 means tool_config.parallelism (1 if not set)"},
+		cli.DurationFlag{Name: flagToolTimeout, Usage: "Fail a tool that takes longer than this to set up, like 10m, 0 means tool_config.tool_timeout (no limit if not set)"},
+		cli.DurationFlag{Name: flagRunTimeout, Usage: "Stop the whole run after this long, like 1h, 0 means tool_config.run_timeout (no limit if not set)"},
+		cli.StringFlag{Name: flagReport, Usage: "Write a JSON report of the install to this path (default: " + report.DefaultJSONFileName + " in $BITRISE_DEPLOY_DIR if set)"},
+		cli.StringFlag{Name: flagSummary, Usage: "Write a markdown summary of the install to this path (default: " + report.DefaultMarkdownFileName + " in $BITRISE_DEPLOY_DIR if set)"},
+	}
+	app.Commands = []cli.Command{
+		{Name: "install", Usage: "Install the missing tools and update the lockfile", Action: installCommand},
+		{Name: "resolve", Usage: "Show what each tool declaration resolves to without installing anything", Action: resolveCommand},
+		{
+			Name:   "activate",
+			Usage:  "Install the missing tools and activate them for the next build steps (envman by default, github on GitHub Actions)",
+			Flags:  activationFlags(),
+			Action: activateCommand,
+		},
+		{
+			Name:   "env",
+			Usage:  "Install the missing tools and print the environment activation (posix shell exports by default)",
+			Flags:  activationFlags(),
+			Action: envCommand,
+		},
+		{
+			Name:      "exec",
+			Usage:     "Install the missing tools and run a command with them activated, passing through its exit code",
+			ArgsUsage: "-- COMMAND [ARGS...]",
+			Flags: []cli.Flag{
+				cli.StringSliceFlag{Name: flagTool + ", t", Usage: "Only set up this tool and its dependencies (can be repeated)"},
+			},
+			Action: execCommand,
+		},
+		{Name: "list", Usage: "List the tool declarations in install order", Action: listCommand},
+		{
+			Name:  "prune",
+			Usage: "Uninstall the tool versions that are not referenced by the tool declarations or the lockfile",
+			Flags: []cli.Flag{
+				cli.IntFlag{Name: flagKeepLast, Usage: "Keep the latest N installed versions of every tool, even if they are not referenced"},
+				cli.BoolFlag{Name: flagDryRun, Usage: "Only print the versions that would be uninstalled"},
+			},
+			Action: pruneCommand,
+		},
+		{Name: "version", Usage: "Print the version of toolprovider", Action: versionCommand},
+	}
+	app.Action = func(c *cli.Context) error {
+		if c.NArg() > 0 {
+			return usageError{err: fmt.Errorf("unknown command: %s", c.Args().First())}
+		}
+		return cli.ShowAppHelp(c)
+	}
+	app.OnUsageError = func(c *cli.Context, err error, isSubcommand bool) error {
+		return usageError{err: err}
+	}
+	// Errors are returned from Run() and turned into exit codes in main()
+	app.ExitErrHandler = func(c *cli.Context, err error) {}
+	return app
+}
+
+func activationFlags() []cli.Flag {
+	formats := make([]string, 0, len(activation.Formats))
+	for _, f := range activation.Formats {
+		formats = append(formats, string(f))
 	}
-	toolConfig, err := config.ParseToolConfig(bitriseModel)
+	return []cli.Flag{
+		cli.StringFlag{Name: flagFormat + ", f", Usage: "Activation format, one of: " + strings.Join(formats, ", ")},
+		cli.StringFlag{Name: flagOutputFile + ", o", Usage: "Write the activation to this file instead of stdout (not supported by envman and github)"},
+	}
+}
+
+func exitCode(err error) int {
+	var usageErr usageError
+	if errors.As(err, &usageErr) {
+		return exitCodeUsage
+	}
+	var exitErr exitStatusError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+
+	var stageErr pipeline.StageError
+	if !errors.As(err, &stageErr) {
+		return exitCodeError
+	}
+	switch stageErr.Stage {
+	case pipeline.StageConfig:
+		return exitCodeConfig
+	case pipeline.StageLockfile:
+		return exitCodeLockfile
+	case pipeline.StageInstall:
+		return exitCodeInstall
+	case pipeline.StageActivate:
+		return exitCodeActivate
+	default:
+		return exitCodeError
+	}
+}
+
+// loadPipeline reads the tool declarations according to the global flags. Progress messages are written to log.
+func loadPipeline(c *cli.Context, log io.Writer) (*pipeline.Pipeline, error) {
+	if c.NArg() > 0 {
+		return nil, usageError{err: fmt.Errorf("unexpected arguments: %s", strings.Join(c.Args(), " "))}
+	}
+	opts, err := pipelineOptions(c, log)
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+	return pipeline.Load(opts, provider.DefaultRegistry)
+}
+
+func pipelineOptions(c *cli.Context, log io.Writer) (pipeline.Options, error) {
+	if c.GlobalInt(flagParallel) < 0 {
+		return pipeline.Options{}, usageError{err: fmt.Errorf("--%s must be a positive number", flagParallel)}
 	}
-	toolDeclarations, err := config.ParseToolDeclarations(bitriseModel)
+	for _, flag := range []string{flagToolTimeout, flagRunTimeout} {
+		if c.GlobalDuration(flag) < 0 {
+			return pipeline.Options{}, usageError{err: fmt.Errorf("--%s must be a positive duration", flag)}
+		}
+	}
+
+	return pipeline.Options{
+		ConfigPath:      c.GlobalString(flagConfig),
+		WorkflowID:      c.GlobalString(flagWorkflow),
+		ProviderID:      c.GlobalString(flagProvider),
+		LockfilePath:    c.GlobalString(flagLockfile),
+		Frozen:          c.GlobalBool(flagFrozen),
+		ContinueOnError: c.GlobalBool(flagContinue),
+		Parallelism:     c.GlobalInt(flagParallel),
+		ToolTimeout:     c.GlobalDuration(flagToolTimeout),
+		RunTimeout:      c.GlobalDuration(flagRunTimeout),
+		Log:             log,
+	}, nil
+}
+
+func installCommand(c *cli.Context) error {
+	startedAt := time.Now()
+	p, err := loadPipeline(c, os.Stdout)
 	if err != nil {
-		panic(err)
+		return err
+	}
+	if len(p.ToolRequests()) == 0 {
+		fmt.Println("No tools to set up.")
+		return nil
 	}
 
-	var toolProvider provider.ToolProvider
-	switch toolConfig.Provider {
-	case "asdf":
-		toolProvider = asdf.AsdfToolProvider{
-			ExecEnv: execenv.ExecEnv{
-				EnvVars:   convertEnvToMap(os.Environ()),
-				ShellInit: "", // TODO
-			},
+	_, err = install(c, p, startedAt, os.Stdout)
+	return err
+}
+
+// install installs the tools and writes the reports of the run, even if the install failed.
+func install(c *cli.Context, p *pipeline.Pipeline, startedAt time.Time, log io.Writer) ([]pipeline.InstalledTool, error) {
+	ctx, stop := commandContext(log)
+	installed, installErr := p.Install(ctx)
+	stop()
+
+	r := report.New(startedAt, installed, p.Providers(), installErr)
+	reportErr := writeReports(c, r, log)
+	if installErr != nil {
+		if reportErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", reportErr)
 		}
-	case "mise":
-		// TODO: this is just temporary until we merge this repo into the CLI codebase
-		home, err := os.UserHomeDir()
-		if err != nil {
-			panic(fmt.Errorf("get user home dir: %w", err))
+		return nil, installErr
+	}
+	return installed, reportErr
+}
+
+func writeReports(c *cli.Context, r report.Report, log io.Writer) error {
+	jsonPath, markdownPath := c.GlobalString(flagReport), c.GlobalString(flagSummary)
+	if deployDir := os.Getenv("BITRISE_DEPLOY_DIR"); deployDir != "" {
+		if jsonPath == "" {
+			jsonPath = filepath.Join(deployDir, report.DefaultJSONFileName)
 		}
-		installDir := filepath.Join(home, ".bitrise", "tools", "mise")
-		dataDir := filepath.Join(home, ".bitrise", "tools", "mise-data")
-		p, err := mise.NewToolProvider(installDir, dataDir)
-		if err != nil {
-			panic(fmt.Errorf("create Mise tool provider: %w", err))
+		if markdownPath == "" {
+			markdownPath = filepath.Join(deployDir, report.DefaultMarkdownFileName)
 		}
-		toolProvider = p
-	default:
-		panic(fmt.Errorf("unsupported tool provider: %s", toolConfig.Provider))
 	}
 
-	err = toolProvider.Bootstrap()
+	if jsonPath != "" {
+		if err := r.WriteJSON(jsonPath); err != nil {
+			return err
+		}
+		fmt.Fprintf(log, "Report written to %s\n", jsonPath)
+	}
+	if markdownPath != "" {
+		if err := r.WriteMarkdown(markdownPath); err != nil {
+			return err
+		}
+		fmt.Fprintf(log, "Summary written to %s\n", markdownPath)
+	}
+	return nil
+}
+
+func resolveCommand(c *cli.Context) error {
+	p, err := loadPipeline(c, os.Stdout)
 	if err != nil {
-		panic(fmt.Errorf("bootstrap tool provider %s: %w", toolConfig.Provider, err))
+		return err
 	}
 
-	if len(toolDeclarations) == 0 {
-		fmt.Println("No tools to set up.")
-		return
+	ctx, stop := commandContext(os.Stdout)
+	defer stop()
+	plans, err := p.Plan(ctx)
+	if err != nil {
+		return err
 	}
+	return printPlan(os.Stdout, plans)
+}
+
+func activateCommand(c *cli.Context) error {
+	return activate(c, activation.DefaultFormat())
+}
 
-	fmt.Println("Tools to set up:")
+func envCommand(c *cli.Context) error {
+	return activate(c, activation.FormatPosix)
+}
 
-	for toolName, toolRequest := range toolDeclarations {
-		resolutionStrategy := "strict" // default
-		switch toolRequest.ResolutionStrategy {
-		case provider.ResolutionStrategyLatestInstalled:
-			resolutionStrategy = "closest_installed"
-		case provider.ResolutionStrategyLatestReleased:
-			resolutionStrategy = "closest_released"
+// activate installs the missing tools and writes the environment activation in the selected format.
+func activate(c *cli.Context, defaultFormat activation.Format) error {
+	format := defaultFormat
+	if f := c.String(flagFormat); f != "" {
+		format = activation.Format(f)
+	}
+	if !slices.Contains(activation.Formats, format) {
+		return usageError{err: fmt.Errorf("unknown activation format: %s", format)}
+	}
+	outputFile := c.String(flagOutputFile)
+
+	// stdout is reserved for the activation if it's printed there, so that the output can be eval'd
+	var out io.Writer = os.Stdout
+	var log io.Writer = os.Stdout
+	if format.WritesToOutput() && outputFile == "" {
+		log = os.Stderr
+	}
+	if outputFile != "" {
+		if !format.WritesToOutput() {
+			return usageError{err: fmt.Errorf("--%s is not supported by the %s format", flagOutputFile, format)}
 		}
+		f, err := os.Create(outputFile)
+		if err != nil {
+			return pipeline.StageError{Stage: pipeline.StageActivate, Err: fmt.Errorf("create output file: %w", err)}
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		out = f
+	}
 
-		fmt.Printf("- %s v%s (resolution: %s)\n",
-			toolName,
-			toolRequest.UnparsedVersion,
-			resolutionStrategy)
+	writer, err := activation.NewWriter(format, out)
+	// Without envman the tools are still installed, only the activation is skipped, unless envman was requested explicitly
+	skipActivation := errors.Is(err, activation.ErrEnvmanNotFound) && c.String(flagFormat) == ""
+	if err != nil && !skipActivation {
+		return pipeline.StageError{Stage: pipeline.StageActivate, Err: err}
 	}
 
-	fmt.Println()
-	fmt.Println("Installing any missing tools...")
+	startedAt := time.Now()
+	p, err := loadPipeline(c, log)
+	if err != nil {
+		return err
+	}
+	if len(p.ToolRequests()) == 0 {
+		fmt.Fprintln(log, "No tools to set up.")
+	}
 
-	var toolInstalls []provider.ToolInstallResult
-	for toolName, toolRequest := range toolDeclarations {
-		canonicalToolName := provider.GetCanonicalToolName(toolName)
-		toolRequest.ToolName = canonicalToolName
+	installed, err := install(c, p, startedAt, log)
+	if err != nil {
+		return err
+	}
 
-		fmt.Printf("Installing %s v%s...\n", canonicalToolName, toolRequest.UnparsedVersion)
-		result, err := toolProvider.InstallTool(toolRequest)
-		if err != nil {
-			panic(err)
+	if skipActivation {
+		fmt.Fprintln(log)
+		fmt.Fprintln(log, "Warning: envman is not installed or not in PATH. Skipping environment activation.")
+		return nil
+	}
+
+	fmt.Fprintf(log, "Activating environment (%s)...\n", format)
+	err = writer.Write(pipeline.MergedActivation(installed))
+	if err != nil {
+		return pipeline.StageError{Stage: pipeline.StageActivate, Err: fmt.Errorf("write %s activation: %w", format, err)}
+	}
+	fmt.Fprintln(log, "Environment setup complete!")
+	return nil
+}
+
+// execCommand installs the tools and runs the command in the activated environment.
+// Progress messages go to stderr, so that stdout is the command's own output.
+func execCommand(c *cli.Context) error {
+	if c.NArg() == 0 {
+		return usageError{err: errors.New("missing command, usage: toolprovider exec [--tool NAME] -- COMMAND [ARGS...]")}
+	}
+
+	startedAt := time.Now()
+	opts, err := pipelineOptions(c, os.Stderr)
+	if err != nil {
+		return err
+	}
+	opts.Tools = c.StringSlice(flagTool)
+	p, err := pipeline.Load(opts, provider.DefaultRegistry)
+	if err != nil {
+		return err
+	}
+	installed, err := install(c, p, startedAt, os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	environ := activation.Environ(pipeline.MergedActivation(installed), os.Environ())
+	return runCommand(c.Args(), environ)
+}
+
+// commandContext returns the context of the provider calls of a command. It's canceled on SIGINT or SIGTERM,
+// so that the processes started by the providers are stopped too, and the provider events are printed to log.
+// Call stop to restore the default signal handling.
+func commandContext(log io.Writer) (ctx context.Context, stop context.CancelFunc) {
+	ctx, stop = signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	return provider.WithObserver(ctx, &progressPrinter{w: log}), stop
+}
+
+// progressPrinter prints the provider events as they happen. Lines are prefixed with the tool name (or the provider
+// for events that are not about a tool), so that the output of concurrent installs can be told apart.
+type progressPrinter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (p *progressPrinter) OnEvent(e provider.Event) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	printEvent(p.w, e)
+}
+
+func printEvent(w io.Writer, e provider.Event) {
+	prefix := e.ToolName
+	if prefix == "" {
+		prefix = e.ProviderID
+	}
+
+	var message string
+	switch e.Kind {
+	case provider.EventResolveStarted:
+		message = fmt.Sprintf("Resolving version %s...", orDash(e.Version))
+	case provider.EventResolveFinished:
+		message = fmt.Sprintf("Resolved version %s", e.Version)
+	case provider.EventPluginAdded:
+		message = fmt.Sprintf("Added plugin %s", e.Message)
+	case provider.EventDownloadProgress:
+		message = fmt.Sprintf("Downloaded %s", formatBytes(e.Bytes))
+		if e.Bytes == 0 {
+			message = fmt.Sprintf("Downloading %s...", e.Message)
+		} else if e.TotalBytes > 0 {
+			message = fmt.Sprintf("Downloaded %s of %s", formatBytes(e.Bytes), formatBytes(e.TotalBytes))
 		}
-		toolInstalls = append(toolInstalls, result)
+	case provider.EventOutput:
+		message = e.Message
+	case provider.EventActivated:
+		message = fmt.Sprintf("Activated %s", e.Version)
+	case provider.EventProviderFallback:
+		message = fmt.Sprintf("%s failed, trying the next provider: %s", e.ProviderID, e.Message)
+	default:
+		return
+	}
+	fmt.Fprintf(w, "[%s] %s\n", prefix, message)
+}
 
-		if result.IsAlreadyInstalled {
-			fmt.Printf("%s v%s is already installed.\n", result.ToolName, result.ConcreteVersion)
-		} else {
-			fmt.Printf("Successfully installed %s v%s.\n", result.ToolName, result.ConcreteVersion)
+// runCommand runs the command with the given environment and the stdio of toolprovider. Signals received by toolprovider
+// are forwarded to the command. A non-zero exit code is returned as exitStatusError, so that it can be passed through.
+func runCommand(args []string, environ []string) error {
+	var pathEnv string
+	for _, kv := range environ {
+		if value, ok := strings.CutPrefix(kv, "PATH="); ok {
+			pathEnv = value
 		}
 	}
+	// The command is looked up in the activated $PATH, not in the one of toolprovider.
+	path, err := lookPath(args[0], pathEnv)
+	if err != nil {
+		return exitStatusError{code: 127, err: err}
+	}
 
-	if _, err := exec.LookPath("envman"); err != nil {
-		fmt.Println()
-		fmt.Println("Warning: envman is not installed or not in PATH. Skipping environment activation.")
-		return
+	cmd := exec.Command(path, args[1:]...)
+	cmd.Args[0] = args[0]
+	cmd.Env = environ
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT)
+	defer signal.Stop(signals)
+
+	err = cmd.Start()
+	if err != nil {
+		return exitStatusError{code: 126, err: fmt.Errorf("run %s: %w", args[0], err)}
 	}
+	go func() {
+		for sig := range signals {
+			_ = cmd.Process.Signal(sig)
+		}
+	}()
+	err = cmd.Wait()
+	signal.Stop(signals)
+	close(signals)
 
-	fmt.Println()
-	fmt.Println("Activating environment with envman...")
-	if os.Getenv("CI") == "" {
-		_ = exec.Command("envman", "init").Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		// Use the shell convention for commands killed by a signal
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return exitStatusError{code: 128 + int(status.Signal())}
+		}
+		return exitStatusError{code: exitErr.ExitCode()}
+	}
+	if err != nil {
+		return exitStatusError{code: 126, err: fmt.Errorf("run %s: %w", args[0], err)}
 	}
+	return nil
+}
 
-	for _, install := range toolInstalls {
-		activation, err := toolProvider.ActivateEnv(install)
-		if err != nil {
-			panic(fmt.Errorf("activate tool %s: %w", install.ToolName, err))
+// lookPath finds an executable in pathEnv the same way as a shell. Names with a slash are used as they are.
+func lookPath(file, pathEnv string) (string, error) {
+	if strings.Contains(file, "/") {
+		return file, nil
+	}
+	for _, dir := range filepath.SplitList(pathEnv) {
+		if dir == "" {
+			dir = "."
 		}
-		err = extendEnvmanEnv(activation)
-		if err != nil {
-			panic(fmt.Errorf("extend envman env for %s: %w", install.ToolName, err))
+		path := filepath.Join(dir, file)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() && info.Mode().Perm()&0111 != 0 {
+			return path, nil
 		}
-		fmt.Printf("Environment for %s activated.\n", install.ToolName)
 	}
-	fmt.Print("Environment setup complete!")
+	return "", fmt.Errorf("command not found: %s", file)
+}
 
+func listCommand(c *cli.Context) error {
+	p, err := loadPipeline(c, io.Discard)
+	if err != nil {
+		return err
+	}
+	return printToolRequests(os.Stdout, p.ToolRequests())
 }
 
-func convertEnvToMap(env []string) map[string]string {
-	result := make(map[string]string)
-	for _, envVar := range env {
-		parts := strings.SplitN(envVar, "=", 2)
-		if len(parts) == 2 {
-			result[parts[0]] = parts[1]
-		}
+func pruneCommand(c *cli.Context) error {
+	if c.Int(flagKeepLast) < 0 {
+		return usageError{err: fmt.Errorf("--%s must be a positive number", flagKeepLast)}
+	}
+	p, err := loadPipeline(c, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := commandContext(os.Stdout)
+	defer stop()
+	dryRun := c.Bool(flagDryRun)
+	result, err := p.Prune(ctx, pipeline.PruneOptions{KeepLast: c.Int(flagKeepLast), DryRun: dryRun})
+	if err != nil {
+		return err
 	}
-	return result
+	return printPruneResult(os.Stdout, result, dryRun)
 }
 
-func extendEnvmanEnv(activation provider.EnvironmentActivation) error {
-	for k, v := range activation.ContributedEnvVars {
-		cmd := exec.Command("envman", "add", "--key", k, "--value", v)
-		if out, err := cmd.CombinedOutput(); err != nil {
-			fmt.Println(string(out))
-			return fmt.Errorf("add $%s to env: %w", k, err)
-		}
+func printPruneResult(w io.Writer, result pipeline.PruneResult, dryRun bool) error {
+	if len(result.Pruned) == 0 {
+		fmt.Fprintf(w, "Nothing to prune, %d installed versions are in use.\n", result.Kept)
+		return nil
 	}
 
-	if len(activation.ContributedPaths) > 0 {
-		newPath := prependPath(os.Getenv("PATH"), strings.Join(activation.ContributedPaths, ":"))
-		cmd := exec.Command("envman", "add", "--key", "PATH", "--value", newPath)
-		if out, err := cmd.CombinedOutput(); err != nil {
-			fmt.Println(string(out))
-			return fmt.Errorf("update $PATH: %w", err)
-		}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TOOL\tVERSION\tPROVIDER\tSIZE")
+	for _, v := range result.Pruned {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", v.ToolName, v.Version, v.ProviderID, formatBytes(v.Size))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if dryRun {
+		fmt.Fprintf(w, "Would uninstall %d versions and reclaim %s, keeping %d.\n", len(result.Pruned), formatBytes(result.ReclaimedBytes), result.Kept)
+	} else {
+		fmt.Fprintf(w, "Uninstalled %d versions and reclaimed %s, kept %d.\n", len(result.Pruned), formatBytes(result.ReclaimedBytes), result.Kept)
+	}
+	return nil
+}
+
+// formatBytes formats a size with a binary unit, e.g. 1.5 GiB.
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
 	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+func versionCommand(c *cli.Context) error {
+	fmt.Printf("toolprovider %s (commit: %s, built at: %s)\n", version, commit, date)
 	return nil
 }
 
-func prependPath(pathEnv, newPath string) string {
-	if pathEnv == "" {
-		return newPath
+func printToolRequests(w io.Writer, requests []provider.ToolRequest) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TOOL\tVERSION\tSTRATEGY\tPROVIDER\tDEPENDS ON")
+	for _, r := range requests {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.ToolName, orDash(r.UnparsedVersion), r.ResolutionStrategy, r.ProviderID, orDash(strings.Join(r.DependsOn, ", ")))
 	}
+	return tw.Flush()
+}
 
-	pathItems := strings.Split(pathEnv, ":")
-	pathItems = slices.DeleteFunc(pathItems, func(p string) bool {
-		return p == newPath
-	})
+func printPlan(w io.Writer, plans []provider.ToolInstallPlan) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TOOL\tREQUESTED\tSTRATEGY\tRESOLVED\tINSTALLED\tPROVIDER\tACTION")
+	for _, p := range plans {
+		installed := "no"
+		if p.IsInstalled {
+			installed = "yes"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", p.ToolName, orDash(p.RequestedVersion), planStrategy(p), p.ResolvedVersion, installed, orDash(p.ProviderID), p.PlannedAction())
+	}
+	return tw.Flush()
+}
 
-	if len(pathItems) == 0 {
-		return newPath
+// planStrategy shows the effective strategy next to the requested one if they differ, e.g. auto: closest_installed.
+func planStrategy(p provider.ToolInstallPlan) string {
+	if effective := p.EffectiveStrategy(); effective != p.ResolutionStrategy {
+		return fmt.Sprintf("%s: %s", p.ResolutionStrategy, effective)
 	}
+	return p.ResolutionStrategy.String()
+}
 
-	return fmt.Sprintf("%s:%s", newPath, strings.Join(pathItems, ":"))
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
 }
@@ -1,64 +1,192 @@
 package main
 
-import "testing"
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
 
-func TestPrependPath(t *testing.T) {
+	"github.com/bitrise-io/toolprovider/pipeline"
+	"github.com/bitrise-io/toolprovider/provider"
+)
+
+func TestPrintPlan(t *testing.T) {
+	plans := []provider.ToolInstallPlan{
+		{ToolName: "ruby", RequestedVersion: "3.2", ResolutionStrategy: provider.ResolutionStrategyLatestReleased, ResolvedVersion: "3.2.8", IsInstalled: false, ProviderID: "asdf"},
+		{ToolName: "tuist", RequestedVersion: "", ResolutionStrategy: provider.ResolutionStrategyLatestReleased, ResolvedVersion: "4.55.6", IsInstalled: true, ProviderID: "mise"},
+		{ToolName: "nodejs", RequestedVersion: "20", ResolutionStrategy: provider.ResolutionStrategyAuto, ResolvedVersion: "20.18.0", IsInstalled: true},
+	}
+
+	var out strings.Builder
+	err := printPlan(&out, plans)
+
+	expected := `TOOL    REQUESTED  STRATEGY                 RESOLVED  INSTALLED  PROVIDER  ACTION
+ruby    3.2        closest_released         3.2.8     no         asdf      install
+tuist   -          closest_released         4.55.6    yes        mise      use installed
+nodejs  20         auto: closest_installed  20.18.0   yes        -         use installed
+`
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestPrintPruneResult(t *testing.T) {
+	result := pipeline.PruneResult{
+		Pruned: []pipeline.PrunedVersion{
+			{ProviderID: "asdf", InstalledVersion: provider.InstalledVersion{ToolName: "nodejs", Version: "18.20.0"}, Size: 180 * 1024 * 1024},
+			{ProviderID: "mise", InstalledVersion: provider.InstalledVersion{ToolName: "golang", Version: "1.21.0"}, Size: 512},
+		},
+		Kept:           3,
+		ReclaimedBytes: 180*1024*1024 + 512,
+	}
+
+	var out strings.Builder
+	err := printPruneResult(&out, result, true)
+
+	expected := `TOOL    VERSION  PROVIDER  SIZE
+nodejs  18.20.0  asdf      180.0 MiB
+golang  1.21.0   mise      512 B
+Would uninstall 2 versions and reclaim 180.0 MiB, keeping 3.
+`
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestPrintEvent(t *testing.T) {
+	events := []provider.Event{
+		{Kind: provider.EventDownloadProgress, ProviderID: "mise", Message: "https://example.com/mise.tar.gz", TotalBytes: -1},
+		{Kind: provider.EventDownloadProgress, ProviderID: "mise", Bytes: 4 * 1024 * 1024, TotalBytes: 20 * 1024 * 1024},
+		{Kind: provider.EventDownloadProgress, ProviderID: "mise", Bytes: 512, TotalBytes: -1},
+		{Kind: provider.EventResolveStarted, ProviderID: "asdf", ToolName: "ruby", Version: "3.3"},
+		{Kind: provider.EventPluginAdded, ProviderID: "asdf", ToolName: "ruby", Message: "ruby"},
+		{Kind: provider.EventResolveFinished, ProviderID: "asdf", ToolName: "ruby", Version: "3.3.6"},
+		{Kind: provider.EventOutput, ProviderID: "asdf", ToolName: "ruby", Message: "Downloading ruby-3.3.6.tar.gz..."},
+		{Kind: provider.EventActivated, ProviderID: "asdf", ToolName: "ruby", Version: "3.3.6"},
+		{Kind: provider.EventProviderFallback, ProviderID: "direct", ToolName: "node", Version: "20", Message: "node is not in the manifest"},
+	}
+
+	var out strings.Builder
+	for _, e := range events {
+		printEvent(&out, e)
+	}
+
+	expected := `[mise] Downloading https://example.com/mise.tar.gz...
+[mise] Downloaded 4.0 MiB of 20.0 MiB
+[mise] Downloaded 512 B
+[ruby] Resolving version 3.3...
+[ruby] Added plugin ruby
+[ruby] Resolved version 3.3.6
+[ruby] Downloading ruby-3.3.6.tar.gz...
+[ruby] Activated 3.3.6
+[node] direct failed, trying the next provider: node is not in the manifest
+`
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestExitCode(t *testing.T) {
 	tests := []struct {
-		name        string
-		pathEnv     string
-		newPath     string
-		expected    string
+		name     string
+		err      error
+		expected int
 	}{
-		{
-			name:        "empty path env",
-			pathEnv:     "",
-			newPath:     "/usr/local/bin",
-			expected:    "/usr/local/bin",
-		},
-		{
-			name:        "prepend to existing path",
-			pathEnv:     "/usr/bin:/bin",
-			newPath:     "/usr/local/bin",
-			expected:    "/usr/local/bin:/usr/bin:/bin",
-		},
-		{
-			name:        "remove duplicate and prepend",
-			pathEnv:     "/usr/bin:/usr/local/bin:/bin",
-			newPath:     "/usr/local/bin",
-			expected:    "/usr/local/bin:/usr/bin:/bin",
-		},
-		{
-			name:        "duplicate at end",
-			pathEnv:     "/usr/bin:/bin:/usr/local/bin",
-			newPath:     "/usr/local/bin",
-			expected:    "/usr/local/bin:/usr/bin:/bin",
-		},
-		{
-			name:        "single path duplicate",
-			pathEnv:     "/usr/local/bin",
-			newPath:     "/usr/local/bin",
-			expected:    "/usr/local/bin",
-		},
-		{
-			name:        "empty new path",
-			pathEnv:     "/usr/bin:/bin",
-			newPath:     "",
-			expected:    ":/usr/bin:/bin",
-		},
-		{
-			name:        "multiple duplicates",
-			pathEnv:     "/usr/local/bin:/usr/bin:/usr/local/bin:/bin:/usr/local/bin",
-			newPath:     "/usr/local/bin",
-			expected:    "/usr/local/bin:/usr/bin:/bin",
-		},
+		{name: "usage error", err: usageError{err: errors.New("unknown command: foo")}, expected: exitCodeUsage},
+		{name: "config error", err: pipeline.StageError{Stage: pipeline.StageConfig, Err: errors.New("invalid")}, expected: exitCodeConfig},
+		{name: "wrapped install error", err: fmt.Errorf("run: %w", pipeline.StageError{Stage: pipeline.StageInstall, Err: errors.New("failed")}), expected: exitCodeInstall},
+		{name: "exit code of exec", err: exitStatusError{code: 42}, expected: 42},
+		{name: "unexpected error", err: errors.New("boom"), expected: exitCodeError},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := prependPath(tt.pathEnv, tt.newPath)
-			if result != tt.expected {
-				t.Errorf("prependPath(%q, %q) = %q, want %q", tt.pathEnv, tt.newPath, result, tt.expected)
+			if got := exitCode(tt.err); got != tt.expected {
+				t.Errorf("expected exit code %d, got %d", tt.expected, got)
 			}
 		})
 	}
 }
+
+func TestRunCommand(t *testing.T) {
+	binDir := t.TempDir()
+	script := "#!/bin/sh\necho \"$GREETING\" >\"$1\"\nexit 3\n"
+	if err := os.WriteFile(filepath.Join(binDir, "greet"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	outFile := filepath.Join(t.TempDir(), "out.txt")
+
+	// The command is looked up in the activated $PATH
+	environ := []string{"GREETING=hello", "PATH=" + binDir + ":" + os.Getenv("PATH")}
+	err := runCommand([]string{"greet", outFile}, environ)
+	if err != (exitStatusError{code: 3}) {
+		t.Fatalf("expected exit status 3, got %v", err)
+	}
+	out, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "hello\n" {
+		t.Errorf("unexpected output: %q", out)
+	}
+
+	err = runCommand([]string{"greet"}, []string{"PATH=" + os.Getenv("PATH")})
+	var exitErr exitStatusError
+	if !errors.As(err, &exitErr) || exitErr.code != 127 {
+		t.Errorf("expected exit status 127 for a missing command, got %v", err)
+	}
+}
+
+func TestActivateWithoutEnvman(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "bitrise.yml")
+	if err := os.WriteFile(configPath, []byte("format_version: \"11\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Version files are read from the working directory
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+	t.Setenv("PATH", t.TempDir())
+	t.Setenv("GITHUB_ACTIONS", "")
+
+	stdout := os.Stdout
+	out, err := os.Create(filepath.Join(t.TempDir(), "stdout.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = out
+	err = newApp().Run([]string{"toolprovider", "--config", configPath, "activate"})
+	os.Stdout = stdout
+	_ = out.Close()
+
+	// The default format warns instead of failing, the tools are installed anyway
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	log, err := os.ReadFile(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(log), "Warning: envman is not installed or not in PATH. Skipping environment activation.") {
+		t.Errorf("missing warning in output: %q", log)
+	}
+
+	err = newApp().Run([]string{"toolprovider", "--config", configPath, "activate", "--format", "envman"})
+	if err == nil || !strings.Contains(err.Error(), "envman is not installed or not in PATH") {
+		t.Errorf("expected an error for the explicit envman format, got %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Stage identifies the step of the pipeline that failed, so that callers can tell apart a broken config
+// from a failed install (e.g. to use different exit codes).
+type Stage int
+
+const (
+	// StageConfig covers reading and validating the tool declarations.
+	StageConfig Stage = iota + 1
+	// StageLockfile covers reading, checking and writing the lockfile.
+	StageLockfile
+	// StageInstall covers creating the providers, resolving versions, installing and uninstalling tools.
+	StageInstall
+	// StageActivate covers the environment activation of the installed tools.
+	StageActivate
+)
+
+func (s Stage) String() string {
+	switch s {
+	case StageConfig:
+		return "config"
+	case StageLockfile:
+		return "lockfile"
+	case StageInstall:
+		return "install"
+	case StageActivate:
+		return "activate"
+	default:
+		return "unknown"
+	}
+}
+
+// StageError wraps every error returned by the pipeline with the stage it happened in.
+type StageError struct {
+	Stage Stage
+	Err   error
+}
+
+func (e StageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e StageError) Unwrap() error {
+	return e.Err
+}
+
+// ToolFailure is the failure of a single tool in a continue-on-error install.
+type ToolFailure struct {
+	ToolName string
+	Stage    Stage
+	Err      error
+}
+
+// InstallErrors collects every failure of an install with Options.ContinueOnError, in install order.
+// errors.As() and errors.Is() check each failure, e.g. to find a provider.ToolInstallError.
+type InstallErrors []ToolFailure
+
+func (e InstallErrors) Error() string {
+	var b strings.Builder
+	if len(e) == 1 {
+		b.WriteString("1 tool failed:")
+	} else {
+		fmt.Fprintf(&b, "%d tools failed:", len(e))
+	}
+	for _, f := range e {
+		fmt.Fprintf(&b, "\n- %s (%s): %s", f.ToolName, f.Stage, strings.ReplaceAll(f.Err.Error(), "\n", "\n  "))
+	}
+	return b.String()
+}
+
+func (e InstallErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, f := range e {
+		errs = append(errs, f.Err)
+	}
+	return errs
+}
@@ -0,0 +1,640 @@
+// Package pipeline implements the whole tool setup flow: reading the tool declarations, resolving and installing
+// the tools through their providers, and collecting the environment activations.
+// It's used by the toolprovider CLI, but it's meant to be called directly by other Go programs too (e.g. the Bitrise CLI).
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bitrise-io/toolprovider/config"
+	"github.com/bitrise-io/toolprovider/provider"
+)
+
+type Options struct {
+	// ConfigPath is the path of bitrise.yml.
+	ConfigPath string
+	// WorkDir is where version files (.tool-versions, .nvmrc, etc.) are looked up, see config.ParseVersionFiles().
+	// Empty means the current working directory.
+	WorkDir string
+	// WorkflowID selects the tool declarations of a workflow, see config.ParseWorkflowToolDeclarations().
+	// Empty means the global declarations only.
+	WorkflowID string
+	// Tools limits the setup to these tools and their dependencies. Empty means every declared tool.
+	// The lockfile entries of the other tools are kept.
+	Tools []string
+	// ProviderID overrides the provider of every tool, including the ones with an explicit provider in bitrise.yml.
+	ProviderID string
+	// LockfilePath is the path of the lockfile. Empty means config.DefaultLockfileName next to ConfigPath.
+	LockfilePath string
+	// Frozen installs the exact versions from the lockfile and fails if the lockfile is out of date.
+	// The lockfile is not written in this mode.
+	Frozen bool
+	// ContinueOnError tries every tool instead of stopping at the first failure, see Pipeline.Install().
+	// It's also enabled by tool_config.continue_on_error.
+	ContinueOnError bool
+	// Parallelism is the maximum number of tools installed (or resolved) at the same time.
+	// Zero means tool_config.parallelism, which defaults to 1.
+	Parallelism int
+	// UseNativeTools uses the native install of a tool instead of installing it with the provider if the native
+	// version satisfies the request, see provider.ToolProvider.IsInstalledNative().
+	// It's also enabled by tool_config.use_native_tools.
+	UseNativeTools bool
+	// ToolTimeout limits the time spent on a single tool by Install() and Plan(). Waiting for a provider that installs
+	// one tool at a time doesn't count. Zero means tool_config.tool_timeout, no limit if that's not set either.
+	ToolTimeout time.Duration
+	// RunTimeout limits the time spent on a whole Install(), Plan() or Prune().
+	// Zero means tool_config.run_timeout, no limit if that's not set either.
+	RunTimeout time.Duration
+	// Log receives human-readable progress messages. Nil means no output.
+	Log io.Writer
+}
+
+// InstalledTool is a tool that was installed (or found to be installed already) and activated.
+type InstalledTool struct {
+	Request provider.ToolRequest
+	// ProviderID is the provider that served the tool. For a fallback chain, it's the member that installed the tool.
+	ProviderID string
+	Result     provider.ToolInstallResult
+	Activation provider.EnvironmentActivation
+	Timings    Timings
+	// Events are the events the provider emitted while it set up the tool, see provider.Emit().
+	Events []provider.Event
+	// Err is set if the tool failed to install or activate, or if it was skipped because a dependency failed.
+	// Failed tools have no activation.
+	Err error
+}
+
+// Timings breaks down the time spent on a tool.
+type Timings struct {
+	// Resolve is the time spent resolving the requested version, see provider.ToolInstallResult.ResolveDuration.
+	Resolve time.Duration
+	// Install is the rest of the InstallTool call, including post-install commands.
+	Install  time.Duration
+	Activate time.Duration
+}
+
+type Pipeline struct {
+	opts       Options
+	requests   []provider.ToolRequest
+	dispatcher *provider.Dispatcher
+	// bitriseYml and versionFiles are kept for Prune(), which looks at the declarations of every workflow.
+	bitriseYml   config.BitriseYml
+	versionFiles map[string]provider.ToolRequest
+}
+
+// Load reads the tool declarations of every source and orders them for installation, see config.InstallOrder().
+// Providers are created from the registry with their tool_config.provider_options, but not until they are needed
+// by Plan() or Install().
+func Load(opts Options, registry *provider.Registry) (*Pipeline, error) {
+	if opts.Log == nil {
+		opts.Log = io.Discard
+	}
+	if opts.LockfilePath == "" {
+		opts.LockfilePath = filepath.Join(filepath.Dir(opts.ConfigPath), config.DefaultLockfileName)
+	}
+	if opts.WorkDir == "" {
+		workDir, err := os.Getwd()
+		if err != nil {
+			return nil, StageError{Stage: StageConfig, Err: fmt.Errorf("get working directory: %w", err)}
+		}
+		opts.WorkDir = workDir
+	}
+
+	bitriseYml, err := config.ParseBitriseYml(opts.ConfigPath)
+	if err != nil {
+		return nil, StageError{Stage: StageConfig, Err: err}
+	}
+	err = config.ValidateToolBlocks(bitriseYml)
+	if err != nil {
+		return nil, StageError{Stage: StageConfig, Err: err}
+	}
+	toolConfig, err := config.ParseToolConfig(bitriseYml)
+	if err != nil {
+		return nil, StageError{Stage: StageConfig, Err: err}
+	}
+
+	// Manifest paths are relative to bitrise.yml
+	for providerID, providerOpts := range toolConfig.ProviderOptions {
+		if providerOpts.Manifest != "" && !filepath.IsAbs(providerOpts.Manifest) {
+			providerOpts.Manifest = filepath.Join(filepath.Dir(opts.ConfigPath), providerOpts.Manifest)
+			toolConfig.ProviderOptions[providerID] = providerOpts
+		}
+	}
+
+	var declarations map[string]provider.ToolRequest
+	if opts.WorkflowID != "" {
+		declarations, err = config.ParseWorkflowToolDeclarations(bitriseYml, opts.WorkflowID)
+	} else {
+		declarations, err = config.ParseToolDeclarations(bitriseYml)
+	}
+	if err != nil && !errors.Is(err, config.ErrNoToolDeclarations) {
+		return nil, StageError{Stage: StageConfig, Err: err}
+	}
+	versionFileDeclarations, err := config.ParseVersionFiles(opts.WorkDir)
+	if err != nil {
+		return nil, StageError{Stage: StageConfig, Err: fmt.Errorf("parse version files: %w", err)}
+	}
+	declarations = config.MergeToolDeclarations(declarations, versionFileDeclarations)
+
+	opts.ContinueOnError = opts.ContinueOnError || toolConfig.ContinueOnError
+	opts.UseNativeTools = opts.UseNativeTools || toolConfig.UseNativeTools
+	if opts.Parallelism == 0 {
+		opts.Parallelism = max(toolConfig.Parallelism, 1)
+	}
+	if opts.ToolTimeout == 0 {
+		opts.ToolTimeout = toolConfig.ToolTimeout
+	}
+	if opts.RunTimeout == 0 {
+		opts.RunTimeout = toolConfig.RunTimeout
+	}
+	if opts.ProviderID != "" {
+		toolConfig.Provider = opts.ProviderID
+		toolConfig.Providers = nil
+		for name, request := range declarations {
+			request.ProviderID = ""
+			declarations[name] = request
+		}
+	}
+	declarations = config.AssignProviders(declarations, toolConfig)
+
+	if opts.Frozen && len(declarations) > 0 {
+		lockfile, err := config.ReadLockfile(opts.LockfilePath)
+		if err != nil {
+			return nil, StageError{Stage: StageLockfile, Err: err}
+		}
+		declarations, err = config.FreezeToolRequests(declarations, lockfile, opts.WorkflowID)
+		if err != nil {
+			return nil, StageError{Stage: StageLockfile, Err: err}
+		}
+		fmt.Fprintf(opts.Log, "Using locked versions from %s\n", opts.LockfilePath)
+	}
+
+	requests, err := config.InstallOrder(declarations, config.DeclarationOrder(bitriseYml, opts.WorkflowID))
+	if err != nil {
+		return nil, StageError{Stage: StageConfig, Err: err}
+	}
+	for i := range requests {
+		requests[i].ToolName = provider.GetCanonicalToolName(requests[i].ToolName)
+	}
+	if len(opts.Tools) > 0 {
+		requests, err = selectTools(requests, opts.Tools)
+		if err != nil {
+			return nil, StageError{Stage: StageConfig, Err: err}
+		}
+	}
+
+	return &Pipeline{
+		opts:       opts,
+		requests:   requests,
+		dispatcher: provider.NewDispatcher(toolConfig.Provider, registry.ProviderFactory(toolConfig.ProviderOptions)),
+
+		bitriseYml:   bitriseYml,
+		versionFiles: versionFileDeclarations,
+	}, nil
+}
+
+// ToolRequests returns the tool requests in install order, with canonical tool names and providers assigned.
+func (p *Pipeline) ToolRequests() []provider.ToolRequest {
+	return p.requests
+}
+
+// Plan resolves every tool request without installing anything, see provider.ToolProvider.PlanInstall().
+// Up to Options.Parallelism tools are resolved at the same time.
+func (p *Pipeline) Plan(ctx context.Context) ([]provider.ToolInstallPlan, error) {
+	ctx, cancel := p.runContext(ctx)
+	defer cancel()
+
+	plans := make([]provider.ToolInstallPlan, len(p.requests))
+	errs := make([]error, len(p.requests))
+	workers := make(chan struct{}, p.opts.Parallelism)
+	var wg sync.WaitGroup
+	for i, request := range p.requests {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func() {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+			toolProvider, err := p.dispatcher.ProviderFor(ctx, request)
+			if err != nil {
+				errs[i] = p.contextError(ctx, ctx, err)
+				return
+			}
+			if native, ok := p.findNative(ctx, toolProvider, request, io.Discard); ok {
+				plans[i] = provider.ToolInstallPlan{
+					ToolName:           request.ToolName,
+					RequestedVersion:   request.UnparsedVersion,
+					ResolutionStrategy: request.ResolutionStrategy,
+					ResolvedVersion:    native.Version,
+					IsInstalled:        true,
+					IsNative:           true,
+					ProviderID:         toolProvider.ID(),
+				}
+				return
+			}
+
+			unlock := p.lockProvider(toolProvider)
+			defer unlock()
+			toolCtx, cancel := p.toolContext(ctx)
+			defer cancel()
+			plans[i], err = toolProvider.PlanInstall(toolCtx, request)
+			if err != nil {
+				errs[i] = fmt.Errorf("plan %s: %w", request.ToolName, p.contextError(ctx, toolCtx, err))
+			}
+			if plans[i].ProviderID == "" {
+				plans[i].ProviderID = toolProvider.ID()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, StageError{Stage: StageInstall, Err: err}
+		}
+	}
+	return plans, nil
+}
+
+// Install installs the missing tools and activates every tool, so that tools can use the environment of
+// their dependencies while they install. The resolved versions are written to the lockfile unless Options.Frozen
+// is set or a tool failed.
+//
+// Up to Options.Parallelism tools are installed at the same time, a tool starts once its dependencies are done.
+// Tools are started in install order, so a parallelism of 1 installs them one after another. Providers that don't
+// support concurrent installs (see provider.ToolProvider.SupportsConcurrentInstalls()) install one tool at a time
+// regardless. The log of each tool is written at once when the tool is done, so that the logs of concurrent installs
+// don't interleave. Provider events are passed to the observer of ctx (see provider.WithObserver()) as they happen.
+//
+// The returned tools are in install order and include the failed ones (see InstalledTool.Err), so that failures can
+// be reported. Tools whose dependency failed are not attempted and fail too. The failure of an optional tool
+// (see provider.ToolRequest.Optional) is only logged, the others stop the install (after the running installs
+// finish), unless Options.ContinueOnError is set: then every tool is tried and the failures are returned together
+// as InstallErrors.
+//
+// When ctx is done or Options.RunTimeout is over, the running installs are stopped and no more tools are started.
+// A tool that runs out of Options.ToolTimeout fails like any other failed tool.
+func (p *Pipeline) Install(ctx context.Context) ([]InstalledTool, error) {
+	ctx, cancel := p.runContext(ctx)
+	defer cancel()
+
+	type outcome struct {
+		index int
+		tool  InstalledTool
+		stage Stage
+		log   *bytes.Buffer
+	}
+
+	indexes := make(map[string]int, len(p.requests))
+	for i, request := range p.requests {
+		indexes[request.ToolName] = i
+	}
+	tools := make([]InstalledTool, len(p.requests))
+	started := make([]bool, len(p.requests))
+	done := make([]bool, len(p.requests))
+	isReady := func(request provider.ToolRequest) bool {
+		for _, dependency := range request.DependsOn {
+			if i, ok := indexes[dependency]; ok && !done[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	outcomes := make(chan outcome)
+	running := 0
+	stopped := false
+	var failures InstallErrors
+	for {
+		for i, request := range p.requests {
+			if stopped || running == p.opts.Parallelism || ctx.Err() != nil {
+				break
+			}
+			if started[i] || !isReady(request) {
+				continue
+			}
+
+			// Dependencies are done, so the finished tools are enough for the dependency env.
+			var finished []InstalledTool
+			for j := range tools {
+				if done[j] {
+					finished = append(finished, tools[j])
+				}
+			}
+			var toolLog io.Writer = p.opts.Log
+			var buffer *bytes.Buffer
+			if p.opts.Parallelism > 1 {
+				buffer = &bytes.Buffer{}
+				toolLog = buffer
+			}
+
+			started[i] = true
+			running++
+			go func() {
+				tool, stage := p.installTool(ctx, request, finished, toolLog)
+				outcomes <- outcome{index: i, tool: tool, stage: stage, log: buffer}
+			}()
+		}
+		if running == 0 {
+			break
+		}
+
+		o := <-outcomes
+		running--
+		done[o.index] = true
+		tools[o.index] = o.tool
+		if o.log != nil {
+			_, _ = o.log.WriteTo(p.opts.Log)
+		}
+
+		request := o.tool.Request
+		if o.tool.Err == nil {
+			continue
+		}
+		if request.Optional {
+			fmt.Fprintf(p.opts.Log, "Warning: optional tool %s failed, continuing without it: %s\n", request.ToolName, o.tool.Err)
+			continue
+		}
+		failures = append(failures, ToolFailure{ToolName: request.ToolName, Stage: o.stage, Err: o.tool.Err})
+		if p.opts.ContinueOnError {
+			fmt.Fprintf(p.opts.Log, "Failed to set up %s, continuing with the remaining tools: %s\n", request.ToolName, o.tool.Err)
+		} else {
+			stopped = true
+		}
+	}
+
+	var finished []InstalledTool
+	var lockedTools []config.LockedTool
+	for i, tool := range tools {
+		if !done[i] {
+			continue
+		}
+		finished = append(finished, tool)
+		if tool.Err == nil {
+			lockedTools = append(lockedTools, config.NewLockedTool(tool.ProviderID, tool.Request, tool.Result))
+		}
+	}
+
+	if ctx.Err() != nil && slices.Contains(done, false) {
+		return finished, StageError{Stage: StageInstall, Err: fmt.Errorf("install stopped before every tool was set up: %w", p.contextError(ctx, ctx, ctx.Err()))}
+	}
+	if len(failures) > 0 {
+		// Failures are collected in completion order, but they are reported in install order.
+		slices.SortStableFunc(failures, func(a, b ToolFailure) int {
+			return indexes[a.ToolName] - indexes[b.ToolName]
+		})
+		if !p.opts.ContinueOnError && len(failures) == 1 {
+			return finished, StageError{Stage: failures[0].Stage, Err: failures[0].Err}
+		}
+		return finished, StageError{Stage: failures[0].Stage, Err: failures}
+	}
+
+	if !p.opts.Frozen && len(lockedTools) > 0 {
+		if len(lockedTools) < len(finished) {
+			fmt.Fprintln(p.opts.Log, "The optional tools that failed are left out of the lockfile.")
+		}
+		// The entries of other workflows are kept, see config.Lockfile.
+		lockfile, err := config.ReadLockfile(p.opts.LockfilePath)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return finished, StageError{Stage: StageLockfile, Err: err}
+		}
+		if len(p.opts.Tools) > 0 {
+			// Only the selected tools were installed, the locked versions of the others are kept
+			lockedTools = append(slices.DeleteFunc(slices.Clone(lockfile.ToolsOf(p.opts.WorkflowID)), func(locked config.LockedTool) bool {
+				return slices.ContainsFunc(lockedTools, func(t config.LockedTool) bool { return t.ToolName == locked.ToolName })
+			}), lockedTools...)
+		}
+		lockfile.SetTools(p.opts.WorkflowID, lockedTools)
+		err = config.WriteLockfile(p.opts.LockfilePath, lockfile)
+		if err != nil {
+			return finished, StageError{Stage: StageLockfile, Err: err}
+		}
+		fmt.Fprintf(p.opts.Log, "Resolved versions written to %s\n", p.opts.LockfilePath)
+	}
+
+	return finished, nil
+}
+
+// installTool installs and activates a single tool. finished are the tools that are done, in install order.
+// If the tool fails, its Err is set and the returned stage tells where it failed.
+func (p *Pipeline) installTool(ctx context.Context, request provider.ToolRequest, finished []InstalledTool, log io.Writer) (InstalledTool, Stage) {
+	tool := InstalledTool{Request: request, ProviderID: request.ProviderID}
+	for _, t := range finished {
+		if t.Err != nil && slices.Contains(request.DependsOn, t.Request.ToolName) {
+			tool.Err = fmt.Errorf("dependency %s failed to install", t.Request.ToolName)
+			return tool, StageInstall
+		}
+	}
+	tool.Request.DependencyEnv = dependencyEnv(request, finished)
+
+	// The provider events of the tool are recorded for the report and passed on to the observer of the caller.
+	callerCtx := ctx
+	var eventsMu sync.Mutex
+	ctx = provider.WithObserver(ctx, provider.ObserverFunc(func(e provider.Event) {
+		eventsMu.Lock()
+		tool.Events = append(tool.Events, e)
+		eventsMu.Unlock()
+		provider.Emit(callerCtx, e)
+	}))
+
+	toolProvider, err := p.dispatcher.ProviderFor(ctx, request)
+	if err != nil {
+		tool.Err = p.contextError(ctx, ctx, err)
+		return tool, StageInstall
+	}
+	tool.ProviderID = toolProvider.ID()
+
+	var result provider.ToolInstallResult
+	nativeStart := time.Now()
+	native, isNative := p.findNative(ctx, toolProvider, request, log)
+	if !isNative {
+		unlock := p.lockProvider(toolProvider)
+		defer unlock()
+	}
+	// The timeout starts once the provider is free, so that waiting for the installs of other tools doesn't count.
+	toolCtx, cancel := p.toolContext(ctx)
+	defer cancel()
+
+	if isNative {
+		result = provider.ToolInstallResult{
+			ToolName:           request.ToolName,
+			IsAlreadyInstalled: true,
+			ConcreteVersion:    native.Version,
+			ResolveDuration:    time.Since(nativeStart),
+			NativeTool:         &native,
+		}
+		tool.Timings.Resolve = result.ResolveDuration
+	} else {
+		fmt.Fprintf(log, "Installing %s %s with %s...\n", request.ToolName, request.UnparsedVersion, toolProvider.ID())
+		installStart := time.Now()
+		result, err = toolProvider.InstallTool(toolCtx, tool.Request)
+		installDuration := time.Since(installStart)
+		tool.Timings.Resolve = result.ResolveDuration
+		tool.Timings.Install = installDuration - result.ResolveDuration
+		if err != nil {
+			tool.Timings.Install = installDuration
+			tool.Err = err
+			if toolCtx.Err() != nil {
+				// Errors of stopped installs don't always tell which tool it was
+				tool.Err = fmt.Errorf("install %s %s: %w", request.ToolName, request.UnparsedVersion, p.contextError(ctx, toolCtx, err))
+			}
+			return tool, StageInstall
+		}
+	}
+	tool.Result = result
+	if result.ProviderID != "" {
+		tool.ProviderID = result.ProviderID
+	}
+	if result.NativeTool != nil {
+		fmt.Fprintf(log, "Using native %s %s from %s.\n", result.ToolName, result.ConcreteVersion, result.NativeTool.Executable)
+	} else if result.IsAlreadyInstalled {
+		fmt.Fprintf(log, "%s %s is already installed.\n", result.ToolName, result.ConcreteVersion)
+	} else {
+		fmt.Fprintf(log, "Successfully installed %s %s.\n", result.ToolName, result.ConcreteVersion)
+	}
+
+	activateStart := time.Now()
+	activation, err := toolProvider.ActivateEnv(toolCtx, result)
+	tool.Timings.Activate = time.Since(activateStart)
+	if err != nil {
+		tool.Err = fmt.Errorf("activate tool %s: %w", result.ToolName, p.contextError(ctx, toolCtx, err))
+		return tool, StageActivate
+	}
+	tool.Activation = activation
+	return tool, 0
+}
+
+// findNative returns the native install of the tool if Options.UseNativeTools is set and the native version satisfies
+// the request. Detection errors are only logged, the provider installs the tool in that case.
+func (p *Pipeline) findNative(ctx context.Context, toolProvider provider.ToolProvider, request provider.ToolRequest, log io.Writer) (provider.NativeTool, bool) {
+	if !p.opts.UseNativeTools {
+		return provider.NativeTool{}, false
+	}
+	native, ok, err := toolProvider.IsInstalledNative(ctx, request)
+	if err != nil {
+		fmt.Fprintf(log, "Warning: failed to look for a native %s install: %s\n", request.ToolName, err)
+		return provider.NativeTool{}, false
+	}
+	return native, ok
+}
+
+// runContext applies Options.RunTimeout to ctx.
+func (p *Pipeline) runContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.opts.RunTimeout == 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, p.opts.RunTimeout)
+}
+
+// toolContext applies Options.ToolTimeout to the context of a run.
+func (p *Pipeline) toolContext(runCtx context.Context) (context.Context, context.CancelFunc) {
+	if p.opts.ToolTimeout == 0 {
+		return context.WithCancel(runCtx)
+	}
+	return context.WithTimeout(runCtx, p.opts.ToolTimeout)
+}
+
+// contextError tells which timeout stopped a provider call if err was caused by one. Other errors,
+// including cancellation by the caller, are returned as they are.
+func (p *Pipeline) contextError(runCtx context.Context, toolCtx context.Context, err error) error {
+	switch {
+	case errors.Is(runCtx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("run timed out after %s: %w", p.opts.RunTimeout, err)
+	case errors.Is(toolCtx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("timed out after %s: %w", p.opts.ToolTimeout, err)
+	default:
+		return err
+	}
+}
+
+// lockProvider makes sure that a provider without concurrent install support is used by one tool at a time,
+// including the tools that use it through a fallback chain. It returns the function that releases the provider.
+func (p *Pipeline) lockProvider(toolProvider provider.ToolProvider) func() {
+	return p.dispatcher.Lock(toolProvider)
+}
+
+// Providers returns the providers that were used so far, sorted by ID.
+func (p *Pipeline) Providers() []provider.ToolProvider {
+	return p.dispatcher.Providers()
+}
+
+// MergedActivation merges the activations of every installed tool in install order, see provider.MergeActivations().
+// Failed tools have no activation, so they are left out.
+func MergedActivation(installed []InstalledTool) provider.EnvironmentActivation {
+	activations := make([]provider.EnvironmentActivation, 0, len(installed))
+	for _, tool := range installed {
+		activations = append(activations, tool.Activation)
+	}
+	return provider.MergeActivations(activations...)
+}
+
+// selectTools keeps the requests of the given tools and their dependencies (including the transitive ones) in install order.
+func selectTools(requests []provider.ToolRequest, toolNames []string) ([]provider.ToolRequest, error) {
+	byName := make(map[string]provider.ToolRequest, len(requests))
+	for _, request := range requests {
+		byName[request.ToolName] = request
+	}
+
+	selected := map[string]bool{}
+	var collect func(toolNames []string)
+	collect = func(toolNames []string) {
+		for _, name := range toolNames {
+			if selected[name] {
+				continue
+			}
+			selected[name] = true
+			collect(byName[name].DependsOn)
+		}
+	}
+	for _, name := range toolNames {
+		canonicalName := provider.GetCanonicalToolName(name)
+		if _, ok := byName[canonicalName]; !ok {
+			return nil, fmt.Errorf("tool %s is not declared", name)
+		}
+		collect([]string{canonicalName})
+	}
+
+	return slices.DeleteFunc(requests, func(request provider.ToolRequest) bool {
+		return !selected[request.ToolName]
+	}), nil
+}
+
+// dependencyEnv merges the activations of the tool's dependencies, including the transitive ones.
+// Activations are merged in install order, the same way as the final environment activation.
+func dependencyEnv(tool provider.ToolRequest, installed []InstalledTool) provider.EnvironmentActivation {
+	dependencies := map[string]bool{}
+	var collect func(toolNames []string)
+	collect = func(toolNames []string) {
+		for _, name := range toolNames {
+			if dependencies[name] {
+				continue
+			}
+			dependencies[name] = true
+			for _, t := range installed {
+				if t.Request.ToolName == name {
+					collect(t.Request.DependsOn)
+				}
+			}
+		}
+	}
+	collect(tool.DependsOn)
+
+	var activations []provider.EnvironmentActivation
+	for _, t := range installed {
+		if dependencies[t.Request.ToolName] {
+			activations = append(activations, t.Activation)
+		}
+	}
+	return provider.MergeActivations(activations...)
+}
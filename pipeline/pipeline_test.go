@@ -0,0 +1,658 @@
+package pipeline_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/toolprovider/config"
+	"github.com/bitrise-io/toolprovider/pipeline"
+	"github.com/bitrise-io/toolprovider/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const bitriseYml = `format_version: "17"
+
+meta:
+  experimental:
+    tools:
+      ruby:
+        version: 3.3:latest
+        depends_on: node
+      nodejs: "20"
+      golang:
+        version: "1.22"
+        provider: mise
+`
+
+// fakeProvider installs every tool as <requested version>.0 and activates it as $<TOOL>_VERSION and /<tool>/bin.
+type fakeProvider struct {
+	id              string
+	failingToolName string
+	*fakeState
+}
+
+// fakeState is shared by the providers of a factory.
+type fakeState struct {
+	mu             sync.Mutex
+	dependencyEnvs map[string]provider.EnvironmentActivation
+	// installDelay makes installs take long enough to overlap when they run concurrently.
+	installDelay time.Duration
+	running      int
+	maxRunning   int
+	// installed is returned by ListInstalled() for each provider ID, uninstalled records UninstallTool() calls.
+	installed   map[string][]provider.InstalledVersion
+	uninstalled []string
+	// providerOptions are the options the providers were created with.
+	providerOptions map[string]provider.ProviderOptions
+	// unknownTools are the tools that each provider ID fails to install with provider.ErrToolNotFound.
+	unknownTools map[string][]string
+}
+
+func (p fakeProvider) ID() string { return p.id }
+
+func (p fakeProvider) Version() (string, error) { return "1.0.0", nil }
+
+func (p fakeProvider) Bootstrap(ctx context.Context) error { return nil }
+
+// IsInstalledNative finds tools whose name starts with "native-" with version 1.0.0.
+func (p fakeProvider) IsInstalledNative(ctx context.Context, tool provider.ToolRequest) (provider.NativeTool, bool, error) {
+	if !strings.HasPrefix(tool.ToolName, "native-") {
+		return provider.NativeTool{}, false, nil
+	}
+	return provider.NativeTool{ToolName: tool.ToolName, Version: "1.0.0", Executable: "/usr/bin/" + tool.ToolName}, true, nil
+}
+
+// SupportsConcurrentInstalls is false for asdf, the same way as the real asdf provider.
+func (p fakeProvider) SupportsConcurrentInstalls() bool { return p.id != "asdf" }
+
+func (p fakeProvider) InstallTool(ctx context.Context, tool provider.ToolRequest) (provider.ToolInstallResult, error) {
+	p.mu.Lock()
+	p.running++
+	p.maxRunning = max(p.maxRunning, p.running)
+	p.mu.Unlock()
+	provider.Emit(ctx, provider.Event{Kind: provider.EventOutput, ProviderID: p.id, ToolName: tool.ToolName, Message: "installing " + tool.ToolName})
+	var err error
+	select {
+	case <-time.After(p.installDelay):
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.running--
+	if err != nil {
+		return provider.ToolInstallResult{}, err
+	}
+
+	if slices.Contains(p.unknownTools[p.id], tool.ToolName) {
+		return provider.ToolInstallResult{}, provider.ToolInstallError{ToolName: tool.ToolName, RequestedVersion: tool.UnparsedVersion, Err: provider.ErrToolNotFound}
+	}
+	if tool.ToolName == p.failingToolName {
+		return provider.ToolInstallResult{}, provider.ToolInstallError{ToolName: tool.ToolName, RequestedVersion: tool.UnparsedVersion}
+	}
+	p.dependencyEnvs[tool.ToolName] = tool.DependencyEnv
+	return provider.ToolInstallResult{ToolName: tool.ToolName, ConcreteVersion: tool.UnparsedVersion + ".0"}, nil
+}
+
+func (p fakeProvider) PlanInstall(ctx context.Context, tool provider.ToolRequest) (provider.ToolInstallPlan, error) {
+	return provider.ToolInstallPlan{ToolName: tool.ToolName, ResolvedVersion: tool.UnparsedVersion + ".0"}, nil
+}
+
+func (p fakeProvider) ListInstalled(ctx context.Context) ([]provider.InstalledVersion, error) {
+	return p.installed[p.id], nil
+}
+
+func (p fakeProvider) UninstallTool(ctx context.Context, toolName string, version string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.uninstalled = append(p.uninstalled, p.id+":"+toolName+"@"+version)
+	return nil
+}
+
+func (p fakeProvider) ActivateEnv(ctx context.Context, result provider.ToolInstallResult) (provider.EnvironmentActivation, error) {
+	if result.NativeTool != nil {
+		return result.NativeTool.Activation(), nil
+	}
+	return provider.EnvironmentActivation{
+		ContributedEnvVars: map[string]string{result.ToolName + "_VERSION": result.ConcreteVersion},
+		ContributedPaths:   []string{"/" + result.ToolName + "/bin"},
+	}, nil
+}
+
+func newFakeRegistry(dependencyEnvs map[string]provider.EnvironmentActivation, failingToolName string) *provider.Registry {
+	return newFakeRegistryWithState(&fakeState{dependencyEnvs: dependencyEnvs}, failingToolName)
+}
+
+// newFakeRegistryWithState registers fake asdf and mise providers that record their options in the state.
+func newFakeRegistryWithState(state *fakeState, failingToolName string) *provider.Registry {
+	registry := provider.NewRegistry()
+	for _, providerID := range []string{"asdf", "mise"} {
+		registry.Register(providerID, func(opts provider.ProviderOptions) (provider.ToolProvider, error) {
+			state.mu.Lock()
+			defer state.mu.Unlock()
+			if state.providerOptions == nil {
+				state.providerOptions = map[string]provider.ProviderOptions{}
+			}
+			state.providerOptions[providerID] = opts
+			return fakeProvider{id: providerID, failingToolName: failingToolName, fakeState: state}, nil
+		})
+	}
+	return registry
+}
+
+func writeConfig(t *testing.T) string {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "bitrise.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte(bitriseYml), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".python-version"), []byte("3.12\n"), 0644))
+	// Version files are looked up in the working directory
+	workDir, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() {
+		require.NoError(t, os.Chdir(workDir))
+	})
+	return configPath
+}
+
+func TestLoad(t *testing.T) {
+	configPath := writeConfig(t)
+
+	p, err := pipeline.Load(pipeline.Options{ConfigPath: configPath}, newFakeRegistry(nil, ""))
+	require.NoError(t, err)
+
+	var summary [][]string
+	for _, r := range p.ToolRequests() {
+		summary = append(summary, []string{r.ToolName, r.UnparsedVersion, r.ProviderID})
+	}
+	assert.Equal(t, [][]string{
+		{"nodejs", "20", "asdf"},
+		{"ruby", "3.3", "asdf"},
+		{"golang", "1.22", "mise"},
+		{"python", "3.12", "asdf"},
+	}, summary)
+
+	p, err = pipeline.Load(pipeline.Options{ConfigPath: configPath, WorkDir: t.TempDir()}, newFakeRegistry(nil, ""))
+	require.NoError(t, err)
+	assert.Len(t, p.ToolRequests(), 3, "version files are not looked up next to bitrise.yml")
+
+	p, err = pipeline.Load(pipeline.Options{ConfigPath: configPath, ProviderID: "mise"}, newFakeRegistry(nil, ""))
+	require.NoError(t, err)
+	for _, r := range p.ToolRequests() {
+		assert.Equal(t, "mise", r.ProviderID, r.ToolName)
+	}
+
+	p, err = pipeline.Load(pipeline.Options{ConfigPath: configPath, Tools: []string{"python", "ruby"}}, newFakeRegistry(nil, ""))
+	require.NoError(t, err)
+	var toolNames []string
+	for _, r := range p.ToolRequests() {
+		toolNames = append(toolNames, r.ToolName)
+	}
+	assert.Equal(t, []string{"nodejs", "ruby", "python"}, toolNames, "dependencies are included in install order")
+
+	_, err = pipeline.Load(pipeline.Options{ConfigPath: configPath, Tools: []string{"java"}}, newFakeRegistry(nil, ""))
+	assertStage(t, pipeline.StageConfig, err)
+	assert.EqualError(t, err, "tool java is not declared")
+}
+
+func TestInstall(t *testing.T) {
+	configPath := writeConfig(t)
+	dependencyEnvs := map[string]provider.EnvironmentActivation{}
+
+	p, err := pipeline.Load(pipeline.Options{ConfigPath: configPath}, newFakeRegistry(dependencyEnvs, ""))
+	require.NoError(t, err)
+	var observed []string
+	ctx := provider.WithObserver(context.Background(), provider.ObserverFunc(func(e provider.Event) {
+		observed = append(observed, e.ToolName+": "+e.Message)
+	}))
+	installed, err := p.Install(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"nodejs: installing nodejs", "ruby: installing ruby", "golang: installing golang", "python: installing python"}, observed)
+	require.Len(t, installed[1].Events, 1)
+	assert.Equal(t, "installing ruby", installed[1].Events[0].Message)
+	assert.False(t, installed[1].Events[0].Time.IsZero())
+
+	require.Len(t, installed, 4)
+	assert.Equal(t, "mise", installed[2].ProviderID)
+	assert.Equal(t, []string{"/nodejs/bin"}, dependencyEnvs["ruby"].ContributedPaths, "ruby is installed with its dependency activated")
+	assert.Empty(t, dependencyEnvs["golang"].ContributedPaths)
+	assert.Equal(t, []string{"/nodejs/bin", "/ruby/bin", "/golang/bin", "/python/bin"}, pipeline.MergedActivation(installed).ContributedPaths)
+
+	lockfilePath := filepath.Join(filepath.Dir(configPath), config.DefaultLockfileName)
+	lockfile, err := config.ReadLockfile(lockfilePath)
+	require.NoError(t, err)
+	assert.Len(t, lockfile.Tools, 4)
+
+	// The frozen run uses the locked versions
+	p, err = pipeline.Load(pipeline.Options{ConfigPath: configPath, Frozen: true}, newFakeRegistry(dependencyEnvs, ""))
+	require.NoError(t, err)
+	assert.Equal(t, "3.3.0", p.ToolRequests()[1].UnparsedVersion)
+}
+
+func TestWorkflowLockfile(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "bitrise.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`format_version: "17"
+
+meta:
+  experimental:
+    tools:
+      nodejs: "20"
+
+workflows:
+  deploy:
+    meta:
+      experimental:
+        tools:
+          nodejs: "22"
+          ruby: "3.3"
+`), 0644))
+	dependencyEnvs := map[string]provider.EnvironmentActivation{}
+
+	for _, workflowID := range []string{"", "deploy"} {
+		p, err := pipeline.Load(pipeline.Options{ConfigPath: configPath, WorkflowID: workflowID}, newFakeRegistry(dependencyEnvs, ""))
+		require.NoError(t, err)
+		_, err = p.Install(context.Background())
+		require.NoError(t, err)
+	}
+
+	// Each run keeps the locked versions of the other one
+	for workflowID, expected := range map[string][]string{"": {"20.0"}, "deploy": {"22.0", "3.3.0"}} {
+		p, err := pipeline.Load(pipeline.Options{ConfigPath: configPath, WorkflowID: workflowID, Frozen: true}, newFakeRegistry(dependencyEnvs, ""))
+		require.NoError(t, err, workflowID)
+		var versions []string
+		for _, r := range p.ToolRequests() {
+			versions = append(versions, r.UnparsedVersion)
+		}
+		assert.Equal(t, expected, versions, workflowID)
+	}
+}
+
+func TestSelectedToolsLockfile(t *testing.T) {
+	configPath := writeConfig(t)
+	registry := newFakeRegistry(map[string]provider.EnvironmentActivation{}, "")
+
+	p, err := pipeline.Load(pipeline.Options{ConfigPath: configPath}, registry)
+	require.NoError(t, err)
+	_, err = p.Install(context.Background())
+	require.NoError(t, err)
+
+	// Installing a subset of the tools (e.g. exec --tool) keeps the locked versions of the other tools
+	p, err = pipeline.Load(pipeline.Options{ConfigPath: configPath, Tools: []string{"ruby"}}, registry)
+	require.NoError(t, err)
+	_, err = p.Install(context.Background())
+	require.NoError(t, err)
+
+	p, err = pipeline.Load(pipeline.Options{ConfigPath: configPath, Frozen: true}, registry)
+	require.NoError(t, err)
+	require.Len(t, p.ToolRequests(), 4)
+}
+
+func TestFallbackChain(t *testing.T) {
+	configPath := writeConfig(t)
+	state := &fakeState{dependencyEnvs: map[string]provider.EnvironmentActivation{}, unknownTools: map[string][]string{"asdf": {"golang"}}}
+
+	p, err := pipeline.Load(pipeline.Options{ConfigPath: configPath, ProviderID: "asdf,mise"}, newFakeRegistryWithState(state, ""))
+	require.NoError(t, err)
+	installed, err := p.Install(context.Background())
+	require.NoError(t, err)
+
+	providerIDs := map[string]string{}
+	for _, tool := range installed {
+		providerIDs[tool.Request.ToolName] = tool.ProviderID
+	}
+	assert.Equal(t, map[string]string{"nodejs": "asdf", "ruby": "asdf", "golang": "mise", "python": "asdf"}, providerIDs)
+
+	lockfile, err := config.ReadLockfile(filepath.Join(filepath.Dir(configPath), config.DefaultLockfileName))
+	require.NoError(t, err)
+	for _, tool := range lockfile.Tools {
+		assert.Equal(t, providerIDs[tool.ToolName], tool.ProviderID, tool.ToolName)
+	}
+
+	// The frozen run installs each tool with the provider that served it
+	p, err = pipeline.Load(pipeline.Options{ConfigPath: configPath, ProviderID: "asdf,mise", Frozen: true}, newFakeRegistryWithState(state, ""))
+	require.NoError(t, err)
+	for _, r := range p.ToolRequests() {
+		assert.Equal(t, providerIDs[r.ToolName], r.ProviderID, r.ToolName)
+	}
+}
+
+func TestStageErrors(t *testing.T) {
+	configPath := writeConfig(t)
+
+	_, err := pipeline.Load(pipeline.Options{ConfigPath: filepath.Join(t.TempDir(), "bitrise.yml")}, newFakeRegistry(nil, ""))
+	assertStage(t, pipeline.StageConfig, err)
+
+	_, err = pipeline.Load(pipeline.Options{ConfigPath: configPath, Frozen: true}, newFakeRegistry(nil, ""))
+	assertStage(t, pipeline.StageLockfile, err)
+
+	p, err := pipeline.Load(pipeline.Options{ConfigPath: configPath}, newFakeRegistry(map[string]provider.EnvironmentActivation{}, "ruby"))
+	require.NoError(t, err)
+	installed, err := p.Install(context.Background())
+	assertStage(t, pipeline.StageInstall, err)
+	var installErr provider.ToolInstallError
+	assert.True(t, errors.As(err, &installErr))
+	require.Len(t, installed, 2, "the failed tool is returned after the installed ones")
+	assert.NoError(t, installed[0].Err)
+	assert.Equal(t, "ruby", installed[1].Request.ToolName)
+	assert.ErrorAs(t, installed[1].Err, &installErr)
+}
+
+func assertStage(t *testing.T, expected pipeline.Stage, err error) {
+	t.Helper()
+	var stageErr pipeline.StageError
+	require.ErrorAs(t, err, &stageErr)
+	assert.Equal(t, expected, stageErr.Stage)
+}
+
+func TestContinueOnError(t *testing.T) {
+	configPath := writeConfig(t)
+
+	p, err := pipeline.Load(pipeline.Options{ConfigPath: configPath, ContinueOnError: true}, newFakeRegistry(map[string]provider.EnvironmentActivation{}, "nodejs"))
+	require.NoError(t, err)
+	installed, err := p.Install(context.Background())
+	assertStage(t, pipeline.StageInstall, err)
+
+	var installErrs pipeline.InstallErrors
+	require.ErrorAs(t, err, &installErrs)
+	require.Len(t, installErrs, 2)
+	assert.Equal(t, "nodejs", installErrs[0].ToolName)
+	assert.Equal(t, "ruby", installErrs[1].ToolName, "ruby is skipped because its dependency failed")
+	assert.EqualError(t, installErrs[1].Err, "dependency nodejs failed to install")
+	assert.Contains(t, err.Error(), "2 tools failed:\n- nodejs (install): ")
+	assert.Contains(t, err.Error(), "\n- ruby (install): dependency nodejs failed to install")
+	var installErr provider.ToolInstallError
+	assert.ErrorAs(t, err, &installErr)
+
+	require.Len(t, installed, 4)
+	assert.Equal(t, []string{"/golang/bin", "/python/bin"}, pipeline.MergedActivation(installed).ContributedPaths)
+
+	assert.NoFileExists(t, filepath.Join(filepath.Dir(configPath), config.DefaultLockfileName))
+}
+
+func TestOptionalTool(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "bitrise.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`format_version: "17"
+
+meta:
+  experimental:
+    tools:
+      nodejs: "20"
+      golang:
+        version: "1.22"
+        optional: true
+      ruby: "3.3"
+`), 0644))
+
+	p, err := pipeline.Load(pipeline.Options{ConfigPath: configPath}, newFakeRegistry(map[string]provider.EnvironmentActivation{}, "golang"))
+	require.NoError(t, err)
+	installed, err := p.Install(context.Background())
+	require.NoError(t, err, "optional tools don't fail the install")
+
+	require.Len(t, installed, 3)
+	assert.Error(t, installed[1].Err)
+	assert.Equal(t, []string{"/nodejs/bin", "/ruby/bin"}, pipeline.MergedActivation(installed).ContributedPaths)
+
+	// The required tools are locked, so a frozen run works even though the optional tool failed
+	lockfile, err := config.ReadLockfile(filepath.Join(filepath.Dir(configPath), config.DefaultLockfileName))
+	require.NoError(t, err)
+	var lockedNames []string
+	for _, locked := range lockfile.ToolsOf("") {
+		lockedNames = append(lockedNames, locked.ToolName)
+	}
+	assert.ElementsMatch(t, []string{"nodejs", "ruby"}, lockedNames)
+
+	p, err = pipeline.Load(pipeline.Options{ConfigPath: configPath, Frozen: true}, newFakeRegistry(map[string]provider.EnvironmentActivation{}, ""))
+	require.NoError(t, err)
+	installed, err = p.Install(context.Background())
+	require.NoError(t, err)
+	require.Len(t, installed, 3)
+	assert.NoError(t, installed[1].Err)
+}
+
+func TestProviderOptions(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "bitrise.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`format_version: "17"
+
+meta:
+  experimental:
+    tools:
+      nodejs: "20"
+      golang:
+        version: "1.22"
+        provider: mise
+    tool_config:
+      provider_options:
+        mise:
+          data_dir: /opt/mise-data
+          version: 2025.7.18
+        asdf:
+          manifest: tools.manifest.yml
+`), 0644))
+
+	state := &fakeState{dependencyEnvs: map[string]provider.EnvironmentActivation{}}
+	p, err := pipeline.Load(pipeline.Options{ConfigPath: configPath}, newFakeRegistryWithState(state, ""))
+	require.NoError(t, err)
+	_, err = p.Install(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]provider.ProviderOptions{
+		"asdf": {Manifest: filepath.Join(filepath.Dir(configPath), "tools.manifest.yml")},
+		"mise": {DataDir: "/opt/mise-data", Version: "2025.7.18"},
+	}, state.providerOptions)
+
+	p, err = pipeline.Load(pipeline.Options{ConfigPath: configPath, ProviderID: "unknown"}, newFakeRegistryWithState(state, ""))
+	require.NoError(t, err)
+	_, err = p.Install(context.Background())
+	assertStage(t, pipeline.StageInstall, err)
+	assert.EqualError(t, err, "create tool provider unknown: unsupported tool provider: unknown, expected one of: asdf, mise")
+}
+
+func TestParallelInstall(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "bitrise.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`format_version: "17"
+
+meta:
+  experimental:
+    tools:
+      nodejs: "20"
+      ruby:
+        version: "3.3"
+        depends_on: nodejs
+      golang: "1.22"
+      python: "3.12"
+      java: "21"
+    tool_config:
+      provider: mise
+      providers:
+        java: asdf
+        python: asdf
+      parallelism: 3
+`), 0644))
+
+	state := &fakeState{dependencyEnvs: map[string]provider.EnvironmentActivation{}, installDelay: 50 * time.Millisecond}
+	var log strings.Builder
+	p, err := pipeline.Load(pipeline.Options{ConfigPath: configPath, Log: &log}, newFakeRegistryWithState(state, ""))
+	require.NoError(t, err)
+	installed, err := p.Install(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, installed, 5)
+	assert.Equal(t, 3, state.maxRunning)
+	assert.Equal(t, []string{"/nodejs/bin"}, state.dependencyEnvs["ruby"].ContributedPaths, "ruby waits for its dependency")
+	assert.Equal(t, []string{"/nodejs/bin", "/ruby/bin", "/golang/bin", "/python/bin", "/java/bin"}, pipeline.MergedActivation(installed).ContributedPaths)
+	assert.Contains(t, log.String(), "Installing python 3.12 with asdf...\nSuccessfully installed python 3.12.0.\n", "the log of a tool is not interleaved")
+
+	// Installs of a provider without concurrency support don't overlap
+	state = &fakeState{dependencyEnvs: map[string]provider.EnvironmentActivation{}, installDelay: 10 * time.Millisecond}
+	p, err = pipeline.Load(pipeline.Options{ConfigPath: configPath, ProviderID: "asdf"}, newFakeRegistryWithState(state, ""))
+	require.NoError(t, err)
+	_, err = p.Install(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, state.maxRunning)
+}
+
+func TestInstallTimeouts(t *testing.T) {
+	configPath := writeConfig(t)
+
+	state := &fakeState{dependencyEnvs: map[string]provider.EnvironmentActivation{}, installDelay: time.Minute}
+	p, err := pipeline.Load(pipeline.Options{ConfigPath: configPath, ToolTimeout: 20 * time.Millisecond}, newFakeRegistryWithState(state, ""))
+	require.NoError(t, err)
+	_, err = p.Install(context.Background())
+	assertStage(t, pipeline.StageInstall, err)
+	assert.EqualError(t, err, "install nodejs 20: timed out after 20ms: context deadline exceeded")
+
+	p, err = pipeline.Load(pipeline.Options{ConfigPath: configPath, ContinueOnError: true, RunTimeout: 20 * time.Millisecond}, newFakeRegistryWithState(state, ""))
+	require.NoError(t, err)
+	start := time.Now()
+	_, err = p.Install(context.Background())
+	assert.Less(t, time.Since(start), time.Second, "no more tools are started")
+	assertStage(t, pipeline.StageInstall, err)
+	assert.ErrorContains(t, err, "run timed out after 20ms")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p, err = pipeline.Load(pipeline.Options{ConfigPath: configPath}, newFakeRegistryWithState(state, ""))
+	require.NoError(t, err)
+	_, err = p.Install(ctx)
+	assertStage(t, pipeline.StageInstall, err)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestNativeTools(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "bitrise.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`format_version: "17"
+
+meta:
+  experimental:
+    tools:
+      nodejs: "20"
+      native-java: "1"
+    tool_config:
+      use_native_tools: true
+`), 0644))
+
+	dependencyEnvs := map[string]provider.EnvironmentActivation{}
+	p, err := pipeline.Load(pipeline.Options{ConfigPath: configPath}, newFakeRegistry(dependencyEnvs, ""))
+	require.NoError(t, err)
+
+	plans, err := p.Plan(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"install", "use native"}, []string{plans[0].PlannedAction(), plans[1].PlannedAction()})
+
+	installed, err := p.Install(context.Background())
+	require.NoError(t, err)
+	require.Len(t, installed, 2)
+	assert.NotContains(t, dependencyEnvs, "native-java", "the native tool is not installed by the provider")
+	result := installed[1].Result
+	assert.True(t, result.IsAlreadyInstalled)
+	assert.Equal(t, "1.0.0", result.ConcreteVersion)
+	require.NotNil(t, result.NativeTool)
+	assert.Equal(t, []string{"/usr/bin"}, installed[1].Activation.ContributedPaths)
+}
+
+func TestPrune(t *testing.T) {
+	configPath := writeConfig(t)
+	lockfilePath := filepath.Join(filepath.Dir(configPath), config.DefaultLockfileName)
+	require.NoError(t, config.WriteLockfile(lockfilePath, config.Lockfile{Tools: []config.LockedTool{
+		{ProviderID: "asdf", ToolName: "ruby", RequestedVersion: "3.3", ResolutionStrategy: "closest_released", ConcreteVersion: "3.3.0"},
+	}}))
+	installDir := filepath.Join(t.TempDir(), "18.20.0")
+	require.NoError(t, os.MkdirAll(filepath.Join(installDir, "bin"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(installDir, "bin", "node"), make([]byte, 100), 0755))
+
+	newState := func() *fakeState {
+		return &fakeState{installed: map[string][]provider.InstalledVersion{
+			"asdf": {
+				{ToolName: "nodejs", Version: "18.20.0", Path: installDir},
+				{ToolName: "nodejs", Version: "20.10.0"},
+				{ToolName: "nodejs", Version: "20.18.0"},
+				{ToolName: "ruby", Version: "3.2.0"},
+				{ToolName: "ruby", Version: "3.3.0"},
+				{ToolName: "ruby", Version: "3.3.1"},
+				{ToolName: "python", Version: "3.12.1"},
+				{ToolName: "java", Version: "17.0.1"},
+			},
+			"mise": {
+				{ToolName: "golang", Version: "1.21.0"},
+				{ToolName: "golang", Version: "1.22.5"},
+				{ToolName: "node", Version: "22.1.0"},
+			},
+		}}
+	}
+	prunedVersions := func(result pipeline.PruneResult) []string {
+		var pruned []string
+		for _, v := range result.Pruned {
+			pruned = append(pruned, v.ProviderID+":"+v.ToolName+"@"+v.Version)
+		}
+		return pruned
+	}
+
+	state := newState()
+	p, err := pipeline.Load(pipeline.Options{ConfigPath: configPath}, newFakeRegistryWithState(state, ""))
+	require.NoError(t, err)
+	result, err := p.Prune(context.Background(), pipeline.PruneOptions{DryRun: true})
+	require.NoError(t, err)
+	expected := []string{"asdf:nodejs@18.20.0", "asdf:nodejs@20.10.0", "asdf:ruby@3.2.0", "mise:golang@1.21.0", "mise:node@22.1.0"}
+	assert.Equal(t, expected, prunedVersions(result), "versions used by the declarations and the lockfile, and tools that are not declared are kept")
+	assert.Equal(t, 6, result.Kept)
+	assert.Equal(t, int64(100), result.ReclaimedBytes)
+	assert.Empty(t, state.uninstalled, "nothing is uninstalled in a dry run")
+
+	result, err = p.Prune(context.Background(), pipeline.PruneOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, expected, state.uninstalled)
+
+	state = newState()
+	p, err = pipeline.Load(pipeline.Options{ConfigPath: configPath}, newFakeRegistryWithState(state, ""))
+	require.NoError(t, err)
+	result, err = p.Prune(context.Background(), pipeline.PruneOptions{KeepLast: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"asdf:nodejs@18.20.0", "asdf:ruby@3.2.0"}, state.uninstalled)
+	assert.Equal(t, 9, result.Kept)
+}
+
+func TestPruneWorkflows(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "bitrise.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`format_version: "17"
+
+meta:
+  experimental:
+    tools:
+      nodejs: "20"
+
+workflows:
+  deploy:
+    meta:
+      experimental:
+        tools:
+          nodejs: "22"
+          ruby: "3.3"
+  test: {}
+`), 0644))
+	state := &fakeState{installed: map[string][]provider.InstalledVersion{
+		"asdf": {
+			{ToolName: "nodejs", Version: "18.20.0"},
+			{ToolName: "nodejs", Version: "20.18.0"},
+			{ToolName: "nodejs", Version: "22.1.0"},
+			{ToolName: "ruby", Version: "3.2.0"},
+			{ToolName: "ruby", Version: "3.3.6"},
+			// Installed by another project that shares the install directory
+			{ToolName: "python", Version: "3.12.1"},
+		},
+	}}
+
+	p, err := pipeline.Load(pipeline.Options{ConfigPath: configPath, WorkflowID: "test"}, newFakeRegistryWithState(state, ""))
+	require.NoError(t, err)
+	result, err := p.Prune(context.Background(), pipeline.PruneOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"asdf:nodejs@18.20.0", "asdf:ruby@3.2.0"}, state.uninstalled, "versions of other workflows are kept")
+	assert.Equal(t, 4, result.Kept)
+}
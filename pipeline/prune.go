@@ -0,0 +1,220 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/exp/maps"
+
+	"github.com/bitrise-io/toolprovider/config"
+	"github.com/bitrise-io/toolprovider/provider"
+)
+
+type PruneOptions struct {
+	// KeepLast keeps the latest N installed versions of every tool, even if they are not referenced.
+	KeepLast int
+	// DryRun only reports the versions that would be removed.
+	DryRun bool
+}
+
+// PrunedVersion is an installed version that was removed (or would be removed in a dry run).
+type PrunedVersion struct {
+	ProviderID string
+	provider.InstalledVersion
+	// Size is the disk usage of the install in bytes, zero if the provider doesn't expose the install directory.
+	Size int64
+}
+
+type PruneResult struct {
+	Pruned []PrunedVersion
+	// Kept is the number of installed versions that are referenced or kept by PruneOptions.KeepLast.
+	Kept int
+	// ReclaimedBytes is the total size of the pruned versions.
+	ReclaimedBytes int64
+}
+
+// Prune uninstalls the versions installed by the providers of the tool declarations (and the default provider,
+// and every member of fallback chains) that are not referenced by the declarations or the lockfile. A declaration
+// references the installed version it would use, see referencedVersion(). The declarations of every workflow count,
+// not only the ones of Options.WorkflowID. Tools that are neither declared nor locked are left alone, because
+// the install directory of a provider may be shared with other projects.
+// Versions are measured before they are uninstalled, so that the reclaimed disk space can be reported.
+func (p *Pipeline) Prune(ctx context.Context, opts PruneOptions) (PruneResult, error) {
+	ctx, cancel := p.runContext(ctx)
+	defer cancel()
+
+	referenced := map[string][]string{}
+	lockfile, err := config.ReadLockfile(p.opts.LockfilePath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return PruneResult{}, StageError{Stage: StageLockfile, Err: err}
+	}
+	for _, t := range lockfile.AllTools() {
+		referenced[t.ToolName] = append(referenced[t.ToolName], t.ConcreteVersion)
+	}
+
+	declarations, err := p.allDeclarations()
+	if err != nil {
+		return PruneResult{}, StageError{Stage: StageConfig, Err: err}
+	}
+
+	providers := map[string]provider.ToolProvider{}
+	for _, request := range append([]provider.ToolRequest{{}}, p.requests...) {
+		// Every member of a fallback chain may have installed the tool
+		toolProviders, err := p.dispatcher.ProvidersFor(ctx, request)
+		if err != nil {
+			return PruneResult{}, StageError{Stage: StageInstall, Err: p.contextError(ctx, ctx, err)}
+		}
+		for _, toolProvider := range toolProviders {
+			providers[toolProvider.ID()] = toolProvider
+		}
+	}
+	providerIDs := maps.Keys(providers)
+	slices.Sort(providerIDs)
+
+	var result PruneResult
+	for _, providerID := range providerIDs {
+		toolProvider := providers[providerID]
+		installed, err := toolProvider.ListInstalled(ctx)
+		if err != nil {
+			return result, StageError{Stage: StageInstall, Err: fmt.Errorf("list installed tools of %s: %w", providerID, p.contextError(ctx, ctx, err))}
+		}
+
+		for _, v := range prunableVersions(installed, declarations, referenced, opts.KeepLast) {
+			pruned := PrunedVersion{ProviderID: providerID, InstalledVersion: v, Size: dirSize(v.Path)}
+			if !opts.DryRun {
+				fmt.Fprintf(p.opts.Log, "Uninstalling %s %s (%s)...\n", v.ToolName, v.Version, providerID)
+				err = toolProvider.UninstallTool(ctx, v.ToolName, v.Version)
+				if err != nil {
+					return result, StageError{Stage: StageInstall, Err: fmt.Errorf("uninstall %s %s: %w", v.ToolName, v.Version, p.contextError(ctx, ctx, err))}
+				}
+			}
+			result.Pruned = append(result.Pruned, pruned)
+			result.ReclaimedBytes += pruned.Size
+		}
+		result.Kept += len(installed)
+	}
+	result.Kept -= len(result.Pruned)
+	return result, nil
+}
+
+// allDeclarations returns the tool declarations of every workflow and of runs without a workflow,
+// each merged with the version files.
+func (p *Pipeline) allDeclarations() ([]provider.ToolRequest, error) {
+	var all []provider.ToolRequest
+	add := func(declarations map[string]provider.ToolRequest, err error) error {
+		if err != nil && !errors.Is(err, config.ErrNoToolDeclarations) {
+			return err
+		}
+		for _, request := range config.MergeToolDeclarations(declarations, p.versionFiles) {
+			request.ToolName = provider.GetCanonicalToolName(request.ToolName)
+			all = append(all, request)
+		}
+		return nil
+	}
+
+	err := add(config.ParseToolDeclarations(p.bitriseYml))
+	if err != nil {
+		return nil, err
+	}
+	workflowIDs := maps.Keys(p.bitriseYml.Workflows)
+	slices.Sort(workflowIDs)
+	for _, workflowID := range workflowIDs {
+		err := add(config.ParseWorkflowToolDeclarations(p.bitriseYml, workflowID))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return all, nil
+}
+
+// prunableVersions returns the installed versions of the declared or locked tools that are neither referenced
+// by a declaration or the lockfile, nor among the latest keepLast versions of their tool.
+func prunableVersions(installed []provider.InstalledVersion, declarations []provider.ToolRequest, lockedVersions map[string][]string, keepLast int) []provider.InstalledVersion {
+	versionsByTool := map[string][]string{}
+	for _, v := range installed {
+		toolName := provider.GetCanonicalToolName(v.ToolName)
+		versionsByTool[toolName] = append(versionsByTool[toolName], v.Version)
+	}
+
+	managed := map[string]bool{}
+	for toolName := range lockedVersions {
+		managed[toolName] = true
+	}
+	for _, request := range declarations {
+		managed[request.ToolName] = true
+	}
+
+	kept := map[string][]string{}
+	for toolName, versions := range versionsByTool {
+		sorted := provider.LogicallySortedVersions(versions)
+		kept[toolName] = slices.Concat(lockedVersions[toolName], sorted[:min(keepLast, len(sorted))])
+		for _, request := range declarations {
+			if request.ToolName != toolName {
+				continue
+			}
+			if v, ok := referencedVersion(request, versions); ok {
+				kept[toolName] = append(kept[toolName], v)
+			}
+		}
+	}
+
+	var prunable []provider.InstalledVersion
+	for _, v := range installed {
+		toolName := provider.GetCanonicalToolName(v.ToolName)
+		if managed[toolName] && !slices.Contains(kept[toolName], v.Version) {
+			prunable = append(prunable, v)
+		}
+	}
+	return prunable
+}
+
+// referencedVersion returns the installed version that the request would use without installing anything:
+// the requested version itself, the latest one satisfying a constraint, or the latest one matching the version prefix.
+func referencedVersion(request provider.ToolRequest, installedVersions []string) (string, bool) {
+	requested := strings.TrimSpace(request.UnparsedVersion)
+	if slices.Contains(installedVersions, requested) {
+		return requested, true
+	}
+
+	sorted := provider.LogicallySortedVersions(installedVersions)
+	if request.ResolutionStrategy == provider.ResolutionStrategyConstraint {
+		constraints, err := provider.ParseVersionConstraint(requested)
+		if err != nil {
+			return "", false
+		}
+		return provider.LatestMatchingVersion(constraints, sorted)
+	}
+	if (requested == "" || requested == "latest" || requested == "installed") && len(sorted) > 0 {
+		return sorted[0], true
+	}
+	for _, v := range sorted {
+		if provider.MatchesVersionPrefix(v, requested) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// dirSize returns the total size of the regular files in dir. Symlinks are not followed, and unreadable
+// entries are skipped, so the result is a lower bound.
+func dirSize(dir string) int64 {
+	if dir == "" {
+		return 0
+	}
+	var size int64
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
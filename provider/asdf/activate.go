@@ -1,18 +1,29 @@
 package asdf
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
 	"github.com/bitrise-io/toolprovider/provider"
 )
 
-func (a AsdfToolProvider) ActivateEnv(result provider.ToolInstallResult) (provider.EnvironmentActivation, error) {
+func (a AsdfToolProvider) ActivateEnv(ctx context.Context, result provider.ToolInstallResult) (provider.EnvironmentActivation, error) {
+	activation := envActivation(result)
+	provider.Emit(ctx, provider.Event{Kind: provider.EventActivated, ProviderID: a.ID(), ToolName: result.ToolName, Version: result.ConcreteVersion})
+	return activation, nil
+}
+
+func envActivation(result provider.ToolInstallResult) provider.EnvironmentActivation {
+	if result.NativeTool != nil {
+		return result.NativeTool.Activation()
+	}
+
 	envKey := fmt.Sprint("ASDF_", strings.ToUpper(result.ToolName), "_VERSION")
 	return provider.EnvironmentActivation{
 		ContributedEnvVars: map[string]string{
 			envKey: result.ConcreteVersion,
 		},
 		ContributedPaths: []string{}, // TODO: shims dir?
-	}, nil
+	}
 }
@@ -1,43 +1,78 @@
 package asdf
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/bitrise-io/bitrise/v2/log"
 	"github.com/bitrise-io/toolprovider/provider"
 	"github.com/bitrise-io/toolprovider/provider/asdf/execenv"
+	"github.com/hashicorp/go-version"
 )
 
-type ProviderOptions struct {
-	AsdfVersion string
-}
-
 type AsdfToolProvider struct {
 	ExecEnv execenv.ExecEnv
+	// PinnedVersion makes Bootstrap() fail if the installed asdf is a different version. Empty means any version.
+	PinnedVersion string
 }
 
 func (a AsdfToolProvider) ID() string {
 	return "asdf"
 }
 
-func (a AsdfToolProvider) Bootstrap() error {
+func (a AsdfToolProvider) Version() (string, error) {
+	// asdf --version doesn't download or build anything, so it's not cancellable.
+	v, err := a.asdfVersion(context.Background())
+	if err != nil {
+		return "", fmt.Errorf("get asdf version: %w", err)
+	}
+	return v.String(), nil
+}
+
+func (a AsdfToolProvider) Bootstrap(ctx context.Context) error {
 	// TODO:
 	// Check if asdf is installed
 	// Check if asdf version satisfies the supported version range
+	if a.PinnedVersion == "" {
+		return nil
+	}
 
+	pinned, err := version.NewVersion(a.PinnedVersion)
+	if err != nil {
+		return fmt.Errorf("parse pinned asdf version %s: %w", a.PinnedVersion, err)
+	}
+	installed, err := a.asdfVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("get asdf version: %w", err)
+	}
+	if !installed.Equal(pinned) {
+		return fmt.Errorf("asdf %s is installed, but version %s is pinned in tool_config", installed, pinned)
+	}
 	return nil
 }
 
-func (a AsdfToolProvider) InstallTool(tool provider.ToolRequest) (provider.ToolInstallResult, error) {
-	err := a.InstallPlugin(tool)
+// SupportsConcurrentInstalls is false, because plugin add/update and reshim modify state shared by every tool
+// (the plugin list and the shims directory).
+func (a AsdfToolProvider) SupportsConcurrentInstalls() bool {
+	return false
+}
+
+func (a AsdfToolProvider) InstallTool(ctx context.Context, tool provider.ToolRequest) (provider.ToolInstallResult, error) {
+	// a is a copy, so the dependency env only affects the commands of this install.
+	a.ExecEnv.EnvVars = tool.DependencyEnv.Apply(a.ExecEnv.EnvVars)
+
+	err := a.InstallPlugin(ctx, tool)
 	if err != nil {
 		return provider.ToolInstallResult{}, fmt.Errorf("install tool plugin %s: %w", tool.ToolName, err)
 	}
 
-	installedVersions, err := a.listInstalled(tool.ToolName)
+	resolveStart := time.Now()
+	provider.Emit(ctx, provider.Event{Kind: provider.EventResolveStarted, ProviderID: a.ID(), ToolName: tool.ToolName, Version: tool.UnparsedVersion})
+	installedVersions, err := a.listInstalled(ctx, tool.ToolName)
 	if err != nil {
 		return provider.ToolInstallResult{}, fmt.Errorf("list installed versions: %w", err)
 	}
@@ -45,15 +80,18 @@ func (a AsdfToolProvider) InstallTool(tool provider.ToolRequest) (provider.ToolI
 	// Short-circuit for exact version match among installed versions.
 	// Fetching released versions is a slow operation that we want to avoid.
 	v := strings.TrimSpace(tool.UnparsedVersion)
-	if tool.ResolutionStrategy == provider.ResolutionStrategyStrict && slices.Contains(installedVersions, v) {
+	// Auto requests for a fully specified version are strict too.
+	if tool.EffectiveStrategy(true) == provider.ResolutionStrategyStrict && slices.Contains(installedVersions, v) {
+		provider.Emit(ctx, provider.Event{Kind: provider.EventResolveFinished, ProviderID: a.ID(), ToolName: tool.ToolName, Version: v})
 		return provider.ToolInstallResult{
 			ToolName:           tool.ToolName,
 			IsAlreadyInstalled: true,
 			ConcreteVersion:    v,
+			ResolveDuration:    time.Since(resolveStart),
 		}, nil
 	}
 
-	releasedVersions, err := a.listReleased(tool.ToolName)
+	releasedVersions, err := a.listReleased(ctx, tool.ToolName)
 	if err != nil {
 		return provider.ToolInstallResult{}, fmt.Errorf("list released versions: %w", err)
 	}
@@ -66,53 +104,59 @@ func (a AsdfToolProvider) InstallTool(tool provider.ToolRequest) (provider.ToolI
 	}
 
 	resolution, err := ResolveVersion(tool, releasedVersions, installedVersions)
-	if err != nil {
-		var nomatchErr *ErrNoMatchingVersion
+	var nomatchErr *ErrNoMatchingVersion
+	if errors.As(err, &nomatchErr) {
+		log.Warn("No matching version found, updating asdf-%s plugin and retrying...", tool.ToolName)
+		// Some asdf plugins hardcode the list of installable versions and need a new plugin release to support new versions.
+		_, err = a.ExecEnv.StreamAsdf(ctx, provider.OutputLineEmitter(ctx, a.ID(), tool.ToolName), "plugin", "update", tool.ToolName)
+		if err != nil {
+			return provider.ToolInstallResult{}, fmt.Errorf("update plugin: %w", err)
+		}
+		releasedVersions, err = a.listReleased(ctx, tool.ToolName)
+		if err != nil {
+			return provider.ToolInstallResult{}, fmt.Errorf("list released versions after plugin update: %w", err)
+		}
+		resolution, err = ResolveVersion(tool, releasedVersions, installedVersions)
 		if errors.As(err, &nomatchErr) {
-			log.Warn("No matching version found, updating asdf-%s plugin and retrying...", tool.ToolName)
-			// Some asdf plugins hardcode the list of installable versions and need a new plugin release to support new versions.
-			_, err = a.ExecEnv.RunAsdf("plugin", "update", tool.ToolName)
-			if err != nil {
-				return provider.ToolInstallResult{}, fmt.Errorf("update plugin: %w", err)
-			}
-			releasedVersions, err = a.listReleased(tool.ToolName)
-			if err != nil {
-				return provider.ToolInstallResult{}, fmt.Errorf("list released versions after plugin update: %w", err)
-			}
-			resolution, err = ResolveVersion(tool, releasedVersions, installedVersions)
-			if err != nil {
-				if errors.As(err, &nomatchErr) {
-					errorDetails := provider.ToolInstallError{
-						ToolName:         tool.ToolName,
-						RequestedVersion: tool.UnparsedVersion,
-						Cause:            nomatchErr.Error(),
-						Recommendation:   fmt.Sprintf("You might want to use `%s:installed` or `%s:latest` to install the latest installed or latest released version of %s %s.", tool.UnparsedVersion, tool.UnparsedVersion, tool.ToolName, tool.UnparsedVersion),
-					}
-					return provider.ToolInstallResult{}, errorDetails
-				}
-				return provider.ToolInstallResult{}, fmt.Errorf("resolve version: %w", err)
+			return provider.ToolInstallResult{}, provider.ToolInstallError{
+				ToolName:         tool.ToolName,
+				RequestedVersion: tool.UnparsedVersion,
+				Cause:            nomatchErr.Error(),
+				Recommendation:   fmt.Sprintf("You might want to use `%s:installed` or `%s:latest` to install the latest installed or latest released version of %s %s.", tool.UnparsedVersion, tool.UnparsedVersion, tool.ToolName, tool.UnparsedVersion),
+				Err:              provider.ErrVersionNotFound,
 			}
 		}
-
+	}
+	if err != nil {
 		return provider.ToolInstallResult{}, fmt.Errorf("resolve version: %w", err)
 	}
 
+	resolveDuration := time.Since(resolveStart)
+	provider.Emit(ctx, provider.Event{Kind: provider.EventResolveFinished, ProviderID: a.ID(), ToolName: tool.ToolName, Version: resolution.VersionString})
+
 	if resolution.IsInstalled {
 		return provider.ToolInstallResult{
 			ToolName:           tool.ToolName,
 			IsAlreadyInstalled: true,
 			ConcreteVersion:    resolution.VersionString,
+			ResolveDuration:    resolveDuration,
 		}, nil
 	} else {
-		err = a.installToolVersion(tool.ToolName, resolution.VersionString)
+		err = a.installToolVersion(ctx, tool.ToolName, resolution.VersionString)
 		if err != nil {
 			return provider.ToolInstallResult{}, err
 		}
 
-		return provider.ToolInstallResult{
+		result := provider.ToolInstallResult{
 			ToolName:           tool.ToolName,
 			IsAlreadyInstalled: false,
 			ConcreteVersion:    resolution.VersionString,
-		}, nil
+			ResolveDuration:    resolveDuration,
+		}
+		err = a.runPostInstall(ctx, tool, result)
+		if err != nil {
+			return provider.ToolInstallResult{}, provider.UninstallAfterFailedPostInstall(ctx, a, result, err)
+		}
+		return result, nil
 	}
 }
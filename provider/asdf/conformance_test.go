@@ -0,0 +1,96 @@
+package asdf_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/bitrise-io/toolprovider/provider"
+	"github.com/bitrise-io/toolprovider/provider/asdf"
+	"github.com/bitrise-io/toolprovider/provider/asdf/execenv"
+	"github.com/bitrise-io/toolprovider/provider/providertest"
+)
+
+func TestMain(m *testing.M) {
+	providertest.RunFakeCLI(fakeAsdf)
+	os.Exit(m.Run())
+}
+
+func TestConformance(t *testing.T) {
+	providertest.Conformance{
+		ToolName: "golang",
+		NewProvider: func(t *testing.T, released, installed []string) provider.ToolProvider {
+			binDir := t.TempDir()
+			providertest.WriteFakeCLI(t, filepath.Join(binDir, "asdf"), providertest.NewVersionDir(t, "golang", released, installed))
+			// asdf where is not run in the ExecEnv, see filterAliasVersions()
+			t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+			return asdf.AsdfToolProvider{ExecEnv: execenv.ExecEnv{EnvVars: map[string]string{}}}
+		},
+	}.Run(t)
+}
+
+// fakeAsdf implements the asdf 0.16 commands that the provider runs. Plugins are directories in plugins/.
+func fakeAsdf(name string, args []string, dir providertest.VersionDir, stdout, stderr io.Writer) int {
+	fail := func(format string, a ...any) int {
+		fmt.Fprintf(stderr, format+"\n", a...)
+		return 1
+	}
+	pluginsDir := filepath.Join(string(dir), "plugins")
+
+	switch {
+	case slices.Equal(args, []string{"--version"}):
+		fmt.Fprintln(stdout, "asdf version 0.16.7")
+	case len(args) >= 2 && args[0] == "plugin" && args[1] == "list":
+		entries, _ := os.ReadDir(pluginsDir)
+		for _, entry := range entries {
+			fmt.Fprintln(stdout, entry.Name())
+		}
+	case len(args) >= 3 && args[0] == "plugin" && args[1] == "add":
+		if err := os.MkdirAll(filepath.Join(pluginsDir, args[2]), 0755); err != nil {
+			return fail("%s", err)
+		}
+	case len(args) == 3 && args[0] == "plugin" && args[1] == "update":
+	case len(args) == 3 && args[0] == "list" && args[1] == "all":
+		released, err := dir.Released(args[2])
+		if err != nil {
+			return fail("%s", err)
+		}
+		for _, v := range released {
+			fmt.Fprintln(stdout, v)
+		}
+	case len(args) == 2 && args[0] == "list":
+		installed, err := dir.Installed(args[1])
+		if err != nil {
+			return fail("%s", err)
+		}
+		if len(installed) == 0 {
+			return fail("No compatible versions installed (%s)", args[1])
+		}
+		for _, v := range installed {
+			fmt.Fprintf(stdout, "  %s\n", v)
+		}
+	case len(args) == 3 && args[0] == "where":
+		fmt.Fprintln(stdout, dir.InstallPath(args[1], args[2]))
+	case len(args) == 3 && args[0] == "install":
+		released, err := dir.Released(args[1])
+		if err != nil {
+			return fail("%s", err)
+		}
+		if !slices.Contains(released, args[2]) {
+			return fail("version %s of %s not found", args[2], args[1])
+		}
+		if err := dir.Install(args[1], args[2]); err != nil {
+			return fail("%s", err)
+		}
+	case len(args) == 3 && args[0] == "uninstall":
+		if err := dir.Uninstall(args[1], args[2]); err != nil {
+			return fail("%s", err)
+		}
+	default:
+		return fail("%s: unsupported command: %v", name, args)
+	}
+	return 0
+}
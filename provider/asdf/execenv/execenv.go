@@ -1,12 +1,17 @@
 package execenv
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"strings"
+	"syscall"
+	"time"
 
 	"al.essio.dev/pkg/shellescape"
+
+	"github.com/bitrise-io/toolprovider/internal/lineoutput"
 )
 
 // ExecEnv contains everything needed to run asdf commands in a specific environment
@@ -25,17 +30,34 @@ type ExecEnv struct {
 	ShellInit string
 }
 
-func (e *ExecEnv) RunAsdf(args ...string) (string, error) {
+// waitDelay is how long a command may keep its output pipes open after it's killed on cancellation.
+const waitDelay = 5 * time.Second
+
+func (e *ExecEnv) RunAsdf(ctx context.Context, args ...string) (string, error) {
 	cmdWithArgs := append([]string{"asdf"}, args...)
-	return e.RunCommand(nil, cmdWithArgs...)
+	return e.RunCommand(ctx, nil, cmdWithArgs...)
 }
 
-func (e *ExecEnv) RunAsdfPlugin(args ...string) (string, error) {
+func (e *ExecEnv) RunAsdfPlugin(ctx context.Context, args ...string) (string, error) {
 	cmdWithArgs := append([]string{"asdf", "plugin"}, args...)
-	return e.RunCommand(nil, cmdWithArgs...)
+	return e.RunCommand(ctx, nil, cmdWithArgs...)
+}
+
+// StreamAsdf is RunAsdf, but it also passes each line of the output to onLine while asdf runs.
+func (e *ExecEnv) StreamAsdf(ctx context.Context, onLine func(line string), args ...string) (string, error) {
+	cmdWithArgs := append([]string{"asdf"}, args...)
+	return e.StreamCommand(ctx, onLine, nil, cmdWithArgs...)
 }
 
-func (e *ExecEnv) RunCommand(extraEnvs map[string]string, args ...string) (string, error) {
+// RunCommand runs the command in a bash subshell. When ctx is done, the whole process group of the subshell is killed,
+// so that plugin scripts, downloads and compilers started by asdf don't outlive the command.
+func (e *ExecEnv) RunCommand(ctx context.Context, extraEnvs map[string]string, args ...string) (string, error) {
+	return e.StreamCommand(ctx, nil, extraEnvs, args...)
+}
+
+// StreamCommand is RunCommand, but it also passes each line of the output to onLine while the command runs.
+// A nil onLine only collects the output.
+func (e *ExecEnv) StreamCommand(ctx context.Context, onLine func(line string), extraEnvs map[string]string, args ...string) (string, error) {
 	innerShellCmd := []string{}
 	if e.ShellInit != "" {
 		innerShellCmd = append(innerShellCmd, e.ShellInit+" &&")
@@ -45,7 +67,12 @@ func (e *ExecEnv) RunCommand(extraEnvs map[string]string, args ...string) (strin
 	// We need to spawn a sub-shell because classic asdf is implemented in bash and
 	// relies on shell features.
 	bashArgs := []string{"-c", strings.Join(innerShellCmd, " ")}
-	bashCmd := exec.Command("bash", bashArgs...)
+	bashCmd := exec.CommandContext(ctx, "bash", bashArgs...)
+	bashCmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	bashCmd.Cancel = func() error {
+		return syscall.Kill(-bashCmd.Process.Pid, syscall.SIGKILL)
+	}
+	bashCmd.WaitDelay = waitDelay
 	if !e.ClearInheritedEnvs {
 		bashCmd.Env = os.Environ()
 	}
@@ -56,9 +83,18 @@ func (e *ExecEnv) RunCommand(extraEnvs map[string]string, args ...string) (strin
 		bashCmd.Env = append(bashCmd.Env, fmt.Sprintf("%s=%s", k, v))
 	}
 
-	output, err := bashCmd.CombinedOutput()
+	outputWriter := lineoutput.NewWriter(onLine)
+	bashCmd.Stdout = outputWriter
+	bashCmd.Stderr = outputWriter
+	err := bashCmd.Run()
+	outputWriter.Flush()
+	output := outputWriter.Output()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return string(output), fmt.Errorf("%s %v: %w", "bash", bashArgs, ctxErr)
+	}
 	if err != nil {
-		return "", fmt.Errorf("%s %v: %w\n\nOutput:\n%s", "bash", bashArgs, err, output)
+		// Output is returned in case of an error too, so that callers can report it in a structured way.
+		return string(output), fmt.Errorf("%s %v: %w\n\nOutput:\n%s", "bash", bashArgs, err, output)
 	}
 
 	return string(output), nil
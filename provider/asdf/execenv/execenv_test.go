@@ -0,0 +1,35 @@
+package execenv_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/toolprovider/provider/asdf/execenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunCommandCancellation(t *testing.T) {
+	env := execenv.ExecEnv{}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// The background sleep inherits the output pipe, so the command only returns early if it's killed too
+	start := time.Now()
+	_, err := env.RunCommand(ctx, nil, "bash", "-c", "sleep 30 & sleep 30")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 3*time.Second, "the whole process group is killed")
+}
+
+func TestStreamCommand(t *testing.T) {
+	env := execenv.ExecEnv{}
+	var lines []string
+	output, err := env.StreamCommand(context.Background(), func(line string) {
+		lines = append(lines, line)
+	}, nil, "bash", "-c", `echo first; echo second >&2; printf last`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first", "second", "last"}, lines)
+	assert.Equal(t, "first\nsecond\nlast", output)
+}
@@ -1,13 +1,14 @@
 package asdf
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/bitrise-io/toolprovider/provider"
 	"github.com/bitrise-io/toolprovider/provider/asdf/workarounds"
 )
 
-func (a *AsdfToolProvider) installToolVersion(
+func (a *AsdfToolProvider) installToolVersion(ctx context.Context,
 	toolName string,
 	versionString string,
 ) error {
@@ -15,7 +16,7 @@ func (a *AsdfToolProvider) installToolVersion(
 		return fmt.Errorf("toolName and versionString must not be empty")
 	}
 
-	out, err := a.ExecEnv.RunAsdf("install", toolName, versionString)
+	out, err := a.ExecEnv.StreamAsdf(ctx, provider.OutputLineEmitter(ctx, a.ID(), toolName), "install", toolName, versionString)
 	if err != nil {
 		return provider.ToolInstallError{
 			ToolName:         toolName,
@@ -26,7 +27,7 @@ func (a *AsdfToolProvider) installToolVersion(
 	}
 
 	if toolName == "nodejs" {
-		err = workarounds.SetupCorepack(a.ExecEnv, versionString)
+		err = workarounds.SetupCorepack(ctx, a.ExecEnv, versionString)
 		if err != nil {
 			return fmt.Errorf("setup corepack for %s %s: %w", toolName, versionString, err)
 		}
@@ -1,6 +1,7 @@
 package asdf
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -8,8 +9,8 @@ import (
 	"github.com/bitrise-io/toolprovider/provider"
 )
 
-// Unlikely to conflict with any plugin name or URL, but clearly separates the plugin name and URL.
-const PluginSourceSeparator = "::"
+// PluginSourceSeparator separates the plugin name and URL, see provider.ParsePluginIdentifier().
+const PluginSourceSeparator = provider.PluginSourceSeparator
 
 type PluginSource struct {
 	PluginName  string
@@ -29,7 +30,7 @@ var pluginSourceMap = map[string]PluginSource{
 //
 // It resolves the plugin source from the tool request or predefined map,
 // checks if the plugin is already installed, and if not, installs it using asdf.
-func (a AsdfToolProvider) InstallPlugin(tool provider.ToolRequest) error {
+func (a AsdfToolProvider) InstallPlugin(ctx context.Context, tool provider.ToolRequest) error {
 	plugin, err := fetchPluginSource(tool)
 	if err != nil {
 		// E.g. parse error while resolving plugin source.
@@ -46,6 +47,7 @@ func (a AsdfToolProvider) InstallPlugin(tool provider.ToolRequest) error {
 			RequestedVersion: tool.UnparsedVersion,
 			Cause:            fmt.Sprintf("This tool integration (%s) is not tested or vetted by Bitrise.", tool.ToolName),
 			Recommendation:   fmt.Sprintf("If you want to use this tool anyway, look up its asdf plugin and provide it in the `plugin` field of the tool declaration. For example: `plugin: %s::https://github/url/to/asdf/plugin/repo.git`", tool.ToolName),
+			Err:              provider.ErrToolNotFound,
 		}
 	}
 	if plugin.PluginName == "" {
@@ -53,7 +55,7 @@ func (a AsdfToolProvider) InstallPlugin(tool provider.ToolRequest) error {
 		return fmt.Errorf("plugin name for tool %s is not defined", tool.ToolName)
 	}
 
-	installed, err := a.isPluginInstalled(*plugin)
+	installed, err := a.isPluginInstalled(ctx, *plugin)
 	if err != nil {
 		log.Warnf("Failed to check if plugin is already installed: %v", err)
 	}
@@ -68,26 +70,27 @@ func (a AsdfToolProvider) InstallPlugin(tool provider.ToolRequest) error {
 		pluginAddArgs = append(pluginAddArgs, plugin.GitCloneURL)
 	}
 
-	_, err = a.ExecEnv.RunAsdfPlugin(pluginAddArgs...)
+	_, err = a.ExecEnv.StreamAsdf(ctx, provider.OutputLineEmitter(ctx, a.ID(), tool.ToolName), append([]string{"plugin"}, pluginAddArgs...)...)
 	if err != nil {
 		return err
 	}
 
 	// Check if the plugin is found in the list of installed plugins after adding.
-	installed, err = a.isPluginInstalled(*plugin)
+	installed, err = a.isPluginInstalled(ctx, *plugin)
 	if err != nil {
 		return fmt.Errorf("check if plugin was installed successfully: %w", err)
 	}
 	if !installed {
 		return fmt.Errorf("%s plugin could not be installed", tool.ToolName)
 	}
+	provider.Emit(ctx, provider.Event{Kind: provider.EventPluginAdded, ProviderID: a.ID(), ToolName: tool.ToolName, Message: strings.TrimSpace(plugin.PluginName + " " + plugin.GitCloneURL)})
 
 	return nil
 }
 
-func (a *AsdfToolProvider) isPluginInstalled(plugin PluginSource) (bool, error) {
+func (a *AsdfToolProvider) isPluginInstalled(ctx context.Context, plugin PluginSource) (bool, error) {
 	pluginListArgs := []string{"list", "--urls"}
-	out, err := a.ExecEnv.RunAsdfPlugin(pluginListArgs...)
+	out, err := a.ExecEnv.RunAsdfPlugin(ctx, pluginListArgs...)
 	if err != nil {
 		return false, err
 	}
@@ -132,28 +135,12 @@ func fetchPluginSource(toolRequest provider.ToolRequest) (*PluginSource, error)
 	return nil, nil
 }
 
-// parsePluginSourceFromInput parses a plugin identifier string into a PluginSource struct.
-// The expected format is "pluginName::[gitCloneURL]", where gitCloneURL is optional.
+// parsePluginSourceFromInput parses a plugin identifier string into a PluginSource struct,
+// see provider.ParsePluginIdentifier().
 func parsePluginSourceFromInput(pluginIdentifier string) (*PluginSource, error) {
-	parts := strings.Split(pluginIdentifier, PluginSourceSeparator)
-	if len(parts) > 2 {
-		return nil, fmt.Errorf("invalid plugin identifier format: %s, expected format is 'pluginName%s[gitCloneURL]'", pluginIdentifier, PluginSourceSeparator)
-	}
-
-	if len(parts) == 0 {
-		return nil, fmt.Errorf("invalid plugin identifier format: %s", pluginIdentifier)
-	}
-	pluginName := strings.TrimSpace(parts[0])
-	if pluginName == "" {
-		return nil, fmt.Errorf("plugin name cannot be empty in identifier: %s", pluginIdentifier)
-	}
-	if strings.HasPrefix(pluginName, "http://") || strings.HasPrefix(pluginName, "https://") {
-		return nil, fmt.Errorf("plugin name should not contain URL: %s", pluginName)
-	}
-
-	pluginURL := ""
-	if len(parts) > 1 {
-		pluginURL = strings.TrimSpace(parts[1])
+	pluginName, pluginURL, err := provider.ParsePluginIdentifier(pluginIdentifier)
+	if err != nil {
+		return nil, err
 	}
 
 	return &PluginSource{
@@ -0,0 +1,45 @@
+package asdf
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bitrise-io/toolprovider/provider"
+)
+
+// IsInstalledNative looks for the tool in the $PATH of the asdf exec env and in the known stack locations.
+// Installs and shims in the asdf data dir are skipped.
+func (a AsdfToolProvider) IsInstalledNative(ctx context.Context, tool provider.ToolRequest) (provider.NativeTool, bool, error) {
+	dataDir, err := a.dataDir()
+	if err != nil {
+		return provider.NativeTool{}, false, err
+	}
+
+	native, ok := provider.FindNativeTool(ctx, tool, provider.NativeSearchDirs(tool.ToolName, a.env("PATH")), []string{dataDir})
+	return native, ok, nil
+}
+
+// dataDir returns $ASDF_DATA_DIR of the asdf exec env, or its default.
+func (a AsdfToolProvider) dataDir() (string, error) {
+	if dataDir := a.env("ASDF_DATA_DIR"); dataDir != "" {
+		return dataDir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("get user home dir: %w", err)
+	}
+	return filepath.Join(home, ".asdf"), nil
+}
+
+// env returns the value of an env var in the asdf exec env.
+func (a AsdfToolProvider) env(key string) string {
+	if v, ok := a.ExecEnv.EnvVars[key]; ok {
+		return v
+	}
+	if a.ExecEnv.ClearInheritedEnvs {
+		return ""
+	}
+	return os.Getenv(key)
+}
@@ -1,6 +1,7 @@
 package asdf
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -10,8 +11,8 @@ import (
 	"github.com/hashicorp/go-version"
 )
 
-func (a *AsdfToolProvider) asdfVersion() (*version.Version, error) {
-	output, err := a.ExecEnv.RunAsdf("--version")
+func (a *AsdfToolProvider) asdfVersion(ctx context.Context) (*version.Version, error) {
+	output, err := a.ExecEnv.RunAsdf(ctx, "--version")
 	if err != nil {
 		return nil, err
 	}
@@ -30,8 +31,8 @@ func (a *AsdfToolProvider) asdfVersion() (*version.Version, error) {
 }
 
 // TODO: check if tool-plugin is installed
-func (a *AsdfToolProvider) listInstalled(toolName string) ([]string, error) {
-	output, err := a.ExecEnv.RunAsdf("list", toolName)
+func (a *AsdfToolProvider) listInstalled(ctx context.Context, toolName string) ([]string, error) {
+	output, err := a.ExecEnv.RunAsdf(ctx, "list", toolName)
 	if err != nil {
 		// asdf 0.16.0+ returns exit code 1 if no versions are installed
 		if strings.Contains(err.Error(), "No compatible versions installed") {
@@ -41,7 +42,7 @@ func (a *AsdfToolProvider) listInstalled(toolName string) ([]string, error) {
 	}
 
 	installedVersions := parseAsdfListOutput(output)
-	filteredVersions, err := filterAliasVersions(toolName, installedVersions)
+	filteredVersions, err := filterAliasVersions(ctx, toolName, installedVersions)
 	if err != nil {
 		return nil, fmt.Errorf("filter alias versions: %w", err)
 	}
@@ -49,8 +50,8 @@ func (a *AsdfToolProvider) listInstalled(toolName string) ([]string, error) {
 }
 
 // TODO: check if tool-plugin is installed
-func (a *AsdfToolProvider) listReleased(toolName string) ([]string, error) {
-	asdfVer, err := a.asdfVersion()
+func (a *AsdfToolProvider) listReleased(ctx context.Context, toolName string) ([]string, error) {
+	asdfVer, err := a.asdfVersion(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -61,7 +62,7 @@ func (a *AsdfToolProvider) listReleased(toolName string) ([]string, error) {
 		subcommands = []string{"list-all", toolName}
 	}
 
-	output, err := a.ExecEnv.RunAsdf(subcommands...)
+	output, err := a.ExecEnv.RunAsdf(ctx, subcommands...)
 	if err != nil {
 		return nil, err
 	}
@@ -98,11 +99,26 @@ func parseAsdfListOutput(output string) []string {
 	return versions
 }
 
-func filterAliasVersions(tool string, versions []string) ([]string, error) {
+func parsePluginListOutput(output string) []string {
+	// One plugin per line, optionally followed by its repo URL and ref (--urls, --refs):
+	//   golang
+	//   nodejs  https://github.com/asdf-vm/asdf-nodejs.git
+	plugins := []string{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.Contains(line, "No plugins installed") {
+			continue
+		}
+		plugins = append(plugins, fields[0])
+	}
+	return plugins
+}
+
+func filterAliasVersions(ctx context.Context, tool string, versions []string) ([]string, error) {
 	// Filter out versions that are symlinks created by the asdf-alias plugin.
 	var filtered []string
 	for _, v := range versions {
-		out, err := exec.Command("asdf", "where", tool, v).Output()
+		out, err := exec.CommandContext(ctx, "asdf", "where", tool, v).Output()
 		if err != nil {
 			return nil, fmt.Errorf("asdf where %s %s: %w", tool, v, err)
 		}
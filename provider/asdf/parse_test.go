@@ -47,3 +47,9 @@ func TestParseAsdfListOutput(t *testing.T) {
 		})
 	}
 }
+
+func TestParsePluginListOutput(t *testing.T) {
+	assert.Equal(t, []string{"golang", "nodejs"}, parsePluginListOutput("golang\nnodejs  https://github.com/asdf-vm/asdf-nodejs.git\n"))
+	assert.Equal(t, []string{}, parsePluginListOutput("No plugins installed\n"))
+	assert.Equal(t, []string{}, parsePluginListOutput(""))
+}
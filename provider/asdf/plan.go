@@ -0,0 +1,54 @@
+package asdf
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bitrise-io/toolprovider/provider"
+)
+
+// PlanInstall resolves the requested version against the installed and released versions without running `asdf install`.
+// The plugin is added if it's missing, because asdf can't list the versions of a tool without its plugin.
+func (a AsdfToolProvider) PlanInstall(ctx context.Context, tool provider.ToolRequest) (provider.ToolInstallPlan, error) {
+	plan := provider.ToolInstallPlan{
+		ToolName:           tool.ToolName,
+		RequestedVersion:   tool.UnparsedVersion,
+		ResolutionStrategy: tool.ResolutionStrategy,
+	}
+
+	err := a.InstallPlugin(ctx, tool)
+	if err != nil {
+		return provider.ToolInstallPlan{}, fmt.Errorf("install tool plugin %s: %w", tool.ToolName, err)
+	}
+
+	installedVersions, err := a.listInstalled(ctx, tool.ToolName)
+	if err != nil {
+		return provider.ToolInstallPlan{}, fmt.Errorf("list installed versions: %w", err)
+	}
+
+	// Same short-circuit as in InstallTool()
+	v := strings.TrimSpace(tool.UnparsedVersion)
+	// Auto requests for a fully specified version are strict too.
+	if tool.EffectiveStrategy(true) == provider.ResolutionStrategyStrict && slices.Contains(installedVersions, v) {
+		plan.ResolvedVersion = v
+		plan.IsInstalled = true
+		return plan, nil
+	}
+
+	releasedVersions, err := a.listReleased(ctx, tool.ToolName)
+	if err != nil {
+		return provider.ToolInstallPlan{}, fmt.Errorf("list released versions: %w", err)
+	}
+
+	resolution, err := ResolveVersion(tool, releasedVersions, installedVersions)
+	if err != nil {
+		// Unlike InstallTool(), the plugin is not updated here to look for new versions.
+		return provider.ToolInstallPlan{}, fmt.Errorf("resolve version: %w", err)
+	}
+
+	plan.ResolvedVersion = resolution.VersionString
+	plan.IsInstalled = resolution.IsInstalled
+	return plan, nil
+}
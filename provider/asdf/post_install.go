@@ -0,0 +1,32 @@
+package asdf
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bitrise-io/toolprovider/provider"
+)
+
+// runPostInstall runs the post-install commands of the tool, one by one, with the freshly installed version activated.
+// It stops at the first failing command.
+func (a AsdfToolProvider) runPostInstall(ctx context.Context, tool provider.ToolRequest, installResult provider.ToolInstallResult) error {
+	if len(tool.PostInstall) == 0 {
+		return nil
+	}
+
+	activation := envActivation(installResult)
+	onLine := provider.OutputLineEmitter(ctx, a.ID(), tool.ToolName)
+	for _, command := range tool.PostInstall {
+		out, err := a.ExecEnv.StreamCommand(ctx, onLine, activation.ContributedEnvVars, "bash", "-c", command)
+		if err != nil {
+			return provider.ToolInstallError{
+				ToolName:         tool.ToolName,
+				RequestedVersion: tool.UnparsedVersion,
+				Cause:            fmt.Sprintf("post-install command failed: %s", command),
+				Recommendation:   "Check the post_install commands of the tool declaration in bitrise.yml.",
+				RawOutput:        out,
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package asdf
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bitrise-io/toolprovider/provider"
+	"github.com/bitrise-io/toolprovider/provider/asdf/execenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunPostInstall(t *testing.T) {
+	installResult := provider.ToolInstallResult{
+		ToolName:        "nodejs",
+		ConcreteVersion: "20.18.0",
+	}
+
+	tests := []struct {
+		name          string
+		postInstall   []string
+		wantErr       bool
+		wantRawOutput string
+	}{
+		{
+			name:        "No post-install commands",
+			postInstall: nil,
+		},
+		{
+			name:        "Commands succeed",
+			postInstall: []string{"true", "test \"$ASDF_NODEJS_VERSION\" = 20.18.0"},
+		},
+		{
+			name:          "Failing command stops the rest",
+			postInstall:   []string{"echo \"node $ASDF_NODEJS_VERSION\"; exit 3", "echo unreachable"},
+			wantErr:       true,
+			wantRawOutput: "node 20.18.0\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := AsdfToolProvider{ExecEnv: execenv.ExecEnv{}}
+			tool := provider.ToolRequest{
+				ToolName:        "nodejs",
+				UnparsedVersion: "20",
+				PostInstall:     tt.postInstall,
+			}
+
+			err := a.runPostInstall(context.Background(), tool, installResult)
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+
+			var installErr provider.ToolInstallError
+			require.ErrorAs(t, err, &installErr)
+			assert.Equal(t, "nodejs", installErr.ToolName)
+			assert.Equal(t, tt.wantRawOutput, installErr.RawOutput)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package asdf
+
+import (
+	"os"
+	"strings"
+
+	"github.com/bitrise-io/toolprovider/provider"
+	"github.com/bitrise-io/toolprovider/provider/asdf/execenv"
+)
+
+func init() {
+	provider.Register("asdf", newFromOptions)
+}
+
+// newFromOptions creates a provider that runs asdf in the environment of the current process. DataDir is set
+// as $ASDF_DATA_DIR, InstallDir as $ASDF_DIR (and it's added to $PATH), Version is checked by Bootstrap().
+func newFromOptions(opts provider.ProviderOptions) (provider.ToolProvider, error) {
+	envVars := convertEnvToMap(os.Environ())
+	if opts.DataDir != "" {
+		envVars["ASDF_DATA_DIR"] = opts.DataDir
+	}
+	if opts.InstallDir != "" {
+		envVars["ASDF_DIR"] = opts.InstallDir
+		envVars["PATH"] = opts.InstallDir + string(os.PathListSeparator) + envVars["PATH"]
+	}
+
+	return AsdfToolProvider{
+		ExecEnv: execenv.ExecEnv{
+			EnvVars:   envVars,
+			ShellInit: opts.ShellInit,
+		},
+		PinnedVersion: opts.Version,
+	}, nil
+}
+
+func convertEnvToMap(env []string) map[string]string {
+	result := make(map[string]string)
+	for _, envVar := range env {
+		parts := strings.SplitN(envVar, "=", 2)
+		if len(parts) == 2 {
+			result[parts[0]] = parts[1]
+		}
+	}
+	return result
+}
@@ -3,7 +3,6 @@ package asdf
 import (
 	"fmt"
 	"slices"
-	"sort"
 	"strings"
 
 	"github.com/bitrise-io/toolprovider/provider"
@@ -32,7 +31,7 @@ func (e ErrNoMatchingVersion) Error() string {
 
 	versionList := ""
 	for _, v := range e.AvailableVersions {
-		if strings.HasPrefix(v, e.RequestedVersion) {
+		if provider.MatchesVersionPrefix(v, e.RequestedVersion) {
 			versionList += fmt.Sprintf("- %s\n", v)
 		}
 	}
@@ -43,6 +42,11 @@ func (e ErrNoMatchingVersion) Error() string {
 	}
 }
 
+// Unwrap classifies the error as provider.ErrVersionNotFound.
+func (e ErrNoMatchingVersion) Unwrap() error {
+	return provider.ErrVersionNotFound
+}
+
 type VersionResolution struct {
 	VersionString string
 	IsSemVer      bool
@@ -60,6 +64,24 @@ func ResolveVersion(
 		return resolveToAbsoluteLatestVersion(request, releasedVersions, installedVersions)
 	}
 
+	if request.ResolutionStrategy == provider.ResolutionStrategyConstraint {
+		return resolveConstraint(request, releasedVersions, installedVersions)
+	}
+
+	if request.ResolutionStrategy == provider.ResolutionStrategyAuto {
+		v, found := provider.ResolveAutoVersion(request.UnparsedVersion, releasedVersions, installedVersions)
+		if !found {
+			return VersionResolution{}, &ErrNoMatchingVersion{AvailableVersions: releasedVersions, RequestedVersion: request.UnparsedVersion}
+		}
+		semverV, err := version.NewVersion(v)
+		return VersionResolution{
+			VersionString: v,
+			IsSemVer:      err == nil,
+			SemVer:        semverV,
+			IsInstalled:   slices.Contains(installedVersions, v),
+		}, nil
+	}
+
 	// Short-circuit for exact version match among installed versions
 	if slices.Contains(installedVersions, strings.TrimSpace(request.UnparsedVersion)) {
 		requestedSemVer, err := version.NewVersion(request.UnparsedVersion)
@@ -87,9 +109,9 @@ func ResolveVersion(
 	switch request.ResolutionStrategy {
 	case provider.ResolutionStrategyLatestInstalled:
 		// Installed versions are checked first because strategy is "latest installed"
-		sortedInstalledVersions := logicallySortedVersions(installedVersions)
+		sortedInstalledVersions := provider.LogicallySortedVersions(installedVersions)
 		for _, v := range sortedInstalledVersions {
-			if strings.HasPrefix(v, request.UnparsedVersion) {
+			if provider.MatchesVersionPrefix(v, request.UnparsedVersion) {
 				// Since semver-compatible versions are sorted according to the semver spec
 				// and are at the front of the list,
 				// we can stop searching if the version prefix-matches the requested version.
@@ -104,10 +126,10 @@ func ResolveVersion(
 		}
 
 		// If there is no match among installed versions, we check the released versions (despite the strategy being "latest installed").
-		sortedReleasedVersions := logicallySortedVersions(releasedVersions)
+		sortedReleasedVersions := provider.LogicallySortedVersions(releasedVersions)
 
 		for _, v := range sortedReleasedVersions {
-			if strings.HasPrefix(v, request.UnparsedVersion) {
+			if provider.MatchesVersionPrefix(v, request.UnparsedVersion) {
 				// Since semver-compatible versions are sorted according to the semver spec
 				// and are at the front of the list,
 				// we can stop searching if the version prefix-matches the requested version.
@@ -123,9 +145,9 @@ func ResolveVersion(
 
 		return VersionResolution{}, &ErrNoMatchingVersion{AvailableVersions: releasedVersions, RequestedVersion: request.UnparsedVersion}
 	case provider.ResolutionStrategyLatestReleased:
-		sortedReleasedVersions := logicallySortedVersions(releasedVersions)
+		sortedReleasedVersions := provider.LogicallySortedVersions(releasedVersions)
 		for _, v := range sortedReleasedVersions {
-			if strings.HasPrefix(v, request.UnparsedVersion) {
+			if provider.MatchesVersionPrefix(v, request.UnparsedVersion) {
 				// Since semver-compatible versions are sorted according to the semver spec
 				// and are at the front of the list,
 				// we can stop searching if the version prefix-matches the requested version.
@@ -149,11 +171,37 @@ func ResolveVersion(
 	return VersionResolution{}, fmt.Errorf("unknown resolution strategy: %v", request.ResolutionStrategy)
 }
 
+// resolveConstraint resolves a constraint expression to the highest released or installed version that satisfies it.
+func resolveConstraint(
+	request provider.ToolRequest,
+	releasedVersions []string,
+	installedVersions []string,
+) (VersionResolution, error) {
+	constraints, err := provider.ParseVersionConstraint(request.UnparsedVersion)
+	if err != nil {
+		return VersionResolution{}, err
+	}
+
+	candidates := append(slices.Clone(releasedVersions), installedVersions...)
+	v, found := provider.LatestMatchingVersion(constraints, candidates)
+	if !found {
+		return VersionResolution{}, &ErrNoMatchingVersion{AvailableVersions: releasedVersions, RequestedVersion: request.UnparsedVersion}
+	}
+
+	semverV, err := version.NewVersion(v)
+	return VersionResolution{
+		VersionString: v,
+		IsSemVer:      err == nil,
+		SemVer:        semverV,
+		IsInstalled:   slices.Contains(installedVersions, v),
+	}, nil
+}
+
 // assignSpecialCaseResolutionStrategy assigns a resolution strategy other than strict based on the request's unparsed version and provided strategy.
 func assignSpecialCaseResolutionStrategy(
 	request provider.ToolRequest,
 ) provider.ResolutionStrategy {
-	if request.ResolutionStrategy != provider.ResolutionStrategyStrict {
+	if request.ResolutionStrategy != provider.ResolutionStrategyStrict && request.ResolutionStrategy != provider.ResolutionStrategyAuto {
 		// Already has a resolution strategy set with an input like ":latest" or "latest:installed".
 		return request.ResolutionStrategy
 	}
@@ -179,31 +227,35 @@ func resolveToAbsoluteLatestVersion(
 	switch resolutionStrategy {
 	case provider.ResolutionStrategyLatestInstalled:
 		// Fetch latest installed version
-		sortedInstalledVersions := logicallySortedVersions(installedVersions)
-		latestInstalled := sortedInstalledVersions[0]
-		if latestInstalled == "" {
-			return VersionResolution{}, &ErrNoMatchingVersion{
-				AvailableVersions: installedVersions,
-				RequestedVersion:  "installed",
+		sortedInstalledVersions := provider.LogicallySortedVersions(installedVersions)
+		if len(sortedInstalledVersions) > 0 {
+			latestInstalled := sortedInstalledVersions[0]
+			if latestInstalled == "" {
+				return VersionResolution{}, &ErrNoMatchingVersion{
+					AvailableVersions: installedVersions,
+					RequestedVersion:  "installed",
+				}
 			}
+			semverV, err := version.NewVersion(latestInstalled)
+			return VersionResolution{
+				VersionString: latestInstalled,
+				IsSemVer:      err == nil,
+				SemVer:        semverV,
+				IsInstalled:   true,
+			}, nil
 		}
-		semverV, err := version.NewVersion(latestInstalled)
-		return VersionResolution{
-			VersionString: latestInstalled,
-			IsSemVer:      err == nil,
-			SemVer:        semverV,
-			IsInstalled:   true,
-		}, nil
+		// Nothing is installed, fall back to the latest released version like ResolveVersion() does for prefixes.
+		fallthrough
 	case provider.ResolutionStrategyLatestReleased:
 		// Fetch latest released version
-		sortedReleasedVersions := logicallySortedVersions(releasedVersions)
-		latestReleased := sortedReleasedVersions[0]
-		if latestReleased == "" {
+		sortedReleasedVersions := provider.LogicallySortedVersions(releasedVersions)
+		if len(sortedReleasedVersions) == 0 || sortedReleasedVersions[0] == "" {
 			return VersionResolution{}, &ErrNoMatchingVersion{
 				AvailableVersions: releasedVersions,
 				RequestedVersion:  "latest",
 			}
 		}
+		latestReleased := sortedReleasedVersions[0]
 		isInstalled := slices.Contains(installedVersions, latestReleased)
 		semverV, err := version.NewVersion(latestReleased)
 		return VersionResolution{
@@ -216,32 +268,3 @@ func resolveToAbsoluteLatestVersion(
 		return VersionResolution{}, fmt.Errorf("could not resolve resolution strategy for version %v", request.UnparsedVersion)
 	}
 }
-
-// logicallySortedVersions reverse-sorts the given versions in a way that semver-compatible versions are sorted according to the semver spec,
-// while non-semver versions are appended at the end in their own lexicographical order.
-// This way, semver-compatible versions are prioritized over non-semver versions.
-func logicallySortedVersions(versions []string) []string {
-	var semverVersions version.Collection
-	var nonSemverVersions []string
-	for _, v := range versions {
-		semverV, err := version.NewVersion(v)
-		if err != nil {
-			nonSemverVersions = append(nonSemverVersions, v)
-			continue
-		}
-		semverVersions = append(semverVersions, semverV)
-	}
-
-	// semverVersions is of type version.Collection, which implements sort.Interface according to the semver spec.
-	sort.Sort(sort.Reverse(semverVersions))
-	// nonSemverVersions are only lexicographically sortable
-	sort.Sort(sort.Reverse(sort.StringSlice(nonSemverVersions)))
-
-	var sortedVersions []string
-	for _, v := range semverVersions {
-		sortedVersions = append(sortedVersions, v.Original())
-	}
-
-	sortedVersions = append(sortedVersions, nonSemverVersions...)
-	return sortedVersions
-}
@@ -261,6 +261,28 @@ func TestStrictResolution(t *testing.T) {
 				IsInstalled:   false,
 			},
 		},
+		{
+			name:              "latest installed version requested with nothing installed",
+			requestedVersion:  "installed",
+			installedVersions: []string{},
+			releasedVersions: []string{
+				"20.5.0",
+				"22.0.0",
+			},
+			expectedResolution: asdf.VersionResolution{
+				VersionString: "22.0.0",
+				IsSemVer:      true,
+				SemVer:        version.Must(version.NewVersion("22.0.0")),
+				IsInstalled:   false,
+			},
+		},
+		{
+			name:              "latest released version requested without released versions",
+			requestedVersion:  "latest",
+			installedVersions: []string{},
+			releasedVersions:  []string{},
+			expectedErr:       &asdf.ErrNoMatchingVersion{AvailableVersions: []string{}, RequestedVersion: "latest"},
+		},
 	}
 
 	runVersionResolutionTests(t, tests, provider.ResolutionStrategyStrict)
@@ -1008,6 +1030,150 @@ func TestLatestReleasedResolution(t *testing.T) {
 	runVersionResolutionTests(t, tests, provider.ResolutionStrategyLatestReleased)
 }
 
+func TestConstraintResolution(t *testing.T) {
+	tests := []struct {
+		name               string
+		requestedVersion   string
+		installedVersions  []string
+		releasedVersions   []string
+		expectedResolution asdf.VersionResolution
+		expectedErr        error
+	}{
+		{
+			name:              "Range matches released version",
+			requestedVersion:  ">=1.21 <1.23",
+			installedVersions: []string{"1.20.14", "1.21.0"},
+			releasedVersions:  []string{"1.20.14", "1.21.0", "1.22.0", "1.22.5", "1.23.0"},
+			expectedResolution: asdf.VersionResolution{
+				VersionString: "1.22.5",
+				IsSemVer:      true,
+				SemVer:        version.Must(version.NewVersion("1.22.5")),
+				IsInstalled:   false,
+			},
+		},
+		{
+			name:              "Caret matches installed version",
+			requestedVersion:  "^20",
+			installedVersions: []string{"18.20.0", "20.19.3"},
+			releasedVersions:  []string{"18.20.0", "20.19.2", "20.19.3", "22.1.0"},
+			expectedResolution: asdf.VersionResolution{
+				VersionString: "20.19.3",
+				IsSemVer:      true,
+				SemVer:        version.Must(version.NewVersion("20.19.3")),
+				IsInstalled:   true,
+			},
+		},
+		{
+			name:              "Installed version that is not released anymore",
+			requestedVersion:  "~> 3.2",
+			installedVersions: []string{"3.4.0-custom"},
+			releasedVersions:  []string{"3.1.0", "3.2.0"},
+			expectedResolution: asdf.VersionResolution{
+				VersionString: "3.2.0",
+				IsSemVer:      true,
+				SemVer:        version.Must(version.NewVersion("3.2.0")),
+				IsInstalled:   false,
+			},
+		},
+		{
+			name:              "Exclusion",
+			requestedVersion:  "!=3.12.1",
+			installedVersions: []string{},
+			releasedVersions:  []string{"3.12.0", "3.12.1"},
+			expectedResolution: asdf.VersionResolution{
+				VersionString: "3.12.0",
+				IsSemVer:      true,
+				SemVer:        version.Must(version.NewVersion("3.12.0")),
+				IsInstalled:   false,
+			},
+		},
+		{
+			name:              "Non-semver versions never match",
+			requestedVersion:  ">=21",
+			installedVersions: []string{},
+			releasedVersions:  []string{"temurin-21.0.0+35.0.LTS", "openjdk-21"},
+			expectedErr: &asdf.ErrNoMatchingVersion{
+				AvailableVersions: []string{"temurin-21.0.0+35.0.LTS", "openjdk-21"},
+				RequestedVersion:  ">=21",
+			},
+		},
+	}
+
+	runVersionResolutionTests(t, tests, provider.ResolutionStrategyConstraint)
+}
+
+func TestAutoResolution(t *testing.T) {
+	tests := []struct {
+		name               string
+		requestedVersion   string
+		installedVersions  []string
+		releasedVersions   []string
+		expectedResolution asdf.VersionResolution
+		expectedErr        error
+	}{
+		{
+			name:              "Prefix prefers installed version",
+			requestedVersion:  "20",
+			installedVersions: []string{"20.18.0", "22.1.0"},
+			releasedVersions:  []string{"18.20.0", "20.18.0", "20.19.3", "22.1.0"},
+			expectedResolution: asdf.VersionResolution{
+				VersionString: "20.18.0",
+				IsSemVer:      true,
+				SemVer:        version.Must(version.NewVersion("20.18.0")),
+				IsInstalled:   true,
+			},
+		},
+		{
+			name:              "Prefix falls back to latest released version",
+			requestedVersion:  "3.12",
+			installedVersions: []string{"3.11.9"},
+			releasedVersions:  []string{"3.11.9", "3.12.1", "3.12.10", "3.12.9"},
+			expectedResolution: asdf.VersionResolution{
+				VersionString: "3.12.10",
+				IsSemVer:      true,
+				SemVer:        version.Must(version.NewVersion("3.12.10")),
+				IsInstalled:   false,
+			},
+		},
+		{
+			name:              "Fully specified version is strict",
+			requestedVersion:  "20.18.0",
+			installedVersions: []string{"20.18.1"},
+			releasedVersions:  []string{"20.18.0", "20.19.3"},
+			expectedResolution: asdf.VersionResolution{
+				VersionString: "20.18.0",
+				IsSemVer:      true,
+				SemVer:        version.Must(version.NewVersion("20.18.0")),
+				IsInstalled:   false,
+			},
+		},
+		{
+			name:              "Latest is resolved to latest released version",
+			requestedVersion:  "latest",
+			installedVersions: []string{"20.18.0"},
+			releasedVersions:  []string{"20.18.0", "20.19.3"},
+			expectedResolution: asdf.VersionResolution{
+				VersionString: "20.19.3",
+				IsSemVer:      true,
+				SemVer:        version.Must(version.NewVersion("20.19.3")),
+				IsInstalled:   false,
+			},
+		},
+		{
+			name:              "No match",
+			requestedVersion:  "24",
+			installedVersions: []string{},
+			releasedVersions:  []string{"20.19.3", "22.1.0"},
+			expectedErr: &asdf.ErrNoMatchingVersion{
+				AvailableVersions: []string{"20.19.3", "22.1.0"},
+				RequestedVersion:  "24",
+			},
+		},
+	}
+
+	runVersionResolutionTests(t, tests, provider.ResolutionStrategyAuto)
+}
+
 func runVersionResolutionTests(
 	t *testing.T,
 	tests []struct {
@@ -0,0 +1,59 @@
+package asdf
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/toolprovider/provider"
+)
+
+// ListInstalled returns the installed versions of every asdf plugin. Versions created by the asdf-alias plugin are skipped.
+func (a AsdfToolProvider) ListInstalled(ctx context.Context) ([]provider.InstalledVersion, error) {
+	plugins, err := a.listPlugins(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dataDir, err := a.dataDir()
+	if err != nil {
+		return nil, err
+	}
+
+	var installed []provider.InstalledVersion
+	for _, plugin := range plugins {
+		versions, err := a.listInstalled(ctx, plugin)
+		if err != nil {
+			return nil, fmt.Errorf("list installed versions of %s: %w", plugin, err)
+		}
+		for _, v := range versions {
+			installed = append(installed, provider.InstalledVersion{
+				ToolName: plugin,
+				Version:  v,
+				Path:     filepath.Join(dataDir, "installs", plugin, v),
+			})
+		}
+	}
+	return installed, nil
+}
+
+func (a AsdfToolProvider) UninstallTool(ctx context.Context, toolName string, version string) error {
+	output, err := a.ExecEnv.RunAsdf(ctx, "uninstall", toolName, version)
+	if err != nil {
+		return fmt.Errorf("asdf uninstall %s %s: %w\n%s", toolName, version, err, output)
+	}
+	return nil
+}
+
+func (a AsdfToolProvider) listPlugins(ctx context.Context) ([]string, error) {
+	output, err := a.ExecEnv.RunAsdfPlugin(ctx, "list")
+	if err != nil {
+		// Some asdf versions exit with an error when there are no plugins
+		if strings.Contains(output, "No plugins installed") {
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("asdf plugin list: %w", err)
+	}
+
+	return parsePluginListOutput(output), nil
+}
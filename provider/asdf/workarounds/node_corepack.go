@@ -1,6 +1,7 @@
 package workarounds
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/bitrise-io/toolprovider/provider/asdf/execenv"
@@ -8,18 +9,18 @@ import (
 
 // When installing a new Node.js version, the `corepack` executable is missing until we reshim the installed version.
 // https://github.com/asdf-vm/asdf-nodejs/blob/90b8ecaa556916daba983a7b01869a9ea682f285/README.md#corepack
-func SetupCorepack(execEnv execenv.ExecEnv, nodeVersion string) error {
+func SetupCorepack(ctx context.Context, execEnv execenv.ExecEnv, nodeVersion string) error {
 	extraEnvs := map[string]string{
 		// Simulate the activated environment
 		"ASDF_NODEJS_VERSION": nodeVersion,
 	}
 
-	out, err := execEnv.RunCommand(extraEnvs, "corepack", "enable")
+	out, err := execEnv.RunCommand(ctx, extraEnvs, "corepack", "enable")
 	if err != nil {
 		return fmt.Errorf("enable corepack: %w\n\nOutput:\n%s", err, out)
 	}
 
-	out, err = execEnv.RunAsdf("reshim", "nodejs", nodeVersion)
+	out, err = execEnv.RunAsdf(ctx, "reshim", "nodejs", nodeVersion)
 	if err != nil {
 		return fmt.Errorf("reshim nodejs after corepack setup: %w\n\nOutput:\n%s", err, out)
 	}
@@ -0,0 +1,117 @@
+package provider
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+var numericVersionPattern = regexp.MustCompile(`\d+(?:\.\d+)*`)
+
+// IsFullySpecifiedVersion reports whether an auto request for the version behaves as strict. That's the case if the first
+// numeric part of the version has at least three components (e.g. 20.11.1 or temurin-21.0.2+13), or if the version
+// has no numeric part at all (e.g. a channel name like stable).
+func IsFullySpecifiedVersion(v string) bool {
+	numericPart := numericVersionPattern.FindString(v)
+	if numericPart == "" {
+		return true
+	}
+	return len(strings.Split(numericPart, ".")) >= 3
+}
+
+// MatchesVersionPrefix reports whether v starts with the version components of prefix, e.g. 3.1 matches 3.1.4
+// and 3.1-rc1, but not 3.12.0.
+func MatchesVersionPrefix(v, prefix string) bool {
+	rest, ok := strings.CutPrefix(v, prefix)
+	if !ok {
+		return false
+	}
+	return rest == "" || prefix == "" || !isDigit(rest[0]) || !isDigit(prefix[len(prefix)-1])
+}
+
+// ResolveAutoVersion resolves the version of a ResolutionStrategyAuto request. A fully specified version
+// (see IsFullySpecifiedVersion()) must be installed or released as it is. Otherwise it's a prefix: the latest installed
+// version matching it is preferred, then the latest released one. Providers use this, so that auto requests resolve
+// the same way with every provider.
+func ResolveAutoVersion(requested string, releasedVersions, installedVersions []string) (string, bool) {
+	requested = strings.TrimSpace(requested)
+	if IsFullySpecifiedVersion(requested) {
+		found := slices.Contains(installedVersions, requested) || slices.Contains(releasedVersions, requested)
+		return requested, found
+	}
+
+	for _, versions := range [][]string{installedVersions, releasedVersions} {
+		for _, v := range LogicallySortedVersions(versions) {
+			if MatchesVersionPrefix(v, requested) {
+				return v, true
+			}
+		}
+	}
+	return "", false
+}
+
+// EffectiveStrategy returns the strategy a request resolves with. It's the requested strategy, except for
+// ResolutionStrategyAuto, which behaves as strict, latest installed or latest released depending on the version
+// and on whether the resolved version is installed (see ResolveAutoVersion()).
+func (t ToolRequest) EffectiveStrategy(isInstalled bool) ResolutionStrategy {
+	return effectiveStrategy(t.ResolutionStrategy, t.UnparsedVersion, isInstalled)
+}
+
+// EffectiveStrategy returns the strategy the plan resolved with, see ToolRequest.EffectiveStrategy().
+func (p ToolInstallPlan) EffectiveStrategy() ResolutionStrategy {
+	return effectiveStrategy(p.ResolutionStrategy, p.RequestedVersion, p.IsInstalled)
+}
+
+func effectiveStrategy(strategy ResolutionStrategy, requestedVersion string, isInstalled bool) ResolutionStrategy {
+	if strategy != ResolutionStrategyAuto {
+		return strategy
+	}
+	switch v := strings.TrimSpace(requestedVersion); {
+	case v == "installed":
+		return ResolutionStrategyLatestInstalled
+	case v == "" || v == "latest":
+		return ResolutionStrategyLatestReleased
+	case IsFullySpecifiedVersion(v):
+		return ResolutionStrategyStrict
+	case isInstalled:
+		return ResolutionStrategyLatestInstalled
+	default:
+		return ResolutionStrategyLatestReleased
+	}
+}
+
+// LogicallySortedVersions reverse-sorts the given versions in a way that semver-compatible versions are sorted according to the semver spec,
+// while non-semver versions are appended at the end in their own lexicographical order.
+// This way, semver-compatible versions are prioritized over non-semver versions.
+func LogicallySortedVersions(versions []string) []string {
+	var semverVersions version.Collection
+	var nonSemverVersions []string
+	for _, v := range versions {
+		semverV, err := version.NewVersion(v)
+		if err != nil {
+			nonSemverVersions = append(nonSemverVersions, v)
+			continue
+		}
+		semverVersions = append(semverVersions, semverV)
+	}
+
+	// semverVersions is of type version.Collection, which implements sort.Interface according to the semver spec.
+	sort.Sort(sort.Reverse(semverVersions))
+	// nonSemverVersions are only lexicographically sortable
+	sort.Sort(sort.Reverse(sort.StringSlice(nonSemverVersions)))
+
+	var sortedVersions []string
+	for _, v := range semverVersions {
+		sortedVersions = append(sortedVersions, v.Original())
+	}
+
+	sortedVersions = append(sortedVersions, nonSemverVersions...)
+	return sortedVersions
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,110 @@
+package provider_test
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/toolprovider/provider"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsFullySpecifiedVersion(t *testing.T) {
+	require.True(t, provider.IsFullySpecifiedVersion("20.11.1"))
+	require.True(t, provider.IsFullySpecifiedVersion("temurin-21.0.2+13.0.LTS"))
+	require.True(t, provider.IsFullySpecifiedVersion("stable"))
+	require.False(t, provider.IsFullySpecifiedVersion("20"))
+	require.False(t, provider.IsFullySpecifiedVersion("3.12"))
+	require.False(t, provider.IsFullySpecifiedVersion("temurin-21"))
+}
+
+func TestMatchesVersionPrefix(t *testing.T) {
+	require.True(t, provider.MatchesVersionPrefix("3.1.4", "3.1"))
+	require.True(t, provider.MatchesVersionPrefix("3.1", "3.1"))
+	require.True(t, provider.MatchesVersionPrefix("3.1-rc1", "3.1"))
+	require.True(t, provider.MatchesVersionPrefix("temurin-21.0.2", "temurin-21"))
+	require.False(t, provider.MatchesVersionPrefix("3.12.0", "3.1"))
+	require.False(t, provider.MatchesVersionPrefix("temurin-210", "temurin-21"))
+	require.False(t, provider.MatchesVersionPrefix("2.3.1", "3.1"))
+}
+
+func TestResolveAutoVersion(t *testing.T) {
+	tests := []struct {
+		name              string
+		requested         string
+		releasedVersions  []string
+		installedVersions []string
+		want              string
+		wantFound         bool
+	}{
+		{
+			name:              "prefix prefers installed version",
+			requested:         "20",
+			releasedVersions:  []string{"18.20.0", "20.18.0", "20.19.3", "22.1.0"},
+			installedVersions: []string{"20.18.0", "22.1.0"},
+			want:              "20.18.0",
+			wantFound:         true,
+		},
+		{
+			name:              "prefix falls back to latest released version",
+			requested:         "3.12",
+			releasedVersions:  []string{"3.11.9", "3.12.1", "3.12.10", "3.12.9"},
+			installedVersions: []string{"3.11.9"},
+			want:              "3.12.10",
+			wantFound:         true,
+		},
+		{
+			name:              "prefix doesn't match longer version components",
+			requested:         "3.1",
+			releasedVersions:  []string{"3.1.4", "3.12.0"},
+			installedVersions: []string{"3.12.0"},
+			want:              "3.1.4",
+			wantFound:         true,
+		},
+		{
+			name:              "fully specified version is strict",
+			requested:         "20.18.0",
+			releasedVersions:  []string{"20.18.0", "20.19.3"},
+			installedVersions: []string{"20.18.1"},
+			want:              "20.18.0",
+			wantFound:         true,
+		},
+		{
+			name:             "fully specified version that doesn't exist",
+			requested:        "20.18.5",
+			releasedVersions: []string{"20.18.0", "20.19.3"},
+			want:             "20.18.5",
+			wantFound:        false,
+		},
+		{
+			name:             "no match",
+			requested:        "24",
+			releasedVersions: []string{"20.19.3", "22.1.0"},
+			wantFound:        false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := provider.ResolveAutoVersion(tt.requested, tt.releasedVersions, tt.installedVersions)
+			require.Equal(t, tt.wantFound, found)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEffectiveStrategy(t *testing.T) {
+	auto := func(v string) provider.ToolRequest {
+		return provider.ToolRequest{ToolName: "nodejs", UnparsedVersion: v, ResolutionStrategy: provider.ResolutionStrategyAuto}
+	}
+
+	require.Equal(t, provider.ResolutionStrategyLatestInstalled, auto("20").EffectiveStrategy(true))
+	require.Equal(t, provider.ResolutionStrategyLatestReleased, auto("20").EffectiveStrategy(false))
+	require.Equal(t, provider.ResolutionStrategyStrict, auto("20.19.3").EffectiveStrategy(true))
+	require.Equal(t, provider.ResolutionStrategyLatestInstalled, auto("installed").EffectiveStrategy(false))
+	require.Equal(t, provider.ResolutionStrategyLatestReleased, auto("latest").EffectiveStrategy(true))
+
+	strict := provider.ToolRequest{ToolName: "nodejs", UnparsedVersion: "20", ResolutionStrategy: provider.ResolutionStrategyStrict}
+	require.Equal(t, provider.ResolutionStrategyStrict, strict.EffectiveStrategy(false))
+
+	plan := provider.ToolInstallPlan{ToolName: "nodejs", RequestedVersion: "20", ResolutionStrategy: provider.ResolutionStrategyAuto, IsInstalled: true}
+	require.Equal(t, provider.ResolutionStrategyLatestInstalled, plan.EffectiveStrategy())
+}
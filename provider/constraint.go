@@ -0,0 +1,113 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+// Matches a single constraint clause like ">=1.21", "~> 3.2", "^20" or "!=3.12.1".
+var constraintClausePattern = regexp.MustCompile(`(>=|<=|!=|~>|>|<|=|\^|~)\s*([0-9][0-9A-Za-z.\-+]*)`)
+
+// IsVersionConstraint reports whether the version string is a constraint expression instead of a (partial) version.
+func IsVersionConstraint(unparsedVersion string) bool {
+	v := strings.TrimSpace(unparsedVersion)
+	return v != "" && strings.ContainsAny(v[:1], "<>=~^!")
+}
+
+// ParseVersionConstraint parses a constraint expression such as `>=1.21 <1.23`, `~> 3.2`, `^20` or `!=3.12.1`.
+// Clauses can be separated by whitespace or commas and all of them must be satisfied.
+// On top of the go-version syntax, the npm-style caret (^1.2.3) and tilde (~1.2) ranges are also supported.
+func ParseVersionConstraint(expression string) (version.Constraints, error) {
+	remainder := strings.TrimSpace(expression)
+	matches := constraintClausePattern.FindAllStringSubmatchIndex(remainder, -1)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("invalid version constraint: %s", expression)
+	}
+
+	var clauses []string
+	lastEnd := 0
+	for _, m := range matches {
+		// Only separators are allowed between clauses
+		if strings.Trim(remainder[lastEnd:m[0]], " ,") != "" {
+			return nil, fmt.Errorf("invalid version constraint: %s", expression)
+		}
+		lastEnd = m[1]
+
+		operator := remainder[m[2]:m[3]]
+		v := remainder[m[4]:m[5]]
+		translated, err := translateConstraintClause(operator, v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version constraint %s: %w", expression, err)
+		}
+		clauses = append(clauses, translated...)
+	}
+	if strings.Trim(remainder[lastEnd:], " ,") != "" {
+		return nil, fmt.Errorf("invalid version constraint: %s", expression)
+	}
+
+	constraints, err := version.NewConstraint(strings.Join(clauses, ", "))
+	if err != nil {
+		return nil, fmt.Errorf("invalid version constraint %s: %w", expression, err)
+	}
+	return constraints, nil
+}
+
+// LatestMatchingVersion returns the highest version among the candidates that satisfies the constraints.
+// Candidates that are not valid semantic versions (e.g. temurin-21.0.0) never match.
+func LatestMatchingVersion(constraints version.Constraints, candidates []string) (string, bool) {
+	var latest *version.Version
+	for _, c := range candidates {
+		v, err := version.NewVersion(c)
+		if err != nil {
+			continue
+		}
+		if !constraints.Check(v) {
+			continue
+		}
+		if latest == nil || v.GreaterThan(latest) {
+			latest = v
+		}
+	}
+	if latest == nil {
+		return "", false
+	}
+	return latest.Original(), true
+}
+
+func translateConstraintClause(operator, v string) ([]string, error) {
+	switch operator {
+	case "^", "~":
+		segments := strings.Split(v, ".")
+		numbers := make([]int, len(segments))
+		for i, s := range segments {
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return nil, fmt.Errorf("%s%s: only numeric versions are supported", operator, v)
+			}
+			numbers[i] = n
+		}
+
+		var upperBound string
+		switch {
+		case operator == "~" && len(numbers) >= 2:
+			// ~1.2.3 := >=1.2.3 <1.3.0
+			upperBound = fmt.Sprintf("%d.%d.0", numbers[0], numbers[1]+1)
+		case operator == "~" || numbers[0] > 0 || len(numbers) == 1:
+			// ~1 and ^1.2.3 := <2.0.0
+			upperBound = fmt.Sprintf("%d.0.0", numbers[0]+1)
+		case numbers[1] > 0 || len(numbers) == 2:
+			// ^0.2.3 := <0.3.0
+			upperBound = fmt.Sprintf("0.%d.0", numbers[1]+1)
+		default:
+			// ^0.0.3 := <0.0.4
+			upperBound = fmt.Sprintf("0.0.%d", numbers[2]+1)
+		}
+		return []string{">= " + v, "< " + upperBound}, nil
+	default:
+		return []string{operator + " " + v}, nil
+	}
+}
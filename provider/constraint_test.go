@@ -0,0 +1,117 @@
+package provider_test
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/toolprovider/provider"
+	"github.com/hashicorp/go-version"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersionConstraint(t *testing.T) {
+	tests := []struct {
+		name        string
+		expression  string
+		matching    []string
+		nonMatching []string
+		wantErr     bool
+	}{
+		{
+			name:        "space separated range",
+			expression:  ">=1.21 <1.23",
+			matching:    []string{"1.21.0", "1.22.5"},
+			nonMatching: []string{"1.20.14", "1.23.0"},
+		},
+		{
+			name:        "comma separated range",
+			expression:  ">= 1.21, < 1.23",
+			matching:    []string{"1.21.0", "1.22.5"},
+			nonMatching: []string{"1.20.14", "1.23.0"},
+		},
+		{
+			name:        "pessimistic operator",
+			expression:  "~> 3.2",
+			matching:    []string{"3.2.0", "3.9.1"},
+			nonMatching: []string{"3.1.9", "4.0.0"},
+		},
+		{
+			name:        "caret",
+			expression:  "^20",
+			matching:    []string{"20.0.0", "20.19.3"},
+			nonMatching: []string{"19.9.0", "21.0.0"},
+		},
+		{
+			name:        "caret with zero major",
+			expression:  "^0.2.3",
+			matching:    []string{"0.2.3", "0.2.9"},
+			nonMatching: []string{"0.2.2", "0.3.0"},
+		},
+		{
+			name:        "tilde",
+			expression:  "~1.2.3",
+			matching:    []string{"1.2.3", "1.2.10"},
+			nonMatching: []string{"1.2.2", "1.3.0"},
+		},
+		{
+			name:        "exclusion",
+			expression:  "!=3.12.1",
+			matching:    []string{"3.12.0", "3.12.2"},
+			nonMatching: []string{"3.12.1"},
+		},
+		{
+			name:       "garbage between clauses",
+			expression: ">=1.21 and <1.23",
+			wantErr:    true,
+		},
+		{
+			name:       "non-numeric caret",
+			expression: "^temurin-21",
+			wantErr:    true,
+		},
+		{
+			name:       "missing version",
+			expression: ">=",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			constraints, err := provider.ParseVersionConstraint(tt.expression)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			for _, v := range tt.matching {
+				require.True(t, constraints.Check(version.Must(version.NewVersion(v))), "%s should satisfy %s", v, tt.expression)
+			}
+			for _, v := range tt.nonMatching {
+				require.False(t, constraints.Check(version.Must(version.NewVersion(v))), "%s should not satisfy %s", v, tt.expression)
+			}
+		})
+	}
+}
+
+func TestLatestMatchingVersion(t *testing.T) {
+	constraints, err := provider.ParseVersionConstraint(">=1.21 <1.23")
+	require.NoError(t, err)
+
+	v, found := provider.LatestMatchingVersion(constraints, []string{"1.20.14", "1.22.5", "temurin-21", "1.21.0", "1.23.0"})
+	require.True(t, found)
+	require.Equal(t, "1.22.5", v)
+
+	_, found = provider.LatestMatchingVersion(constraints, []string{"1.20.14", "1.23.0"})
+	require.False(t, found)
+}
+
+func TestIsVersionConstraint(t *testing.T) {
+	require.True(t, provider.IsVersionConstraint(">=1.21 <1.23"))
+	require.True(t, provider.IsVersionConstraint("~> 3.2"))
+	require.True(t, provider.IsVersionConstraint("^20"))
+	require.True(t, provider.IsVersionConstraint("!=3.12.1"))
+	require.False(t, provider.IsVersionConstraint("20"))
+	require.False(t, provider.IsVersionConstraint("temurin-21"))
+	require.False(t, provider.IsVersionConstraint(""))
+}
@@ -0,0 +1,46 @@
+package direct
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/bitrise-io/toolprovider/internal/lineoutput"
+)
+
+// waitDelay is how long a command may keep its output pipes open after it's killed on cancellation.
+const waitDelay = 5 * time.Second
+
+// runShellCommand runs a command line with bash, with extraEnvs added to the environment of the process.
+// Each line of the output is passed to onLine while the command runs. When ctx is done, the whole process group
+// of bash is killed, so that the processes started by the command don't outlive it.
+func runShellCommand(ctx context.Context, onLine func(line string), extraEnvs map[string]string, command string) (string, error) {
+	cmd := exec.CommandContext(ctx, "bash", "-c", command)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = waitDelay
+	cmd.Env = os.Environ()
+	for k, v := range extraEnvs {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, v))
+	}
+
+	outputWriter := lineoutput.NewWriter(onLine)
+	cmd.Stdout = outputWriter
+	cmd.Stderr = outputWriter
+	err := cmd.Run()
+	outputWriter.Flush()
+	output := string(outputWriter.Output())
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return output, fmt.Errorf("bash -c %s: %w", command, ctxErr)
+	}
+	if err != nil {
+		// Output is returned in case of an error too, so that callers can report it in a structured way.
+		return output, fmt.Errorf("bash -c %s: %w", command, err)
+	}
+	return output, nil
+}
@@ -0,0 +1,145 @@
+// Package direct is a tool provider that needs no tool manager: it downloads the releases of tools as described
+// by a Manifest, verifies their checksum and unpacks them into a versioned store.
+package direct
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/bitrise-io/toolprovider/provider"
+)
+
+// DirectToolProvider installs each tool version into DataDir/installs/<tool>/<version>,
+// and activates it by adding its bin dir to $PATH.
+type DirectToolProvider struct {
+	DataDir  string
+	Manifest Manifest
+}
+
+func NewToolProvider(dataDir string, manifest Manifest) (*DirectToolProvider, error) {
+	if dataDir == "" {
+		return nil, fmt.Errorf("data dir must not be empty")
+	}
+	return &DirectToolProvider{DataDir: dataDir, Manifest: manifest}, nil
+}
+
+func (d *DirectToolProvider) ID() string {
+	return "direct"
+}
+
+// Version is always "builtin", because there's no underlying tool manager.
+func (d *DirectToolProvider) Version() (string, error) {
+	return "builtin", nil
+}
+
+func (d *DirectToolProvider) Bootstrap(ctx context.Context) error {
+	if err := os.MkdirAll(d.installsDir(), 0755); err != nil {
+		return fmt.Errorf("bootstrap direct: create store %s: %w", d.installsDir(), err)
+	}
+	return nil
+}
+
+// SupportsConcurrentInstalls is true, because every tool version is unpacked in its own temp dir
+// and moved to the store in one step.
+func (d *DirectToolProvider) SupportsConcurrentInstalls() bool {
+	return true
+}
+
+func (d *DirectToolProvider) InstallTool(ctx context.Context, tool provider.ToolRequest) (provider.ToolInstallResult, error) {
+	manifestTool, err := d.manifestTool(tool)
+	if err != nil {
+		return provider.ToolInstallResult{}, err
+	}
+
+	resolveStart := time.Now()
+	provider.Emit(ctx, provider.Event{Kind: provider.EventResolveStarted, ProviderID: d.ID(), ToolName: tool.ToolName, Version: tool.UnparsedVersion})
+	installedVersions, err := d.listInstalled(tool.ToolName)
+	if err != nil {
+		return provider.ToolInstallResult{}, err
+	}
+	concreteVersion, isInstalled, err := resolveVersion(tool, manifestTool.ReleasedVersions(runtime.GOOS, runtime.GOARCH), installedVersions)
+	if err != nil {
+		return provider.ToolInstallResult{}, err
+	}
+	resolveDuration := time.Since(resolveStart)
+	provider.Emit(ctx, provider.Event{Kind: provider.EventResolveFinished, ProviderID: d.ID(), ToolName: tool.ToolName, Version: concreteVersion})
+
+	result := provider.ToolInstallResult{
+		ToolName:           tool.ToolName,
+		IsAlreadyInstalled: isInstalled,
+		ConcreteVersion:    concreteVersion,
+		ResolveDuration:    resolveDuration,
+	}
+	if isInstalled {
+		return result, nil
+	}
+
+	err = d.installToolVersion(ctx, tool, manifestTool, concreteVersion)
+	if err != nil {
+		return provider.ToolInstallResult{}, err
+	}
+	err = d.runPostInstall(ctx, tool, result)
+	if err != nil {
+		return provider.ToolInstallResult{}, provider.UninstallAfterFailedPostInstall(ctx, d, result, err)
+	}
+	return result, nil
+}
+
+func (d *DirectToolProvider) ActivateEnv(ctx context.Context, result provider.ToolInstallResult) (provider.EnvironmentActivation, error) {
+	activation := provider.EnvironmentActivation{}
+	if result.NativeTool != nil {
+		activation = result.NativeTool.Activation()
+	} else {
+		installDir := d.installDir(result.ToolName, result.ConcreteVersion)
+		if _, err := os.Stat(installDir); err != nil {
+			return provider.EnvironmentActivation{}, fmt.Errorf("%s %s is not installed: %w", result.ToolName, result.ConcreteVersion, err)
+		}
+		activation = d.envActivation(result)
+	}
+
+	provider.Emit(ctx, provider.Event{Kind: provider.EventActivated, ProviderID: d.ID(), ToolName: result.ToolName, Version: result.ConcreteVersion})
+	return activation, nil
+}
+
+// IsInstalledNative looks for the tool in $PATH and in the known stack locations. The store is skipped.
+func (d *DirectToolProvider) IsInstalledNative(ctx context.Context, tool provider.ToolRequest) (provider.NativeTool, bool, error) {
+	native, ok := provider.FindNativeTool(ctx, tool, provider.NativeSearchDirs(tool.ToolName, os.Getenv("PATH")), []string{d.DataDir})
+	return native, ok, nil
+}
+
+func (d *DirectToolProvider) envActivation(result provider.ToolInstallResult) provider.EnvironmentActivation {
+	binDir := d.installDir(result.ToolName, result.ConcreteVersion)
+	if manifestTool, ok := d.Manifest.Tools[provider.GetCanonicalToolName(result.ToolName)]; ok {
+		binDir = filepath.Join(binDir, manifestTool.BinDir)
+	}
+	return provider.EnvironmentActivation{
+		ContributedEnvVars: map[string]string{},
+		ContributedPaths:   []string{binDir},
+	}
+}
+
+func (d *DirectToolProvider) manifestTool(tool provider.ToolRequest) (ManifestTool, error) {
+	manifestTool, ok := d.Manifest.Tools[provider.GetCanonicalToolName(tool.ToolName)]
+	if !ok {
+		return ManifestTool{}, provider.ToolInstallError{
+			ToolName:         tool.ToolName,
+			RequestedVersion: tool.UnparsedVersion,
+			Cause:            fmt.Sprintf("%s is not in the manifest", tool.ToolName),
+			Recommendation:   "Add the tool to the manifest of the direct provider, or install it with another provider.",
+			Err:              provider.ErrToolNotFound,
+		}
+	}
+	return manifestTool, nil
+}
+
+func (d *DirectToolProvider) installsDir() string {
+	return filepath.Join(d.DataDir, "installs")
+}
+
+func (d *DirectToolProvider) installDir(toolName, version string) string {
+	return filepath.Join(d.installsDir(), toolName, version)
+}
@@ -0,0 +1,360 @@
+package direct_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/toolprovider/provider"
+	"github.com/bitrise-io/toolprovider/provider/direct"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const platform = runtime.GOOS + "/" + runtime.GOARCH
+
+// releaseServer serves the releases of the test tools, and counts the downloads.
+type releaseServer struct {
+	*httptest.Server
+	files map[string][]byte
+
+	mu        sync.Mutex
+	downloads int
+}
+
+func newReleaseServer(t *testing.T) *releaseServer {
+	s := &releaseServer{files: map[string][]byte{
+		"/hello-1.0.0": script("hello 1.0.0"),
+		"/hello-1.1.0": script("hello 1.1.0"),
+		"/greet-2.0.0-" + runtime.GOOS + ".tar.gz": tarGz(t, map[string][]byte{
+			"greet-2.0.0/bin/greet": script("greet 2.0.0"),
+			"greet-2.0.0/README":    []byte("greet"),
+		}),
+		"/wave-3.0.0.zip": zipArchive(t, map[string][]byte{"wave": script("wave 3.0.0")}),
+	}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := s.files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		s.mu.Lock()
+		s.downloads++
+		s.mu.Unlock()
+		_, _ = w.Write(content)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *releaseServer) checksum(path string) string {
+	sum := sha256.Sum256(s.files[path])
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *releaseServer) downloadCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.downloads
+}
+
+func (s *releaseServer) manifest(t *testing.T) direct.Manifest {
+	manifest, err := direct.ParseManifest([]byte(fmt.Sprintf(`tools:
+  hello:
+    url: %[1]s/hello-{{.Version}}
+    bin_dir: bin
+    versions:
+      1.0.0:
+        %[2]s: %[3]s
+      1.1.0:
+        %[2]s: %[4]s
+      2.0.0:
+        plan9/mips: %[3]s
+  greet:
+    url: %[1]s/greet-{{.Version}}-{{.OS}}.tar.gz
+    strip_components: 1
+    bin_dir: bin
+    versions:
+      2.0.0:
+        %[2]s: %[5]s
+  wave:
+    url: %[1]s/wave-{{.Version}}.zip
+    versions:
+      3.0.0:
+        %[2]s: %[6]s
+  broken:
+    url: %[1]s/hello-{{.Version}}
+    versions:
+      1.0.0:
+        %[2]s: %[4]s
+`, s.URL, platform, s.checksum("/hello-1.0.0"), s.checksum("/hello-1.1.0"),
+		s.checksum("/greet-2.0.0-"+runtime.GOOS+".tar.gz"), s.checksum("/wave-3.0.0.zip"))))
+	require.NoError(t, err)
+	return manifest
+}
+
+func TestInstallTool(t *testing.T) {
+	server := newReleaseServer(t)
+	p, err := direct.NewToolProvider(t.TempDir(), server.manifest(t))
+	require.NoError(t, err)
+	require.NoError(t, p.Bootstrap(context.Background()))
+
+	tests := []struct {
+		request         provider.ToolRequest
+		concreteVersion string
+		binDir          string
+		output          string
+	}{
+		{
+			request:         provider.ToolRequest{ToolName: "hello", UnparsedVersion: "1.0", ResolutionStrategy: provider.ResolutionStrategyAuto},
+			concreteVersion: "1.0.0",
+			binDir:          filepath.Join("hello", "1.0.0", "bin"),
+			output:          "hello 1.0.0",
+		},
+		{
+			request:         provider.ToolRequest{ToolName: "hello", UnparsedVersion: "latest", ResolutionStrategy: provider.ResolutionStrategyStrict},
+			concreteVersion: "1.1.0",
+			binDir:          filepath.Join("hello", "1.1.0", "bin"),
+			output:          "hello 1.1.0",
+		},
+		{
+			request:         provider.ToolRequest{ToolName: "greet", UnparsedVersion: "2.0.0", ResolutionStrategy: provider.ResolutionStrategyStrict},
+			concreteVersion: "2.0.0",
+			binDir:          filepath.Join("greet", "2.0.0", "bin"),
+			output:          "greet 2.0.0",
+		},
+		{
+			request:         provider.ToolRequest{ToolName: "wave", UnparsedVersion: ">= 3", ResolutionStrategy: provider.ResolutionStrategyConstraint},
+			concreteVersion: "3.0.0",
+			binDir:          filepath.Join("wave", "3.0.0"),
+			output:          "wave 3.0.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.request.ToolName+" "+tt.request.UnparsedVersion, func(t *testing.T) {
+			var events []provider.Event
+			ctx := provider.WithObserver(context.Background(), provider.ObserverFunc(func(e provider.Event) {
+				events = append(events, e)
+			}))
+
+			result, err := p.InstallTool(ctx, tt.request)
+			require.NoError(t, err)
+			assert.False(t, result.IsAlreadyInstalled)
+			assert.Equal(t, tt.concreteVersion, result.ConcreteVersion)
+
+			activation, err := p.ActivateEnv(ctx, result)
+			require.NoError(t, err)
+			assert.Equal(t, []string{filepath.Join(p.DataDir, "installs", tt.binDir)}, activation.ContributedPaths)
+
+			cmd := exec.Command("bash", "-c", tt.request.ToolName)
+			cmd.Env = mapToEnv(activation.Apply(nil))
+			output, err := cmd.Output()
+			require.NoError(t, err)
+			assert.Equal(t, tt.output, strings.TrimSpace(string(output)))
+
+			var kinds []provider.EventKind
+			for _, e := range events {
+				kinds = append(kinds, e.Kind)
+			}
+			assert.Equal(t, []provider.EventKind{provider.EventResolveStarted, provider.EventResolveFinished, provider.EventDownloadProgress, provider.EventActivated}, kinds)
+		})
+	}
+
+	downloads := server.downloadCount()
+	result, err := p.InstallTool(context.Background(), provider.ToolRequest{ToolName: "hello", UnparsedVersion: "1.0.0", ResolutionStrategy: provider.ResolutionStrategyAuto})
+	require.NoError(t, err)
+	assert.True(t, result.IsAlreadyInstalled)
+	assert.Equal(t, downloads, server.downloadCount(), "installed versions are not downloaded again")
+
+	installed, err := p.ListInstalled(context.Background())
+	require.NoError(t, err)
+	var installedVersions []string
+	for _, v := range installed {
+		installedVersions = append(installedVersions, v.ToolName+"@"+v.Version)
+	}
+	assert.Equal(t, []string{"greet@2.0.0", "hello@1.0.0", "hello@1.1.0", "wave@3.0.0"}, installedVersions)
+
+	require.NoError(t, p.UninstallTool(context.Background(), "hello", "1.0.0"))
+	assert.NoDirExists(t, filepath.Join(p.DataDir, "installs", "hello", "1.0.0"))
+	assert.Error(t, p.UninstallTool(context.Background(), "hello", "../.."))
+}
+
+func TestPostInstall(t *testing.T) {
+	server := newReleaseServer(t)
+	p, err := direct.NewToolProvider(t.TempDir(), server.manifest(t))
+	require.NoError(t, err)
+
+	var lines []string
+	ctx := provider.WithObserver(context.Background(), provider.ObserverFunc(func(e provider.Event) {
+		if e.Kind == provider.EventOutput {
+			lines = append(lines, e.Message)
+		}
+	}))
+	_, err = p.InstallTool(ctx, provider.ToolRequest{ToolName: "hello", UnparsedVersion: "1.0.0", PostInstall: []string{"hello", "echo \"$GREETING\""},
+		DependencyEnv: provider.EnvironmentActivation{ContributedEnvVars: map[string]string{"GREETING": "hi"}}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hello 1.0.0", "hi"}, lines, "the commands run with the installed version activated")
+
+	_, err = p.InstallTool(context.Background(), provider.ToolRequest{ToolName: "greet", UnparsedVersion: "2.0.0", PostInstall: []string{"echo failing; exit 1"}})
+	var installErr provider.ToolInstallError
+	require.ErrorAs(t, err, &installErr)
+	assert.Equal(t, "failing\n", installErr.RawOutput)
+	assert.NoDirExists(t, filepath.Join(p.DataDir, "installs", "greet", "2.0.0"), "an install with a failed post-install command is removed")
+
+	// The next run installs the version again and runs the post-install commands
+	lines = nil
+	result, err := p.InstallTool(ctx, provider.ToolRequest{ToolName: "greet", UnparsedVersion: "2.0.0", PostInstall: []string{"greet"}})
+	require.NoError(t, err)
+	assert.False(t, result.IsAlreadyInstalled)
+	assert.Equal(t, []string{"greet 2.0.0"}, lines)
+
+	// The processes started by the command are killed too, so that they don't keep the output open
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	startedAt := time.Now()
+	_, err = p.InstallTool(ctx, provider.ToolRequest{ToolName: "wave", UnparsedVersion: "3.0.0", PostInstall: []string{"sleep 30 | cat"}})
+	require.Error(t, err)
+	assert.Less(t, time.Since(startedAt), 3*time.Second)
+}
+
+func TestInstallToolErrors(t *testing.T) {
+	server := newReleaseServer(t)
+	p, err := direct.NewToolProvider(t.TempDir(), server.manifest(t))
+	require.NoError(t, err)
+
+	_, err = p.InstallTool(context.Background(), provider.ToolRequest{ToolName: "broken", UnparsedVersion: "1.0.0"})
+	var installErr provider.ToolInstallError
+	require.ErrorAs(t, err, &installErr)
+	assert.Contains(t, installErr.Cause, "checksum mismatch for "+server.URL+"/hello-1.0.0")
+	entries, err := os.ReadDir(filepath.Join(p.DataDir, "tmp"))
+	require.NoError(t, err)
+	assert.Empty(t, entries, "the download is cleaned up")
+	assert.NoDirExists(t, filepath.Join(p.DataDir, "installs", "broken"))
+
+	_, err = p.InstallTool(context.Background(), provider.ToolRequest{ToolName: "hello", UnparsedVersion: "2.0.0"})
+	require.ErrorAs(t, err, &installErr)
+	assert.Equal(t, "no match for requested version 2.0.0, available versions: 1.1.0, 1.0.0", installErr.Cause)
+
+	_, err = p.InstallTool(context.Background(), provider.ToolRequest{ToolName: "ruby", UnparsedVersion: "3.3"})
+	require.ErrorAs(t, err, &installErr)
+	assert.Equal(t, "ruby is not in the manifest", installErr.Cause)
+}
+
+func TestPlanInstall(t *testing.T) {
+	server := newReleaseServer(t)
+	p, err := direct.NewToolProvider(t.TempDir(), server.manifest(t))
+	require.NoError(t, err)
+
+	request := provider.ToolRequest{ToolName: "hello", UnparsedVersion: "1", ResolutionStrategy: provider.ResolutionStrategyAuto}
+	plan, err := p.PlanInstall(context.Background(), request)
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", plan.ResolvedVersion)
+	assert.False(t, plan.IsInstalled)
+	assert.Zero(t, server.downloadCount())
+
+	_, err = p.InstallTool(context.Background(), provider.ToolRequest{ToolName: "hello", UnparsedVersion: "1.0.0"})
+	require.NoError(t, err)
+	plan, err = p.PlanInstall(context.Background(), request)
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", plan.ResolvedVersion, "installed versions are preferred")
+	assert.True(t, plan.IsInstalled)
+}
+
+func TestParseManifest(t *testing.T) {
+	_, err := direct.ParseManifest([]byte(`tools:
+  hello:
+    url: https://example.com/hello-{{.Version}}
+    checksum: abc
+`))
+	assert.ErrorContains(t, err, "field checksum not found")
+
+	_, err = direct.ParseManifest([]byte(`tools:
+  node:
+    url: https://example.com/node-{{.Version}}
+    versions:
+      20.0.0:
+        linux/amd64: abc
+  nodejs:
+    url: https://example.com/node-{{.Version}}
+    versions:
+      20.0.0:
+        linux/amd64: 0000000000000000000000000000000000000000000000000000000000000000
+  tar:
+    bin_dir: ../bin
+    url: https://example.com/tar
+    versions:
+      1.0.0: {}
+`))
+	assert.EqualError(t, err, "tools.node: versions.20.0.0.linux/amd64: expected a SHA256 checksum in hex, got abc\n"+
+		"tools.tar: bin_dir must be a relative path inside the install, got ../bin")
+}
+
+func TestNewFromOptions(t *testing.T) {
+	manifestPath := filepath.Join(t.TempDir(), "tools.yml")
+	require.NoError(t, os.WriteFile(manifestPath, []byte("tools: {}\n"), 0644))
+
+	p, err := provider.DefaultRegistry.New("direct", provider.ProviderOptions{DataDir: "/opt/direct", Manifest: manifestPath})
+	require.NoError(t, err)
+	assert.Equal(t, "/opt/direct", p.(*direct.DirectToolProvider).DataDir)
+
+	_, err = provider.DefaultRegistry.New("direct", provider.ProviderOptions{})
+	assert.EqualError(t, err, "direct needs a manifest, set tool_config.provider_options.direct.manifest")
+	_, err = provider.DefaultRegistry.New("direct", provider.ProviderOptions{Manifest: manifestPath, Version: "1.0"})
+	assert.EqualError(t, err, "version is not supported by direct")
+}
+
+func script(output string) []byte {
+	return []byte("#!/bin/sh\necho " + output + "\n")
+}
+
+func mapToEnv(env map[string]string) []string {
+	var result []string
+	for k, v := range env {
+		result = append(result, k+"="+v)
+	}
+	return result
+}
+
+func tarGz(t *testing.T, files map[string][]byte) []byte {
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for name, content := range files {
+		require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tarWriter.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tarWriter.Close())
+	require.NoError(t, gzipWriter.Close())
+	return buf.Bytes()
+}
+
+func zipArchive(t *testing.T, files map[string][]byte) []byte {
+	var buf bytes.Buffer
+	zipWriter := zip.NewWriter(&buf)
+	for name, content := range files {
+		header := &zip.FileHeader{Name: name, Method: zip.Deflate}
+		header.SetMode(0755)
+		w, err := zipWriter.CreateHeader(header)
+		require.NoError(t, err)
+		_, err = w.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, zipWriter.Close())
+	return buf.Bytes()
+}
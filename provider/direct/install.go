@@ -0,0 +1,134 @@
+package direct
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/bitrise-io/toolprovider/provider"
+	"github.com/bitrise-io/toolprovider/provider/download"
+)
+
+// installToolVersion downloads the release of the version for the current platform, verifies its checksum and unpacks it
+// into a temp dir next to the store, which is then renamed into place, so that a failed install leaves nothing behind.
+func (d *DirectToolProvider) installToolVersion(ctx context.Context, tool provider.ToolRequest, manifestTool ManifestTool, version string) error {
+	installError := provider.ToolInstallError{ToolName: tool.ToolName, RequestedVersion: tool.UnparsedVersion}
+	expectedChecksum, ok := manifestTool.Checksum(version, runtime.GOOS, runtime.GOARCH)
+	if !ok {
+		installError.Cause = fmt.Sprintf("no download of %s %s for %s/%s in the manifest", tool.ToolName, version, runtime.GOOS, runtime.GOARCH)
+		installError.Recommendation = "Add a checksum for this platform to the version in the manifest."
+		installError.Err = provider.ErrVersionNotFound
+		return installError
+	}
+	url, err := manifestTool.DownloadURL(version, runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", tool.ToolName, version, err)
+	}
+
+	tmpRoot := filepath.Join(d.DataDir, "tmp")
+	if err := os.MkdirAll(tmpRoot, 0755); err != nil {
+		return fmt.Errorf("create directory %s: %w", tmpRoot, err)
+	}
+	tmpDir, err := os.MkdirTemp(tmpRoot, fmt.Sprintf("%s-%s-*", tool.ToolName, version))
+	if err != nil {
+		return fmt.Errorf("create temp dir: %w", err)
+	}
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	downloadPath := filepath.Join(tmpDir, "download")
+	checksum, err := fetch(ctx, url, downloadPath, func(read, total int64) {
+		provider.Emit(ctx, provider.Event{Kind: provider.EventDownloadProgress, ProviderID: d.ID(), ToolName: tool.ToolName, Message: url, Bytes: read, TotalBytes: total})
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		installError.Cause = err.Error()
+		installError.Recommendation = "Check the url of the tool in the manifest."
+		return installError
+	}
+	if checksum != expectedChecksum {
+		installError.Cause = fmt.Sprintf("checksum mismatch for %s: expected %s, got %s", url, expectedChecksum, checksum)
+		installError.Recommendation = "Make sure that the checksum in the manifest is the SHA256 of the download for this platform."
+		return installError
+	}
+
+	unpackDir := filepath.Join(tmpDir, "install")
+	format := download.FormatOf(url)
+	targetDir := unpackDir
+	if format == download.FormatBinary {
+		targetDir = filepath.Join(unpackDir, manifestTool.BinDir)
+	}
+	binaryName := manifestTool.binaryName(tool.ToolName)
+	_, err = download.Unpack(format, downloadPath, targetDir, manifestTool.StripComponents, binaryName)
+	if err != nil {
+		return fmt.Errorf("unpack %s: %w", url, err)
+	}
+	if _, err := os.Stat(filepath.Join(unpackDir, manifestTool.BinDir, binaryName)); err != nil {
+		installError.Cause = fmt.Sprintf("%s not found in the download from %s", filepath.Join(manifestTool.BinDir, binaryName), url)
+		installError.Recommendation = "Check the binary, bin_dir and strip_components of the tool in the manifest."
+		return installError
+	}
+
+	installDir := d.installDir(tool.ToolName, version)
+	if err := os.MkdirAll(filepath.Dir(installDir), 0755); err != nil {
+		return fmt.Errorf("create directory %s: %w", filepath.Dir(installDir), err)
+	}
+	if err := os.Rename(unpackDir, installDir); err != nil {
+		if _, statErr := os.Stat(installDir); statErr == nil {
+			// Installed by someone else in the meantime
+			return nil
+		}
+		return fmt.Errorf("move %s %s into the store: %w", tool.ToolName, version, err)
+	}
+	return nil
+}
+
+// fetch downloads url to path and returns the SHA256 checksum of the download.
+func fetch(ctx context.Context, url string, path string, onProgress func(read, total int64)) (string, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("create %s: %w", path, err)
+	}
+	hash := sha256.New()
+	err = download.Fetch(ctx, url, io.MultiWriter(f, hash), onProgress)
+	if closeErr := f.Close(); closeErr != nil {
+		err = errors.Join(err, fmt.Errorf("close %s: %w", path, closeErr))
+	}
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// runPostInstall runs the post-install commands of the tool, one by one, with the freshly installed version activated.
+// It stops at the first failing command.
+func (d *DirectToolProvider) runPostInstall(ctx context.Context, tool provider.ToolRequest, installResult provider.ToolInstallResult) error {
+	if len(tool.PostInstall) == 0 {
+		return nil
+	}
+
+	env := d.envActivation(installResult).Apply(tool.DependencyEnv.Apply(nil))
+	onLine := provider.OutputLineEmitter(ctx, d.ID(), tool.ToolName)
+	for _, command := range tool.PostInstall {
+		out, err := runShellCommand(ctx, onLine, env, command)
+		if err != nil {
+			return provider.ToolInstallError{
+				ToolName:         tool.ToolName,
+				RequestedVersion: tool.UnparsedVersion,
+				Cause:            fmt.Sprintf("post-install command failed: %s", command),
+				Recommendation:   "Check the post_install commands of the tool declaration in bitrise.yml.",
+				RawOutput:        out,
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package direct
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"golang.org/x/exp/maps"
+	"gopkg.in/yaml.v3"
+
+	"github.com/bitrise-io/toolprovider/provider"
+)
+
+// Manifest describes where the releases of each tool are downloaded from. An example:
+//
+//	tools:
+//	  jq:
+//	    url: https://github.com/jqlang/jq/releases/download/jq-{{.Version}}/jq-{{.OS}}-{{.Arch}}
+//	    os:
+//	      darwin: macos
+//	    versions:
+//	      1.7.1:
+//	        linux/amd64: <SHA256 of jq-linux-amd64>
+//	        darwin/arm64: <SHA256 of jq-macos-arm64>
+type Manifest struct {
+	// Tools are keyed by canonical tool name, see provider.GetCanonicalToolName().
+	Tools map[string]ManifestTool `yaml:"tools"`
+}
+
+// ManifestTool describes the releases of one tool.
+type ManifestTool struct {
+	// URL is a text/template of the download URL with the fields .Version, .OS and .Arch.
+	// Downloads ending in .tar.gz, .tgz or .zip are extracted, anything else is a single binary.
+	URL string `yaml:"url"`
+	// OS and Arch rename the Go names of the platform (e.g. darwin and amd64) in the URL.
+	OS   map[string]string `yaml:"os"`
+	Arch map[string]string `yaml:"arch"`
+	// Binary is the name of the executable, defaults to the tool name. Single binary downloads are saved with this name.
+	Binary string `yaml:"binary"`
+	// BinDir is the directory of the executable inside the install, which is added to $PATH. Defaults to the root.
+	BinDir string `yaml:"bin_dir"`
+	// StripComponents is the number of leading path components dropped from the archive entries.
+	StripComponents int `yaml:"strip_components"`
+	// Versions are the released versions with the SHA256 checksum of their download, keyed by "<GOOS>/<GOARCH>".
+	Versions map[string]map[string]string `yaml:"versions"`
+
+	urlTemplate *template.Template
+}
+
+// LoadManifest reads and validates the manifest at path, see ParseManifest().
+func LoadManifest(path string) (Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("read manifest: %w", err)
+	}
+	manifest, err := ParseManifest(data)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("parse manifest %s: %w", path, err)
+	}
+	return manifest, nil
+}
+
+// ParseManifest decodes a manifest and checks that every tool has a valid URL template and at least one version,
+// and that every checksum is a SHA256 hex digest. Unknown keys are errors, so that typos don't go unnoticed.
+func ParseManifest(data []byte) (Manifest, error) {
+	var manifest Manifest
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&manifest); err != nil {
+		return Manifest{}, err
+	}
+
+	tools := make(map[string]ManifestTool, len(manifest.Tools))
+	var errs []error
+	names := maps.Keys(manifest.Tools)
+	slices.Sort(names)
+	for _, name := range names {
+		tool := manifest.Tools[name]
+		canonicalName := provider.GetCanonicalToolName(name)
+		if _, ok := tools[canonicalName]; ok {
+			errs = append(errs, fmt.Errorf("tools.%s: %s is declared more than once", name, canonicalName))
+			continue
+		}
+		if err := tool.validate(canonicalName); err != nil {
+			errs = append(errs, fmt.Errorf("tools.%s: %w", name, err))
+			continue
+		}
+		tools[canonicalName] = tool
+	}
+	if err := errors.Join(errs...); err != nil {
+		return Manifest{}, err
+	}
+	manifest.Tools = tools
+	return manifest, nil
+}
+
+func (t *ManifestTool) validate(toolName string) error {
+	if !isPathComponent(toolName) {
+		return fmt.Errorf("invalid tool name")
+	}
+	if t.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+	urlTemplate, err := template.New("url").Option("missingkey=error").Parse(t.URL)
+	if err != nil {
+		return fmt.Errorf("url: %w", err)
+	}
+	t.urlTemplate = urlTemplate
+	if t.Binary != "" && !isPathComponent(t.Binary) {
+		return fmt.Errorf("binary must be a file name, got %s", t.Binary)
+	}
+	if t.BinDir != "" && !filepath.IsLocal(t.BinDir) {
+		return fmt.Errorf("bin_dir must be a relative path inside the install, got %s", t.BinDir)
+	}
+	if t.StripComponents < 0 {
+		return fmt.Errorf("strip_components must not be negative")
+	}
+	if len(t.Versions) == 0 {
+		return fmt.Errorf("versions are required")
+	}
+
+	for version, checksums := range t.Versions {
+		if !isPathComponent(version) {
+			return fmt.Errorf("versions: invalid version %q", version)
+		}
+		for platform, checksum := range checksums {
+			if goos, goarch, ok := strings.Cut(platform, "/"); !ok || goos == "" || goarch == "" {
+				return fmt.Errorf("versions.%s: expected a platform like linux/amd64, got %s", version, platform)
+			}
+			if decoded, err := hex.DecodeString(checksum); err != nil || len(decoded) != 32 {
+				return fmt.Errorf("versions.%s.%s: expected a SHA256 checksum in hex, got %s", version, platform, checksum)
+			}
+		}
+	}
+	return nil
+}
+
+// ReleasedVersions returns the versions that have a download for the platform.
+func (t ManifestTool) ReleasedVersions(goos, goarch string) []string {
+	var versions []string
+	for version, checksums := range t.Versions {
+		if _, ok := checksums[goos+"/"+goarch]; ok {
+			versions = append(versions, version)
+		}
+	}
+	slices.Sort(versions)
+	return versions
+}
+
+// Checksum returns the SHA256 checksum of the download of the version for the platform.
+func (t ManifestTool) Checksum(version, goos, goarch string) (string, bool) {
+	checksum, ok := t.Versions[version][goos+"/"+goarch]
+	return strings.ToLower(checksum), ok
+}
+
+// DownloadURL renders the URL template for the version and platform.
+func (t ManifestTool) DownloadURL(version, goos, goarch string) (string, error) {
+	urlTemplate := t.urlTemplate
+	if urlTemplate == nil {
+		var err error
+		urlTemplate, err = template.New("url").Option("missingkey=error").Parse(t.URL)
+		if err != nil {
+			return "", fmt.Errorf("parse url template: %w", err)
+		}
+	}
+
+	data := struct{ Version, OS, Arch string }{Version: version, OS: goos, Arch: goarch}
+	if renamed, ok := t.OS[goos]; ok {
+		data.OS = renamed
+	}
+	if renamed, ok := t.Arch[goarch]; ok {
+		data.Arch = renamed
+	}
+	var url strings.Builder
+	if err := urlTemplate.Execute(&url, data); err != nil {
+		return "", fmt.Errorf("render url template: %w", err)
+	}
+	return url.String(), nil
+}
+
+// binaryName is the name of the executable of the tool.
+func (t ManifestTool) binaryName(toolName string) string {
+	if t.Binary != "" {
+		return t.Binary
+	}
+	return toolName
+}
+
+// isPathComponent reports whether s can be used as a single file name in the store.
+func isPathComponent(s string) bool {
+	return s != "" && s != "." && s != ".." && !strings.ContainsAny(s, `/\`)
+}
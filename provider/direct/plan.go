@@ -0,0 +1,32 @@
+package direct
+
+import (
+	"context"
+	"runtime"
+
+	"github.com/bitrise-io/toolprovider/provider"
+)
+
+// PlanInstall resolves the requested version against the manifest and the store the same way as InstallTool().
+func (d *DirectToolProvider) PlanInstall(ctx context.Context, tool provider.ToolRequest) (provider.ToolInstallPlan, error) {
+	manifestTool, err := d.manifestTool(tool)
+	if err != nil {
+		return provider.ToolInstallPlan{}, err
+	}
+	installedVersions, err := d.listInstalled(tool.ToolName)
+	if err != nil {
+		return provider.ToolInstallPlan{}, err
+	}
+	concreteVersion, isInstalled, err := resolveVersion(tool, manifestTool.ReleasedVersions(runtime.GOOS, runtime.GOARCH), installedVersions)
+	if err != nil {
+		return provider.ToolInstallPlan{}, err
+	}
+
+	return provider.ToolInstallPlan{
+		ToolName:           tool.ToolName,
+		RequestedVersion:   tool.UnparsedVersion,
+		ResolutionStrategy: tool.ResolutionStrategy,
+		ResolvedVersion:    concreteVersion,
+		IsInstalled:        isInstalled,
+	}, nil
+}
@@ -0,0 +1,43 @@
+package direct
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bitrise-io/toolprovider/provider"
+)
+
+func init() {
+	provider.Register("direct", newFromOptions)
+}
+
+// newFromOptions creates a provider with the manifest of the options, which is required. DataDir defaults to
+// ~/.bitrise/tools/direct. There's no tool manager to install, so the other options are not supported.
+func newFromOptions(opts provider.ProviderOptions) (provider.ToolProvider, error) {
+	switch {
+	case opts.InstallDir != "":
+		return nil, errors.New("install_dir is not supported by direct")
+	case opts.Version != "":
+		return nil, errors.New("version is not supported by direct")
+	case opts.ShellInit != "":
+		return nil, errors.New("shell_init is not supported by direct")
+	case opts.Manifest == "":
+		return nil, errors.New("direct needs a manifest, set tool_config.provider_options.direct.manifest")
+	}
+
+	if opts.DataDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("get user home dir: %w", err)
+		}
+		opts.DataDir = filepath.Join(home, ".bitrise", "tools", "direct")
+	}
+
+	manifest, err := LoadManifest(opts.Manifest)
+	if err != nil {
+		return nil, err
+	}
+	return NewToolProvider(opts.DataDir, manifest)
+}
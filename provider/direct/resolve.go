@@ -0,0 +1,74 @@
+package direct
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bitrise-io/toolprovider/provider"
+)
+
+// resolveVersion resolves the request to a concrete version the same way as the other providers: "", latest and
+// installed are the latest released or installed version, other versions resolve according to the strategy.
+// It reports whether the resolved version is installed.
+func resolveVersion(tool provider.ToolRequest, releasedVersions, installedVersions []string) (string, bool, error) {
+	requested := strings.TrimSpace(tool.UnparsedVersion)
+	strategy := tool.ResolutionStrategy
+	switch requested {
+	case "", "latest", "installed":
+		if strategy == provider.ResolutionStrategyStrict || strategy == provider.ResolutionStrategyAuto {
+			strategy = provider.ResolutionStrategyLatestReleased
+			if requested == "installed" {
+				strategy = provider.ResolutionStrategyLatestInstalled
+			}
+		}
+		// Every version matches the empty prefix
+		requested = ""
+	}
+
+	var resolved string
+	var found bool
+	switch strategy {
+	case provider.ResolutionStrategyStrict:
+		resolved = requested
+		found = slices.Contains(installedVersions, requested) || slices.Contains(releasedVersions, requested)
+	case provider.ResolutionStrategyAuto:
+		resolved, found = provider.ResolveAutoVersion(requested, releasedVersions, installedVersions)
+	case provider.ResolutionStrategyConstraint:
+		constraints, err := provider.ParseVersionConstraint(requested)
+		if err != nil {
+			return "", false, err
+		}
+		resolved, found = provider.LatestMatchingVersion(constraints, slices.Concat(releasedVersions, installedVersions))
+	case provider.ResolutionStrategyLatestInstalled:
+		resolved, found = latestWithPrefix(installedVersions, requested)
+		if !found {
+			// Falls back to the released versions, like with the other providers
+			resolved, found = latestWithPrefix(releasedVersions, requested)
+		}
+	case provider.ResolutionStrategyLatestReleased:
+		resolved, found = latestWithPrefix(releasedVersions, requested)
+	default:
+		return "", false, fmt.Errorf("unknown resolution strategy: %v", tool.ResolutionStrategy)
+	}
+
+	if !found {
+		return "", false, provider.ToolInstallError{
+			ToolName:         tool.ToolName,
+			RequestedVersion: tool.UnparsedVersion,
+			Cause:            fmt.Sprintf("no match for requested version %s, available versions: %s", tool.UnparsedVersion, strings.Join(provider.LogicallySortedVersions(releasedVersions), ", ")),
+			Recommendation:   "Add the version to the manifest with a checksum for this platform.",
+			Err:              provider.ErrVersionNotFound,
+		}
+	}
+	return resolved, slices.Contains(installedVersions, resolved), nil
+}
+
+func latestWithPrefix(versions []string, prefix string) (string, bool) {
+	for _, v := range provider.LogicallySortedVersions(versions) {
+		if provider.MatchesVersionPrefix(v, prefix) {
+			return v, true
+		}
+	}
+	return "", false
+}
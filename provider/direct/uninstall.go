@@ -0,0 +1,72 @@
+package direct
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/bitrise-io/toolprovider/provider"
+)
+
+// ListInstalled returns the installed versions of every tool in the store, sorted by tool name.
+func (d *DirectToolProvider) ListInstalled(ctx context.Context) ([]provider.InstalledVersion, error) {
+	toolNames, err := readDirNames(d.installsDir())
+	if err != nil {
+		return nil, err
+	}
+
+	installed := []provider.InstalledVersion{}
+	for _, toolName := range toolNames {
+		versions, err := d.listInstalled(toolName)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range versions {
+			installed = append(installed, provider.InstalledVersion{
+				ToolName: toolName,
+				Version:  v,
+				Path:     d.installDir(toolName, v),
+			})
+		}
+	}
+	return installed, nil
+}
+
+func (d *DirectToolProvider) UninstallTool(ctx context.Context, toolName string, version string) error {
+	if !isPathComponent(toolName) || !isPathComponent(version) {
+		return fmt.Errorf("invalid tool version: %s %s", toolName, version)
+	}
+	installDir := d.installDir(toolName, version)
+	if _, err := os.Stat(installDir); err != nil {
+		return fmt.Errorf("%s %s is not installed: %w", toolName, version, err)
+	}
+	if err := os.RemoveAll(installDir); err != nil {
+		return fmt.Errorf("remove %s: %w", installDir, err)
+	}
+	return nil
+}
+
+// listInstalled returns the installed versions of the tool, sorted.
+func (d *DirectToolProvider) listInstalled(toolName string) ([]string, error) {
+	return readDirNames(d.installDir(toolName, ""))
+}
+
+// readDirNames returns the names of the subdirectories of dir, sorted. A missing dir has none.
+func readDirNames(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list %s: %w", dir, err)
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
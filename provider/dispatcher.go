@@ -0,0 +1,177 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// ProviderFactory creates a tool provider by its ID (e.g. "asdf" or "mise"), see Registry.ProviderFactory().
+type ProviderFactory func(providerID string) (ToolProvider, error)
+
+// Dispatcher routes tool requests to their providers when different tools are installed by different providers.
+// Every provider is created and bootstrapped at most once, on first use. It's safe for concurrent use.
+type Dispatcher struct {
+	defaultProviderID string
+	newProvider       ProviderFactory
+
+	mu        sync.Mutex
+	providers map[string]ToolProvider
+	chains    map[string]*FallbackProvider
+	// bootstraps makes the callers of a provider that is being created wait for each other, without blocking other providers.
+	bootstraps map[string]*sync.Mutex
+
+	locksMu sync.Mutex
+	locks   map[string]*sync.Mutex
+}
+
+func NewDispatcher(defaultProviderID string, newProvider ProviderFactory) *Dispatcher {
+	return &Dispatcher{
+		defaultProviderID: defaultProviderID,
+		newProvider:       newProvider,
+		providers:         map[string]ToolProvider{},
+		chains:            map[string]*FallbackProvider{},
+		bootstraps:        map[string]*sync.Mutex{},
+		locks:             map[string]*sync.Mutex{},
+	}
+}
+
+// ProviderFor returns the provider responsible for the tool, see ToolRequest.ProviderID.
+// A fallback chain is served by a FallbackProvider, whose members are created when it needs them.
+func (d *Dispatcher) ProviderFor(ctx context.Context, tool ToolRequest) (ToolProvider, error) {
+	providerID := d.providerID(tool)
+
+	d.mu.Lock()
+	chain := FallbackChain(providerID)
+	if len(chain) > 1 {
+		defer d.mu.Unlock()
+		providerID = FallbackChainID(chain)
+		if p, ok := d.chains[providerID]; ok {
+			return p, nil
+		}
+		p := NewFallbackProvider(chain, d)
+		d.chains[providerID] = p
+		return p, nil
+	}
+	if len(chain) == 1 {
+		providerID = chain[0]
+	}
+	if p, ok := d.providers[providerID]; ok {
+		d.mu.Unlock()
+		return p, nil
+	}
+	bootstrap, ok := d.bootstraps[providerID]
+	if !ok {
+		bootstrap = &sync.Mutex{}
+		d.bootstraps[providerID] = bootstrap
+	}
+	d.mu.Unlock()
+
+	return d.bootstrapProvider(ctx, providerID, bootstrap)
+}
+
+// bootstrapProvider creates and bootstraps the provider, unless another caller did it while this one was waiting.
+// A failed bootstrap is retried by the next caller.
+func (d *Dispatcher) bootstrapProvider(ctx context.Context, providerID string, bootstrap *sync.Mutex) (ToolProvider, error) {
+	bootstrap.Lock()
+	defer bootstrap.Unlock()
+
+	d.mu.Lock()
+	p, ok := d.providers[providerID]
+	d.mu.Unlock()
+	if ok {
+		return p, nil
+	}
+
+	p, err := d.newProvider(providerID)
+	if err != nil {
+		return nil, fmt.Errorf("create tool provider %s: %w", providerID, err)
+	}
+	err = p.Bootstrap(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("bootstrap tool provider %s: %w", providerID, err)
+	}
+
+	d.mu.Lock()
+	d.providers[providerID] = p
+	d.mu.Unlock()
+	return p, nil
+}
+
+// ProvidersFor returns every provider that may serve the tool: the members of its fallback chain in order,
+// or its only provider. Unlike ProviderFor(), it creates the members of a chain right away.
+func (d *Dispatcher) ProvidersFor(ctx context.Context, tool ToolRequest) ([]ToolProvider, error) {
+	var providers []ToolProvider
+	for _, providerID := range FallbackChain(d.providerID(tool)) {
+		p, err := d.ProviderFor(ctx, ToolRequest{ProviderID: providerID})
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, p)
+	}
+	return providers, nil
+}
+
+// Lock makes the calls of a provider that doesn't support concurrent installs (see ToolProvider.SupportsConcurrentInstalls())
+// wait for each other. Callers lock the provider before InstallTool and PlanInstall, and call the returned unlock function
+// when they are done. It's a no-op for providers that support concurrent installs.
+func (d *Dispatcher) Lock(p ToolProvider) (unlock func()) {
+	if p.SupportsConcurrentInstalls() {
+		return func() {}
+	}
+
+	d.locksMu.Lock()
+	lock, ok := d.locks[p.ID()]
+	if !ok {
+		lock = &sync.Mutex{}
+		d.locks[p.ID()] = lock
+	}
+	d.locksMu.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+func (d *Dispatcher) providerID(tool ToolRequest) string {
+	if tool.ProviderID == "" {
+		return d.defaultProviderID
+	}
+	return tool.ProviderID
+}
+
+// Providers returns the providers created so far, sorted by ID. Fallback chains are not included, only their members.
+func (d *Dispatcher) Providers() []ToolProvider {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	ids := make([]string, 0, len(d.providers))
+	for id := range d.providers {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	providers := make([]ToolProvider, 0, len(ids))
+	for _, id := range ids {
+		providers = append(providers, d.providers[id])
+	}
+	return providers
+}
+
+// MergeActivations combines the activations of multiple tools into one.
+// Paths keep their order without duplicates, and env vars of later activations override earlier ones.
+func MergeActivations(activations ...EnvironmentActivation) EnvironmentActivation {
+	merged := EnvironmentActivation{
+		ContributedEnvVars: map[string]string{},
+		ContributedPaths:   []string{},
+	}
+	for _, activation := range activations {
+		maps.Copy(merged.ContributedEnvVars, activation.ContributedEnvVars)
+		for _, p := range activation.ContributedPaths {
+			if !slices.Contains(merged.ContributedPaths, p) {
+				merged.ContributedPaths = append(merged.ContributedPaths, p)
+			}
+		}
+	}
+	return merged
+}
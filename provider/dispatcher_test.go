@@ -0,0 +1,163 @@
+package provider_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/bitrise-io/toolprovider/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubProvider struct {
+	id             string
+	bootstrapCount *int
+}
+
+func (p stubProvider) ID() string { return p.id }
+
+func (p stubProvider) Version() (string, error) { return "1.0.0", nil }
+
+func (p stubProvider) SupportsConcurrentInstalls() bool { return true }
+
+func (p stubProvider) IsInstalledNative(ctx context.Context, tool provider.ToolRequest) (provider.NativeTool, bool, error) {
+	return provider.NativeTool{}, false, nil
+}
+
+func (p stubProvider) ListInstalled(ctx context.Context) ([]provider.InstalledVersion, error) {
+	return nil, nil
+}
+
+func (p stubProvider) UninstallTool(ctx context.Context, toolName string, version string) error {
+	return nil
+}
+
+func (p stubProvider) Bootstrap(ctx context.Context) error {
+	*p.bootstrapCount++
+	return nil
+}
+
+func (p stubProvider) InstallTool(ctx context.Context, tool provider.ToolRequest) (provider.ToolInstallResult, error) {
+	return provider.ToolInstallResult{ToolName: tool.ToolName, ConcreteVersion: tool.UnparsedVersion}, nil
+}
+
+func (p stubProvider) PlanInstall(ctx context.Context, tool provider.ToolRequest) (provider.ToolInstallPlan, error) {
+	return provider.ToolInstallPlan{ToolName: tool.ToolName, ResolvedVersion: tool.UnparsedVersion}, nil
+}
+
+func (p stubProvider) ActivateEnv(ctx context.Context, result provider.ToolInstallResult) (provider.EnvironmentActivation, error) {
+	return provider.EnvironmentActivation{}, nil
+}
+
+func TestDispatcher(t *testing.T) {
+	bootstrapCounts := map[string]*int{}
+	createCount := 0
+	dispatcher := provider.NewDispatcher("asdf", func(providerID string) (provider.ToolProvider, error) {
+		if providerID == "unknown" {
+			return nil, errors.New("unsupported tool provider")
+		}
+		createCount++
+		bootstrapCounts[providerID] = new(int)
+		return stubProvider{id: providerID, bootstrapCount: bootstrapCounts[providerID]}, nil
+	})
+
+	p, err := dispatcher.ProviderFor(context.Background(), provider.ToolRequest{ToolName: "flutter"})
+	require.NoError(t, err)
+	require.Equal(t, "asdf", p.ID())
+
+	p, err = dispatcher.ProviderFor(context.Background(), provider.ToolRequest{ToolName: "java", ProviderID: "mise"})
+	require.NoError(t, err)
+	require.Equal(t, "mise", p.ID())
+
+	p, err = dispatcher.ProviderFor(context.Background(), provider.ToolRequest{ToolName: "ruby", ProviderID: "asdf"})
+	require.NoError(t, err)
+	require.Equal(t, "asdf", p.ID())
+
+	_, err = dispatcher.ProviderFor(context.Background(), provider.ToolRequest{ToolName: "go", ProviderID: "unknown"})
+	require.Error(t, err)
+
+	require.Equal(t, 2, createCount)
+	require.Equal(t, 1, *bootstrapCounts["asdf"])
+	require.Equal(t, 1, *bootstrapCounts["mise"])
+}
+
+// slowProvider bootstraps when release is closed, and signals started when it begins.
+type slowProvider struct {
+	stubProvider
+	started chan<- struct{}
+	release <-chan struct{}
+}
+
+func (p slowProvider) Bootstrap(ctx context.Context) error {
+	p.started <- struct{}{}
+	<-p.release
+	return p.stubProvider.Bootstrap(ctx)
+}
+
+func TestDispatcherSlowBootstrap(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	var mu sync.Mutex
+	bootstrapCounts := map[string]*int{}
+	dispatcher := provider.NewDispatcher("asdf", func(providerID string) (provider.ToolProvider, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		bootstrapCounts[providerID] = new(int)
+		p := stubProvider{id: providerID, bootstrapCount: bootstrapCounts[providerID]}
+		if providerID == "mise" {
+			return slowProvider{stubProvider: p, started: started, release: release}, nil
+		}
+		return p, nil
+	})
+
+	var wg sync.WaitGroup
+	miseProviders := make([]provider.ToolProvider, 2)
+	for i := range miseProviders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p, err := dispatcher.ProviderFor(context.Background(), provider.ToolRequest{ToolName: "java", ProviderID: "mise"})
+			assert.NoError(t, err)
+			miseProviders[i] = p
+		}()
+	}
+	<-started
+
+	// Other providers don't wait for the bootstrap of mise
+	p, err := dispatcher.ProviderFor(context.Background(), provider.ToolRequest{ToolName: "ruby"})
+	require.NoError(t, err)
+	require.Equal(t, "asdf", p.ID())
+
+	close(release)
+	wg.Wait()
+	for _, p := range miseProviders {
+		require.Equal(t, "mise", p.ID())
+	}
+	require.Equal(t, 1, *bootstrapCounts["mise"])
+}
+
+func TestMergeActivations(t *testing.T) {
+	merged := provider.MergeActivations(
+		provider.EnvironmentActivation{
+			ContributedEnvVars: map[string]string{"ASDF_FLUTTER_VERSION": "3.32.5-stable"},
+		},
+		provider.EnvironmentActivation{
+			ContributedEnvVars: map[string]string{"JAVA_HOME": "/opt/java/21"},
+			ContributedPaths:   []string{"/opt/java/21/bin", "/opt/shared/bin"},
+		},
+		provider.EnvironmentActivation{
+			ContributedEnvVars: map[string]string{"JAVA_HOME": "/opt/java/17"},
+			ContributedPaths:   []string{"/opt/node/bin", "/opt/shared/bin"},
+		},
+	)
+
+	require.Equal(t, provider.EnvironmentActivation{
+		ContributedEnvVars: map[string]string{
+			"ASDF_FLUTTER_VERSION": "3.32.5-stable",
+			"JAVA_HOME":            "/opt/java/17",
+		},
+		ContributedPaths: []string{"/opt/java/21/bin", "/opt/shared/bin", "/opt/node/bin"},
+	}, merged)
+}
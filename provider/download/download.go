@@ -0,0 +1,76 @@
+// Package download fetches tool releases over HTTP and unpacks them without letting archive entries escape
+// the target directory.
+package download
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+// ProgressStep is how often Fetch reports the download progress.
+const ProgressStep = 4 * 1024 * 1024
+
+// Fetch downloads url into w, retrying transient failures. onProgress (if not nil) is called with the downloaded
+// and the total size (-1 if unknown) every ProgressStep bytes and once more at the end.
+func Fetch(ctx context.Context, url string, w io.Writer, onProgress func(read, total int64)) error {
+	req, err := retryablehttp.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("create request for %s: %w", url, err)
+	}
+	client := retryablehttp.NewClient()
+	client.Logger = nil
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("download %s: %w", url, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download %s: received status code %d", url, resp.StatusCode)
+	}
+
+	body := &progressReader{reader: resp.Body, total: resp.ContentLength, onProgress: onProgress}
+	_, err = io.Copy(w, body)
+	if err != nil {
+		return fmt.Errorf("download %s: %w", url, err)
+	}
+	body.finish()
+	return nil
+}
+
+type progressReader struct {
+	reader       io.Reader
+	read         int64
+	total        int64
+	lastReported int64
+	onProgress   func(read, total int64)
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	if r.read-r.lastReported >= ProgressStep {
+		r.report()
+	}
+	return n, err
+}
+
+// finish reports the final size, unless it was just reported.
+func (r *progressReader) finish() {
+	if r.read > r.lastReported || r.read == 0 {
+		r.report()
+	}
+}
+
+func (r *progressReader) report() {
+	r.lastReported = r.read
+	if r.onProgress != nil {
+		r.onProgress(r.read, r.total)
+	}
+}
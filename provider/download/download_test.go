@@ -0,0 +1,172 @@
+package download_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bitrise-io/toolprovider/provider/download"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type archiveEntry struct {
+	name    string
+	content string
+	dir     bool
+	symlink bool
+}
+
+var traversalEntries = []archiveEntry{
+	{name: "tool-1.0/", dir: true},
+	{name: "tool-1.0/bin/tool", content: "#!/bin/sh\n"},
+	{name: "tool-1.0/../../escaped", content: "outside"},
+	{name: "/tool-1.0/abs", content: "absolute"},
+	{name: "tool-1.0/link", symlink: true},
+	{name: "top-level-file", content: "stripped away"},
+}
+
+func TestExtractTarGz(t *testing.T) {
+	targetDir := filepath.Join(t.TempDir(), "a", "install")
+	extracted, err := download.ExtractTarGz(bytes.NewReader(tarGz(t, traversalEntries)), targetDir, 1)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{filepath.Join(targetDir, "bin", "tool"), filepath.Join(targetDir, "abs")}, extracted)
+	assertInstall(t, targetDir)
+}
+
+func TestExtractZip(t *testing.T) {
+	targetDir := filepath.Join(t.TempDir(), "a", "install")
+	archivePath := filepath.Join(t.TempDir(), "tool.zip")
+	require.NoError(t, os.WriteFile(archivePath, zipArchive(t, traversalEntries), 0644))
+
+	extracted, err := download.ExtractZip(archivePath, targetDir, 1)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{filepath.Join(targetDir, "bin", "tool"), filepath.Join(targetDir, "abs")}, extracted)
+	assertInstall(t, targetDir)
+}
+
+func TestUnpackBinary(t *testing.T) {
+	binaryPath := filepath.Join(t.TempDir(), "download")
+	require.NoError(t, os.WriteFile(binaryPath, []byte("#!/bin/sh\n"), 0600))
+	targetDir := t.TempDir()
+
+	extracted, err := download.Unpack(download.FormatBinary, binaryPath, targetDir, 0, "tool")
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(targetDir, "tool")}, extracted)
+	info, err := os.Stat(filepath.Join(targetDir, "tool"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+	_, err = download.Unpack(download.FormatBinary, binaryPath, targetDir, 0, "../tool")
+	assert.EqualError(t, err, `invalid binary name: "../tool"`)
+}
+
+func TestFormatOf(t *testing.T) {
+	for name, expected := range map[string]download.Format{
+		"https://example.com/tool-1.0-linux.tar.gz":        download.FormatTarGz,
+		"https://example.com/tool-1.0-linux.TGZ":           download.FormatTarGz,
+		"https://example.com/tool-1.0-windows.zip?raw=1":   download.FormatZip,
+		"https://example.com/tool-1.0-linux-amd64":         download.FormatBinary,
+		"https://example.com/tool-1.0-linux-amd64.tar.bz2": download.FormatBinary,
+	} {
+		assert.Equal(t, expected, download.FormatOf(name), name)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	content := strings.Repeat("x", download.ProgressStep+10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/tool" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		_, _ = w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	var progress []int64
+	err := download.Fetch(context.Background(), server.URL+"/tool", &out, func(read, total int64) {
+		assert.Equal(t, int64(len(content)), total)
+		progress = append(progress, read)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, content, out.String())
+	require.Len(t, progress, 2)
+	assert.GreaterOrEqual(t, progress[0], int64(download.ProgressStep))
+	assert.Equal(t, int64(len(content)), progress[1])
+
+	err = download.Fetch(context.Background(), server.URL+"/missing", &out, nil)
+	assert.EqualError(t, err, "download "+server.URL+"/missing: received status code 404")
+}
+
+// assertInstall checks that only the safe entries of traversalEntries were extracted.
+func assertInstall(t *testing.T, targetDir string) {
+	content, err := os.ReadFile(filepath.Join(targetDir, "bin", "tool"))
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\n", string(content))
+	info, err := os.Stat(filepath.Join(targetDir, "bin", "tool"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+	assert.NoFileExists(t, filepath.Join(targetDir, "..", "..", "escaped"))
+	assert.NoFileExists(t, filepath.Join(targetDir, "link"))
+	assert.NoFileExists(t, filepath.Join(targetDir, "top-level-file"))
+}
+
+func tarGz(t *testing.T, entries []archiveEntry) []byte {
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Mode: 0755, Size: int64(len(entry.content)), Typeflag: tar.TypeReg}
+		switch {
+		case entry.dir:
+			header.Typeflag = tar.TypeDir
+		case entry.symlink:
+			header.Typeflag = tar.TypeSymlink
+			header.Linkname = "/etc/passwd"
+		}
+		require.NoError(t, tarWriter.WriteHeader(header))
+		_, err := tarWriter.Write([]byte(entry.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tarWriter.Close())
+	require.NoError(t, gzipWriter.Close())
+	return buf.Bytes()
+}
+
+func zipArchive(t *testing.T, entries []archiveEntry) []byte {
+	var buf bytes.Buffer
+	zipWriter := zip.NewWriter(&buf)
+	for _, entry := range entries {
+		header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate}
+		switch {
+		case entry.dir:
+			header.SetMode(os.ModeDir | 0755)
+		case entry.symlink:
+			header.SetMode(os.ModeSymlink | 0777)
+			entry.content = "/etc/passwd"
+		default:
+			header.SetMode(0755)
+		}
+		w, err := zipWriter.CreateHeader(header)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(entry.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zipWriter.Close())
+	return buf.Bytes()
+}
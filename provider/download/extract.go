@@ -0,0 +1,218 @@
+package download
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Format is the packaging of a downloaded release.
+type Format string
+
+const (
+	FormatTarGz  Format = "tar.gz"
+	FormatZip    Format = "zip"
+	FormatBinary Format = "binary"
+)
+
+// FormatOf detects the format from the file name of a URL or path. Anything that's not a known archive
+// is treated as a single binary.
+func FormatOf(name string) Format {
+	name = strings.ToLower(name)
+	if i := strings.IndexAny(name, "?#"); i >= 0 {
+		name = name[:i]
+	}
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return FormatTarGz
+	case strings.HasSuffix(name, ".zip"):
+		return FormatZip
+	default:
+		return FormatBinary
+	}
+}
+
+// Unpack unpacks the downloaded file at path into targetDir according to its format. Archives are extracted
+// (see ExtractTarGz() and ExtractZip()), a single binary is copied to targetDir/binaryName and made executable.
+// It returns the paths of the created files.
+func Unpack(format Format, path string, targetDir string, stripComponents int, binaryName string) ([]string, error) {
+	switch format {
+	case FormatTarGz:
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("open %s: %w", path, err)
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		return ExtractTarGz(f, targetDir, stripComponents)
+	case FormatZip:
+		return ExtractZip(path, targetDir, stripComponents)
+	case FormatBinary:
+		targetPath, ok := safeTargetPath(targetDir, binaryName, 0)
+		if !ok || binaryName == "" {
+			return nil, fmt.Errorf("invalid binary name: %q", binaryName)
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("open %s: %w", path, err)
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		if err := writeFile(targetPath, f, 0755); err != nil {
+			return nil, err
+		}
+		return []string{targetPath}, nil
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// ExtractTarGz extracts a .tar.gz stream into targetDir, dropping the first stripComponents path components
+// of every entry, like tar --strip-components. Directories and regular files are extracted, other entries
+// (e.g. symlinks) and entries that would end up outside targetDir are skipped. It returns the paths of the
+// extracted files.
+func ExtractTarGz(r io.Reader, targetDir string, stripComponents int) ([]string, error) {
+	gzipReader, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("create gzip reader: %w", err)
+	}
+	defer func() {
+		_ = gzipReader.Close()
+	}()
+
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return nil, fmt.Errorf("create directory for %s: %w", targetDir, err)
+	}
+
+	tarReader := tar.NewReader(gzipReader)
+	var extracted []string
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read tar header: %w", err)
+		}
+
+		targetPath, ok := safeTargetPath(targetDir, header.Name, stripComponents)
+		if !ok {
+			continue
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(targetPath, 0755); err != nil {
+				return nil, fmt.Errorf("create directory %s: %w", targetPath, err)
+			}
+		case tar.TypeReg:
+			if err := writeFile(targetPath, tarReader, os.FileMode(header.Mode).Perm()); err != nil {
+				return nil, err
+			}
+			extracted = append(extracted, targetPath)
+		default:
+			// Skip other file types (symlinks, etc.)
+		}
+	}
+	return extracted, nil
+}
+
+// ExtractZip extracts the zip archive at path into targetDir the same way as ExtractTarGz().
+func ExtractZip(path string, targetDir string, stripComponents int) ([]string, error) {
+	zipReader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("open zip archive %s: %w", path, err)
+	}
+	defer func() {
+		_ = zipReader.Close()
+	}()
+
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return nil, fmt.Errorf("create directory for %s: %w", targetDir, err)
+	}
+
+	var extracted []string
+	for _, file := range zipReader.File {
+		targetPath, ok := safeTargetPath(targetDir, file.Name, stripComponents)
+		if !ok {
+			continue
+		}
+		mode := file.Mode()
+		switch {
+		case mode.IsDir():
+			if err := os.MkdirAll(targetPath, 0755); err != nil {
+				return nil, fmt.Errorf("create directory %s: %w", targetPath, err)
+			}
+		case mode.IsRegular():
+			if err := extractZipFile(file, targetPath); err != nil {
+				return nil, err
+			}
+			extracted = append(extracted, targetPath)
+		default:
+			// Skip other file types (symlinks, etc.)
+		}
+	}
+	return extracted, nil
+}
+
+func extractZipFile(file *zip.File, targetPath string) error {
+	r, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("open %s in zip archive: %w", file.Name, err)
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+	return writeFile(targetPath, r, file.Mode().Perm())
+}
+
+func writeFile(targetPath string, r io.Reader, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+		return fmt.Errorf("create parent directory for %s: %w", targetPath, err)
+	}
+
+	outFile, err := os.OpenFile(targetPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return fmt.Errorf("create file %s: %w", targetPath, err)
+	}
+	defer func() {
+		_ = outFile.Close()
+	}()
+
+	if _, err := io.Copy(outFile, r); err != nil {
+		return fmt.Errorf("extract file %s: %w", targetPath, err)
+	}
+	// The umask may have masked the permissions of the archive entry
+	if err := os.Chmod(targetPath, perm); err != nil {
+		return fmt.Errorf("set permissions of %s: %w", targetPath, err)
+	}
+	return nil
+}
+
+// safeTargetPath returns where an archive entry is extracted, after stripping the leading path components.
+// It returns false if nothing is left of the name or if the entry would end up outside targetDir.
+func safeTargetPath(targetDir string, name string, stripComponents int) (string, bool) {
+	var parts []string
+	for _, part := range strings.Split(filepath.ToSlash(name), "/") {
+		if part != "" && part != "." {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) <= stripComponents {
+		return "", false
+	}
+
+	targetDir = filepath.Clean(targetDir)
+	targetPath := filepath.Join(targetDir, filepath.Join(parts[stripComponents:]...))
+	rel, err := filepath.Rel(targetDir, targetPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return targetPath, true
+}
@@ -0,0 +1,103 @@
+package provider
+
+import (
+	"context"
+	"time"
+)
+
+// EventKind is the kind of a progress Event.
+type EventKind int
+
+const (
+	EventResolveStarted EventKind = iota
+	EventResolveFinished
+	EventPluginAdded
+	EventDownloadProgress
+	EventOutput
+	EventActivated
+	// EventProviderFallback is emitted when a provider of a fallback chain fails and the next one is tried.
+	EventProviderFallback
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventResolveStarted:
+		return "resolve_started"
+	case EventResolveFinished:
+		return "resolve_finished"
+	case EventPluginAdded:
+		return "plugin_added"
+	case EventDownloadProgress:
+		return "download_progress"
+	case EventOutput:
+		return "output"
+	case EventActivated:
+		return "activated"
+	case EventProviderFallback:
+		return "provider_fallback"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is a progress update emitted by a provider while it works, see Emit().
+type Event struct {
+	Kind       EventKind
+	Time       time.Time
+	ProviderID string
+	// ToolName is empty for events that are not about a single tool, e.g. the download of the provider itself.
+	ToolName string
+	// Version is the requested version for EventResolveStarted and the resolved or activated version
+	// for EventResolveFinished and EventActivated.
+	Version string
+	// Message is the output line for EventOutput, the plugin for EventPluginAdded, the URL for EventDownloadProgress
+	// and the error of the failed provider for EventProviderFallback.
+	Message string
+	// Bytes is the downloaded size so far for EventDownloadProgress, zero when the download starts.
+	// TotalBytes is -1 if the size is unknown.
+	Bytes      int64
+	TotalBytes int64
+}
+
+// Observer receives the events of the providers. OnEvent may be called from several goroutines at the same time
+// and should return quickly, because the provider waits for it.
+type Observer interface {
+	OnEvent(Event)
+}
+
+// ObserverFunc adapts a function to the Observer interface.
+type ObserverFunc func(Event)
+
+func (f ObserverFunc) OnEvent(e Event) {
+	f(e)
+}
+
+type observerKey struct{}
+
+// WithObserver returns a context whose provider calls emit their events to observer.
+func WithObserver(ctx context.Context, observer Observer) context.Context {
+	return context.WithValue(ctx, observerKey{}, observer)
+}
+
+// Emit sends the event to the observer of ctx (see WithObserver()), if there is one. Time is set if it's zero.
+func Emit(ctx context.Context, e Event) {
+	observer, ok := ctx.Value(observerKey{}).(Observer)
+	if !ok {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	observer.OnEvent(e)
+}
+
+// OutputLineEmitter returns a function that emits each line of command output as an EventOutput,
+// or nil if ctx has no observer, so that callers can skip streaming the output.
+func OutputLineEmitter(ctx context.Context, providerID string, toolName string) func(line string) {
+	if _, ok := ctx.Value(observerKey{}).(Observer); !ok {
+		return nil
+	}
+	return func(line string) {
+		Emit(ctx, Event{Kind: EventOutput, ProviderID: providerID, ToolName: toolName, Message: line})
+	}
+}
@@ -0,0 +1,194 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// fallbackSeparator separates the provider IDs of a fallback chain in a single provider ID, e.g. "mise,asdf".
+const fallbackSeparator = ","
+
+// FallbackChain returns the provider IDs of a fallback chain in order. A single provider ID is a chain of one.
+func FallbackChain(providerID string) []string {
+	var chain []string
+	for _, id := range strings.Split(providerID, fallbackSeparator) {
+		if id = strings.TrimSpace(id); id != "" {
+			chain = append(chain, id)
+		}
+	}
+	return chain
+}
+
+// FallbackChainID is the inverse of FallbackChain().
+func FallbackChainID(providerIDs []string) string {
+	return strings.Join(providerIDs, fallbackSeparator)
+}
+
+// IsFallbackError reports whether another provider may succeed where a provider failed with err: the provider
+// doesn't know the tool or has no matching version. Other failures (e.g. a failed compilation) would likely happen
+// with any provider, and cancellation should stop the install, so they are not.
+func IsFallbackError(err error) bool {
+	return errors.Is(err, ErrToolNotFound) || errors.Is(err, ErrVersionNotFound)
+}
+
+// FallbackProvider tries the providers of a fallback chain for each tool in order, and moves on to the next one
+// only on the errors of IsFallbackError(). The provider that served a tool is recorded in ToolInstallResult.ProviderID,
+// and the tool is activated by the same provider.
+//
+// The members come from the Dispatcher, so they are shared with the tools that use them directly, they are created
+// and bootstrapped on first use (a provider that's never needed doesn't have to be available), and the ones that don't
+// support concurrent installs are locked for the duration of each call (see Dispatcher.Lock()).
+type FallbackProvider struct {
+	providerIDs []string
+	dispatcher  *Dispatcher
+}
+
+func NewFallbackProvider(providerIDs []string, dispatcher *Dispatcher) *FallbackProvider {
+	return &FallbackProvider{providerIDs: providerIDs, dispatcher: dispatcher}
+}
+
+func (f *FallbackProvider) ID() string {
+	return FallbackChainID(f.providerIDs)
+}
+
+// Version is empty, because a chain has no version of its own. The members report their versions separately.
+func (f *FallbackProvider) Version() (string, error) {
+	return "", nil
+}
+
+// Bootstrap does nothing, because the members are bootstrapped on first use.
+func (f *FallbackProvider) Bootstrap(ctx context.Context) error {
+	return nil
+}
+
+// SupportsConcurrentInstalls is true, because the members are locked by the chain itself when needed.
+func (f *FallbackProvider) SupportsConcurrentInstalls() bool {
+	return true
+}
+
+func (f *FallbackProvider) InstallTool(ctx context.Context, tool ToolRequest) (ToolInstallResult, error) {
+	var result ToolInstallResult
+	err := f.tryInOrder(ctx, tool, func(member ToolProvider) error {
+		var err error
+		result, err = member.InstallTool(ctx, tool)
+		result.ProviderID = member.ID()
+		return err
+	})
+	return result, err
+}
+
+func (f *FallbackProvider) PlanInstall(ctx context.Context, tool ToolRequest) (ToolInstallPlan, error) {
+	var plan ToolInstallPlan
+	err := f.tryInOrder(ctx, tool, func(member ToolProvider) error {
+		var err error
+		plan, err = member.PlanInstall(ctx, tool)
+		plan.ProviderID = member.ID()
+		return err
+	})
+	return plan, err
+}
+
+// ActivateEnv activates the tool with the provider that installed it. Native tools are activated by the first provider.
+func (f *FallbackProvider) ActivateEnv(ctx context.Context, result ToolInstallResult) (EnvironmentActivation, error) {
+	providerID := result.ProviderID
+	if result.NativeTool != nil {
+		providerID = f.providerIDs[0]
+	}
+	if !slices.Contains(f.providerIDs, providerID) {
+		return EnvironmentActivation{}, fmt.Errorf("%s %s was not installed by any of %s", result.ToolName, result.ConcreteVersion, f.ID())
+	}
+
+	member, err := f.member(ctx, providerID)
+	if err != nil {
+		return EnvironmentActivation{}, err
+	}
+	return member.ActivateEnv(ctx, result)
+}
+
+// IsInstalledNative asks the first provider, because native installs are found the same way by every provider.
+func (f *FallbackProvider) IsInstalledNative(ctx context.Context, tool ToolRequest) (NativeTool, bool, error) {
+	member, err := f.member(ctx, f.providerIDs[0])
+	if err != nil {
+		return NativeTool{}, false, err
+	}
+	return member.IsInstalledNative(ctx, tool)
+}
+
+// ListInstalled returns the installed versions of every member, in chain order.
+func (f *FallbackProvider) ListInstalled(ctx context.Context) ([]InstalledVersion, error) {
+	var installed []InstalledVersion
+	for _, providerID := range f.providerIDs {
+		member, err := f.member(ctx, providerID)
+		if err != nil {
+			return nil, err
+		}
+		versions, err := member.ListInstalled(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("list installed tools of %s: %w", providerID, err)
+		}
+		installed = append(installed, versions...)
+	}
+	return installed, nil
+}
+
+// UninstallTool removes the version from the first member that has it installed.
+func (f *FallbackProvider) UninstallTool(ctx context.Context, toolName string, version string) error {
+	for _, providerID := range f.providerIDs {
+		member, err := f.member(ctx, providerID)
+		if err != nil {
+			return err
+		}
+		installed, err := member.ListInstalled(ctx)
+		if err != nil {
+			return fmt.Errorf("list installed tools of %s: %w", providerID, err)
+		}
+		if slices.ContainsFunc(installed, func(v InstalledVersion) bool { return v.ToolName == toolName && v.Version == version }) {
+			return member.UninstallTool(ctx, toolName, version)
+		}
+	}
+	return fmt.Errorf("%s %s is not installed by any of %s", toolName, version, f.ID())
+}
+
+// tryInOrder calls try with each member until one succeeds or fails with an error that's not a fallback error.
+// If every member fails, the errors of all of them are returned.
+func (f *FallbackProvider) tryInOrder(ctx context.Context, tool ToolRequest, try func(member ToolProvider) error) error {
+	var errs []error
+	for i, providerID := range f.providerIDs {
+		member, err := f.member(ctx, providerID)
+		if err != nil {
+			return err
+		}
+
+		unlock := f.dispatcher.Lock(member)
+		err = try(member)
+		unlock()
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil || !IsFallbackError(err) {
+			return err
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", providerID, err))
+		if i < len(f.providerIDs)-1 {
+			Emit(ctx, Event{Kind: EventProviderFallback, ProviderID: providerID, ToolName: tool.ToolName, Version: tool.UnparsedVersion, Message: fallbackReason(err)})
+		}
+	}
+	return fmt.Errorf("none of the providers %s could install %s %s:\n%w", f.ID(), tool.ToolName, tool.UnparsedVersion, errors.Join(errs...))
+}
+
+// fallbackReason is the short form of err for EventProviderFallback: the cause of a ToolInstallError, if any.
+func fallbackReason(err error) string {
+	var installErr ToolInstallError
+	if errors.As(err, &installErr) && installErr.Cause != "" {
+		return installErr.Cause
+	}
+	return err.Error()
+}
+
+func (f *FallbackProvider) member(ctx context.Context, providerID string) (ToolProvider, error) {
+	return f.dispatcher.ProviderFor(ctx, ToolRequest{ProviderID: providerID})
+}
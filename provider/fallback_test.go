@@ -0,0 +1,148 @@
+package provider_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/toolprovider/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fallbackMember installs every tool except the ones in errs, and records the calls it receives.
+type fallbackMember struct {
+	stubProvider
+	errs  map[string]error
+	calls *[]string
+}
+
+func (p fallbackMember) InstallTool(ctx context.Context, tool provider.ToolRequest) (provider.ToolInstallResult, error) {
+	*p.calls = append(*p.calls, p.id+" install "+tool.ToolName)
+	if err := p.errs[tool.ToolName]; err != nil {
+		return provider.ToolInstallResult{}, err
+	}
+	return p.stubProvider.InstallTool(ctx, tool)
+}
+
+func (p fallbackMember) PlanInstall(ctx context.Context, tool provider.ToolRequest) (provider.ToolInstallPlan, error) {
+	*p.calls = append(*p.calls, p.id+" plan "+tool.ToolName)
+	if err := p.errs[tool.ToolName]; err != nil {
+		return provider.ToolInstallPlan{}, err
+	}
+	return p.stubProvider.PlanInstall(ctx, tool)
+}
+
+func (p fallbackMember) ActivateEnv(ctx context.Context, result provider.ToolInstallResult) (provider.EnvironmentActivation, error) {
+	*p.calls = append(*p.calls, p.id+" activate "+result.ToolName)
+	return provider.EnvironmentActivation{ContributedPaths: []string{"/" + p.id + "/" + result.ToolName}}, nil
+}
+
+func newFallbackDispatcher(calls *[]string, created *[]string) *provider.Dispatcher {
+	errs := map[string]map[string]error{
+		"direct": {
+			"node":  provider.ToolInstallError{ToolName: "node", Cause: "node is not in the manifest", Err: provider.ErrToolNotFound},
+			"ruby":  fmt.Errorf("resolve version: %w", provider.ErrVersionNotFound),
+			"swift": provider.ErrToolNotFound,
+		},
+		"mise": {
+			"ruby":  errors.New("compile ruby: exit status 1"),
+			"swift": provider.ErrToolNotFound,
+		},
+		"asdf": {
+			"swift": provider.ErrVersionNotFound,
+		},
+	}
+	return provider.NewDispatcher("asdf", func(providerID string) (provider.ToolProvider, error) {
+		*created = append(*created, providerID)
+		return fallbackMember{stubProvider: stubProvider{id: providerID, bootstrapCount: new(int)}, errs: errs[providerID], calls: calls}, nil
+	})
+}
+
+func TestFallbackProvider(t *testing.T) {
+	var calls, created []string
+	var events []provider.Event
+	ctx := provider.WithObserver(context.Background(), provider.ObserverFunc(func(e provider.Event) {
+		e.Time = time.Time{}
+		events = append(events, e)
+	}))
+	dispatcher := newFallbackDispatcher(&calls, &created)
+
+	chain, err := dispatcher.ProviderFor(ctx, provider.ToolRequest{ToolName: "go", ProviderID: "direct, mise,asdf"})
+	require.NoError(t, err)
+	assert.Equal(t, "direct,mise,asdf", chain.ID())
+	assert.Empty(t, created)
+
+	result, err := chain.InstallTool(ctx, provider.ToolRequest{ToolName: "go", UnparsedVersion: "1.24"})
+	require.NoError(t, err)
+	assert.Equal(t, "direct", result.ProviderID)
+	assert.Equal(t, []string{"direct"}, created, "members that are not needed are not created")
+
+	result, err = chain.InstallTool(ctx, provider.ToolRequest{ToolName: "node", UnparsedVersion: "20"})
+	require.NoError(t, err)
+	assert.Equal(t, "mise", result.ProviderID)
+	assert.Equal(t, []provider.Event{
+		{Kind: provider.EventProviderFallback, ProviderID: "direct", ToolName: "node", Version: "20", Message: "node is not in the manifest"},
+	}, events)
+
+	activation, err := chain.ActivateEnv(ctx, result)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/mise/node"}, activation.ContributedPaths)
+
+	_, err = chain.InstallTool(ctx, provider.ToolRequest{ToolName: "ruby", UnparsedVersion: "3.3"})
+	assert.EqualError(t, err, "compile ruby: exit status 1", "other errors stop the chain")
+
+	_, err = chain.InstallTool(ctx, provider.ToolRequest{ToolName: "swift", UnparsedVersion: "6"})
+	assert.EqualError(t, err, "none of the providers direct,mise,asdf could install swift 6:\ndirect: tool not found\nmise: tool not found\nasdf: no matching version")
+	assert.True(t, provider.IsFallbackError(err))
+
+	plan, err := chain.PlanInstall(ctx, provider.ToolRequest{ToolName: "node", UnparsedVersion: "20"})
+	require.NoError(t, err)
+	assert.Equal(t, "mise", plan.ProviderID)
+
+	assert.Equal(t, []string{
+		"direct install go",
+		"direct install node", "mise install node",
+		"mise activate node",
+		"direct install ruby", "mise install ruby",
+		"direct install swift", "mise install swift", "asdf install swift",
+		"direct plan node", "mise plan node",
+	}, calls)
+	assert.Equal(t, []string{"direct", "mise", "asdf"}, created)
+}
+
+func TestDispatcherFallbackChains(t *testing.T) {
+	var calls, created []string
+	dispatcher := newFallbackDispatcher(&calls, &created)
+
+	chain, err := dispatcher.ProviderFor(context.Background(), provider.ToolRequest{ProviderID: "mise,asdf"})
+	require.NoError(t, err)
+	again, err := dispatcher.ProviderFor(context.Background(), provider.ToolRequest{ProviderID: "mise, asdf"})
+	require.NoError(t, err)
+	assert.Same(t, chain, again)
+
+	single, err := dispatcher.ProviderFor(context.Background(), provider.ToolRequest{ProviderID: "mise,"})
+	require.NoError(t, err)
+	assert.Equal(t, "mise", single.ID(), "a chain of one is the provider itself")
+
+	providers, err := dispatcher.ProvidersFor(context.Background(), provider.ToolRequest{ProviderID: "direct,asdf"})
+	require.NoError(t, err)
+	require.Len(t, providers, 2)
+	assert.Equal(t, "direct", providers[0].ID())
+	assert.Equal(t, "asdf", providers[1].ID())
+
+	var ids []string
+	for _, p := range dispatcher.Providers() {
+		ids = append(ids, p.ID())
+	}
+	assert.Equal(t, []string{"asdf", "direct", "mise"}, ids)
+}
+
+func TestFallbackChain(t *testing.T) {
+	assert.Equal(t, []string{"direct", "mise"}, provider.FallbackChain(" direct ,, mise"))
+	assert.Equal(t, []string{"asdf"}, provider.FallbackChain("asdf"))
+	assert.Empty(t, provider.FallbackChain(""))
+	assert.Equal(t, "direct,mise", provider.FallbackChainID([]string{"direct", "mise"}))
+}
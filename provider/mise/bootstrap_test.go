@@ -0,0 +1,32 @@
+package mise
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/toolprovider/provider"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBootstrapEvents(t *testing.T) {
+	var events []provider.Event
+	ctx, cancel := context.WithCancel(context.Background())
+	// The download fails right away, the start of it is still reported
+	cancel()
+	ctx = provider.WithObserver(ctx, provider.ObserverFunc(func(e provider.Event) {
+		e.Time = time.Time{}
+		events = append(events, e)
+	}))
+
+	m, err := NewToolProvider(t.TempDir(), t.TempDir())
+	require.NoError(t, err)
+	err = m.Bootstrap(ctx)
+	require.Error(t, err)
+
+	url, err := downloadURL(m.PinnedVersion)
+	require.NoError(t, err)
+	require.Equal(t, []provider.Event{
+		{Kind: provider.EventDownloadProgress, ProviderID: "mise", Message: url, TotalBytes: -1},
+	}, events)
+}
@@ -0,0 +1,112 @@
+package mise
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitrise-io/toolprovider/provider"
+	"github.com/bitrise-io/toolprovider/provider/mise/execenv"
+	"github.com/bitrise-io/toolprovider/provider/providertest"
+)
+
+func TestMain(m *testing.M) {
+	providertest.RunFakeCLI(fakeMise)
+	os.Exit(m.Run())
+}
+
+func TestConformance(t *testing.T) {
+	providertest.Conformance{
+		ToolName: "golang",
+		NewProvider: func(t *testing.T, released, installed []string) provider.ToolProvider {
+			installDir := t.TempDir()
+			providertest.WriteFakeCLI(t, filepath.Join(installDir, "bin", "mise"), providertest.NewVersionDir(t, "golang", released, installed))
+			return &MiseToolProvider{ExecEnv: execenv.ExecEnv{InstallDir: installDir, ExtraEnvs: map[string]string{}}}
+		},
+		Deviations: map[string]string{
+			"strict/Old Golang versioning scheme": "mise matches versions fuzzily, so a strict 1.19 is the latest 1.19.x release",
+		},
+	}.Run(t)
+}
+
+// fakeMise implements the mise commands that the provider runs. Like mise, it matches versions fuzzily:
+// a version also matches the versions it's a prefix of.
+func fakeMise(name string, args []string, dir providertest.VersionDir, stdout, stderr io.Writer) int {
+	fail := func(format string, a ...any) int {
+		fmt.Fprintf(stderr, "mise ERROR "+format+"\n", a...)
+		return 1
+	}
+	latest := func(versions []string, prefix string) string {
+		if prefix == "latest" {
+			prefix = ""
+		}
+		for _, v := range provider.LogicallySortedVersions(versions) {
+			if provider.MatchesVersionPrefix(v, prefix) {
+				return v
+			}
+		}
+		return ""
+	}
+
+	switch {
+	case len(args) == 2 && args[0] == "ls-remote":
+		released, err := dir.Released(args[1])
+		if err != nil {
+			return fail("%s", err)
+		}
+		fmt.Fprintln(stdout, strings.Join(released, "\n"))
+	case len(args) == 5 && args[0] == "ls" && args[1] == "--installed":
+		installed, err := dir.Installed(args[4])
+		if err != nil {
+			return fail("%s", err)
+		}
+		type install struct {
+			Version string `json:"version"`
+		}
+		installs := []install{}
+		for _, v := range installed {
+			installs = append(installs, install{Version: v})
+		}
+		if err := json.NewEncoder(stdout).Encode(installs); err != nil {
+			return fail("%s", err)
+		}
+	case len(args) >= 2 && args[0] == "latest":
+		toolName, version, _ := strings.Cut(args[len(args)-1], "@")
+		versions, err := dir.Released(toolName)
+		if args[1] == "--installed" {
+			versions, err = dir.Installed(toolName)
+		}
+		if err != nil {
+			return fail("%s", err)
+		}
+		fmt.Fprintln(stdout, latest(versions, version))
+	case len(args) == 3 && args[0] == "install" && args[1] == "--yes":
+		toolName, version, _ := strings.Cut(args[2], "@")
+		version = strings.TrimPrefix(version, "prefix:")
+		released, err := dir.Released(toolName)
+		if err != nil {
+			return fail("%s", err)
+		}
+		installed, err := dir.Installed(toolName)
+		if err != nil {
+			return fail("%s", err)
+		}
+		v := latest(released, version)
+		if v == "" {
+			v = latest(installed, version)
+		}
+		if v == "" {
+			return fail("no versions found for %s matching %s", toolName, version)
+		}
+		if err := dir.Install(toolName, v); err != nil {
+			return fail("%s", err)
+		}
+	default:
+		return fail("%s: unsupported command: %v", name, args)
+	}
+	return 0
+}
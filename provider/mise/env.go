@@ -1,6 +1,7 @@
 package mise
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -16,9 +17,9 @@ type envOutput map[string]string
 
 // envVarsForTool returns the env vars required for the given tool version to be available and work correctly in
 // a shell environment. This includes $PATH additions and other env vars, such as $JAVA_HOME, $GOROOT, etc.
-func (m *MiseToolProvider) envVarsForTool(installResult provider.ToolInstallResult) (envOutput, error) {
+func (m *MiseToolProvider) envVarsForTool(ctx context.Context, installResult provider.ToolInstallResult) (envOutput, error) {
 	// Note: --quiet hides warnings and other plain text lines that would break JSON parsing.
-	data, err := m.ExecEnv.RunMise("env", "--quiet", "--json", fmt.Sprintf("%s@%s", installResult.ToolName, installResult.ConcreteVersion))
+	data, err := m.ExecEnv.RunMise(ctx, "env", "--quiet", "--json", fmt.Sprintf("%s@%s", installResult.ToolName, installResult.ConcreteVersion))
 	if err != nil {
 		return envOutput{}, fmt.Errorf("mise env %s@%s: %w", installResult.ToolName, installResult.ConcreteVersion, err)
 	}
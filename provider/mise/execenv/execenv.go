@@ -1,12 +1,20 @@
 package execenv
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"path"
+	"syscall"
+	"time"
+
+	"github.com/bitrise-io/toolprovider/internal/lineoutput"
 )
 
+// waitDelay is how long a command may keep its output pipes open after it's killed on cancellation.
+const waitDelay = 5 * time.Second
+
 // ExecEnv contains everything needed to run mise commands in a specific environment
 // that is installed and pre-configured.
 type ExecEnv struct {
@@ -17,16 +25,38 @@ type ExecEnv struct {
 	ExtraEnvs map[string]string
 }
 
-func (e *ExecEnv) RunMise(args ...string) (string, error) {
+// RunMise runs mise with the given arguments. When ctx is done, the whole process group of mise is killed,
+// so that the downloads and builds it started don't outlive the command.
+func (e *ExecEnv) RunMise(ctx context.Context, args ...string) (string, error) {
+	return e.StreamMise(ctx, nil, args...)
+}
+
+// StreamMise is RunMise, but it also passes each line of the output to onLine while mise runs.
+// A nil onLine only collects the output.
+func (e *ExecEnv) StreamMise(ctx context.Context, onLine func(line string), args ...string) (string, error) {
 	executable := path.Join(e.InstallDir, "bin", "mise")
-	cmd := exec.Command(executable, args...)
+	cmd := exec.CommandContext(ctx, executable, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = waitDelay
 	cmd.Env = os.Environ()
 	for k, v := range e.ExtraEnvs {
 		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, v))
 	}
-	output, err := cmd.CombinedOutput()
+	outputWriter := lineoutput.NewWriter(onLine)
+	cmd.Stdout = outputWriter
+	cmd.Stderr = outputWriter
+	err := cmd.Run()
+	outputWriter.Flush()
+	output := outputWriter.Output()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return string(output), fmt.Errorf("mise %v: %w", args, ctxErr)
+	}
 	if err != nil {
-		return "", fmt.Errorf("%s\n%s", err, output)
+		// Output is returned in case of an error too, so that callers can report it in a structured way.
+		return string(output), fmt.Errorf("%s\n%s", err, output)
 	}
 
 	return string(output), nil
@@ -1,63 +1,84 @@
 package mise
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/bitrise-io/toolprovider/provider"
 )
 
-func (m *MiseToolProvider) installToolVersion(tool provider.ToolRequest) error {
-	versionString, err := miseVersionString(tool, m.resolveToLatestInstalled)
+func (m *MiseToolProvider) installToolVersion(ctx context.Context, tool provider.ToolRequest) error {
+	versionString, err := miseVersionString(tool, m.latestInstalledResolver(ctx))
 	if err != nil {
 		return err
 	}
 
-	output, err := m.ExecEnv.RunMise("install", "--yes", versionString)
+	output, err := m.ExecEnv.StreamMise(ctx, provider.OutputLineEmitter(ctx, m.ID(), tool.ToolName), "install", "--yes", versionString)
 	if err != nil {
 		return provider.ToolInstallError{
 			ToolName:         tool.ToolName,
 			RequestedVersion: versionString,
 			Cause:            fmt.Sprintf("mise install %s: %s", versionString, err),
 			RawOutput:        string(output),
+			Err:              m.classifyInstallError(ctx, tool, string(output)),
 		}
 	}
 	return nil
 }
 
+// missingVersionHints are parts of the output of a `mise install` that failed because the version doesn't exist.
+// Mise has no distinct error for this, so the hints are confirmed by listing the released versions.
+var missingVersionHints = []string{"no versions found", "version not found", "404 Not Found"}
+
+// classifyInstallError returns provider.ErrToolNotFound if the output of a failed `mise install` says that mise
+// doesn't know the tool, provider.ErrVersionNotFound if no released version matches the request, and nil otherwise.
+func (m *MiseToolProvider) classifyInstallError(ctx context.Context, tool provider.ToolRequest, output string) error {
+	if strings.Contains(output, "not found in mise tool registry") {
+		return provider.ErrToolNotFound
+	}
+	if !slices.ContainsFunc(missingVersionHints, func(hint string) bool { return strings.Contains(output, hint) }) {
+		return nil
+	}
+
+	releasedVersions, err := m.listReleased(ctx, tool.ToolName)
+	if err != nil {
+		// The install error is more relevant than this one.
+		return nil
+	}
+	if !slices.ContainsFunc(releasedVersions, func(v string) bool { return provider.MatchesVersionPrefix(v, tool.UnparsedVersion) }) {
+		return provider.ErrVersionNotFound
+	}
+	return nil
+}
+
 // Helper for easier testing.
 // Inputs: tool name, tool version
 // Returns: latest installed version of the tool, or an error if no matching version is installed
 type latestInstalledResolver func(string, string) (string, error)
 
-func isAlreadyInstalled(tool provider.ToolRequest, latestInstalledResolver latestInstalledResolver) (bool, error) {
-	_, err := latestInstalledResolver(tool.ToolName, tool.UnparsedVersion)
-	var isAlreadyInstalled bool
-	if err != nil {
-		if errors.Is(err, errNoMatchingVersion) {
-			isAlreadyInstalled = false
-		} else {
-			return false, err
-		}
-	} else {
-		isAlreadyInstalled = true
+func (m *MiseToolProvider) latestInstalledResolver(ctx context.Context) latestInstalledResolver {
+	return func(toolName string, version string) (string, error) {
+		return m.resolveToLatestInstalled(ctx, toolName, version)
 	}
-	return isAlreadyInstalled, nil
+}
+
+// isAlreadyInstalled tells if the concrete version that a request installed was installed before.
+// Mise installs fuzzy versions, so it's not enough that an installed version matches the request,
+// e.g. 20.1.0 is installed, but 20 is resolved to the newly released 20.5.0.
+func isAlreadyInstalled(concreteVersion string, installedBefore []string) bool {
+	return slices.Contains(installedBefore, concreteVersion)
 }
 
 func miseVersionString(tool provider.ToolRequest, latestInstalledResolver latestInstalledResolver) (string, error) {
 	var miseVersionString string
-	resolutionStrategy := tool.ResolutionStrategy
-	if tool.UnparsedVersion == "installed" {
-		resolutionStrategy = provider.ResolutionStrategyLatestInstalled
-	}
-
-	switch resolutionStrategy {
+	switch tool.ResolutionStrategy {
 	case provider.ResolutionStrategyStrict:
 		miseVersionString = fmt.Sprintf("%s@%s", tool.ToolName, tool.UnparsedVersion)
 	case provider.ResolutionStrategyLatestReleased:
-		// https://mise.jdx.dev/configuration.html#scopes
-		miseVersionString = fmt.Sprintf("%s@prefix:%s", tool.ToolName, tool.UnparsedVersion)
+		miseVersionString = latestReleasedVersionString(tool)
 	case provider.ResolutionStrategyLatestInstalled:
 		latestInstalledV, err := latestInstalledResolver(tool.ToolName, tool.UnparsedVersion)
 		if err == nil {
@@ -65,14 +86,28 @@ func miseVersionString(tool provider.ToolRequest, latestInstalledResolver latest
 		} else {
 			if errors.Is(err, errNoMatchingVersion) {
 				// No local version satisfies the request -> fallback to latest released
-				miseVersionString = fmt.Sprintf("%s@prefix:%s", tool.ToolName, tool.UnparsedVersion)
+				miseVersionString = latestReleasedVersionString(tool)
 			} else {
 				return "", fmt.Errorf("resolve %s %s to latest installed version: %w", tool.ToolName, tool.UnparsedVersion, err)
 			}
 		}
+	case provider.ResolutionStrategyConstraint:
+		return "", fmt.Errorf("version constraint %s must be resolved to a concrete version first", tool.UnparsedVersion)
+	case provider.ResolutionStrategyAuto:
+		return "", fmt.Errorf("version %s must be resolved to a concrete version first", tool.UnparsedVersion)
 	default:
 		return "", fmt.Errorf("unknown resolution strategy: %v", tool.ResolutionStrategy)
 	}
 	return miseVersionString, nil
 
 }
+
+// latestReleasedVersionString is the mise version of the latest released version matching the requested prefix.
+func latestReleasedVersionString(tool provider.ToolRequest) string {
+	if tool.UnparsedVersion == "" {
+		// An empty prefix is not valid, see normalizeKeywords()
+		return fmt.Sprintf("%s@latest", tool.ToolName)
+	}
+	// https://mise.jdx.dev/configuration.html#scopes
+	return fmt.Sprintf("%s@prefix:%s", tool.ToolName, tool.UnparsedVersion)
+}
@@ -1,11 +1,15 @@
 package mise
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/bitrise-io/toolprovider/provider"
+	"github.com/bitrise-io/toolprovider/provider/mise/execenv"
 	"github.com/stretchr/testify/require"
 )
 
@@ -36,6 +40,16 @@ func TestMiseVersionString(t *testing.T) {
 			want:    "python@prefix:3.11",
 			wantErr: false,
 		},
+		{
+			name: "latest released resolution strategy, any version",
+			tool: provider.ToolRequest{
+				ToolName:           "python",
+				UnparsedVersion:    "",
+				ResolutionStrategy: provider.ResolutionStrategyLatestReleased,
+			},
+			want:    "python@latest",
+			wantErr: false,
+		},
 		{
 			name: "latest installed resolution strategy, partial version, version found",
 			tool: provider.ToolRequest{
@@ -56,6 +70,16 @@ func TestMiseVersionString(t *testing.T) {
 			want:    "java@prefix:17",
 			wantErr: false,
 		},
+		{
+			name: "latest installed resolution strategy, nothing installed, fallback to latest released",
+			tool: provider.ToolRequest{
+				ToolName:           "java",
+				UnparsedVersion:    "",
+				ResolutionStrategy: provider.ResolutionStrategyLatestInstalled,
+			},
+			want:    "java@latest",
+			wantErr: false,
+		},
 		{
 			name: "latest installed resolution strategy - error resolving",
 			tool: provider.ToolRequest{
@@ -111,62 +135,118 @@ func TestMiseVersionString(t *testing.T) {
 
 func TestIsAlreadyInstalled(t *testing.T) {
 	tests := []struct {
-		name                 string
-		tool                 provider.ToolRequest
-		latestInstalledError error
-		want                 bool
-		wantErr              bool
+		name            string
+		concreteVersion string
+		installedBefore []string
+		want            bool
 	}{
 		{
-			name: "tool is already installed",
-			tool: provider.ToolRequest{
-				ToolName:        "node",
-				UnparsedVersion: "18.20.0",
-			},
-			latestInstalledError: nil,
-			want:                 true,
-			wantErr:              false,
+			name:            "tool is already installed",
+			concreteVersion: "18.20.0",
+			installedBefore: []string{"18.19.0", "18.20.0"},
+			want:            true,
 		},
 		{
-			name: "tool is not installed - no matching version",
-			tool: provider.ToolRequest{
-				ToolName:        "python",
-				UnparsedVersion: "3.11",
-			},
-			latestInstalledError: errNoMatchingVersion,
-			want:                 false,
-			wantErr:              false,
+			name:            "tool is not installed",
+			concreteVersion: "3.11.9",
+			installedBefore: nil,
+			want:            false,
 		},
 		{
-			name: "error resolving installed versions",
-			tool: provider.ToolRequest{
-				ToolName:        "ruby",
-				UnparsedVersion: "3.0",
-			},
-			latestInstalledError: errors.New("failed to list installed versions"),
-			want:                 false,
-			wantErr:              true,
+			name:            "an older version matching the request is installed",
+			concreteVersion: "20.5.0",
+			installedBefore: []string{"20.1.0"},
+			want:            false,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			latestInstalledResolver := func(toolName, version string) (string, error) {
-				if tt.latestInstalledError != nil {
-					return "", tt.latestInstalledError
-				}
-				return "fake.version", nil
-			}
-
-			got, err := isAlreadyInstalled(tt.tool, latestInstalledResolver)
-
-			if tt.wantErr {
-				require.Error(t, err)
-				require.False(t, got)
-			} else {
-				require.NoError(t, err)
-				require.Equal(t, tt.want, got)
-			}
+			require.Equal(t, tt.want, isAlreadyInstalled(tt.concreteVersion, tt.installedBefore))
 		})
 	}
 }
+
+func TestInstallToolPostInstall(t *testing.T) {
+	installDir := t.TempDir()
+	callsPath := filepath.Join(installDir, "calls")
+	// 20.1.0 is installed, 20 resolves to the newly released 20.5.0.
+	script := `#!/bin/sh
+echo "$*" >>` + callsPath + `
+case "$1" in
+ls) echo '[{"version": "20.1.0"}]' ;;
+latest) echo "20.5.0" ;;
+exec) [ "$6" != "exit 1" ] ;;
+esac
+`
+	require.NoError(t, os.MkdirAll(filepath.Join(installDir, "bin"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(installDir, "bin", "mise"), []byte(script), 0755))
+	m := &MiseToolProvider{ExecEnv: execenv.ExecEnv{InstallDir: installDir, ExtraEnvs: map[string]string{}}}
+
+	result, err := m.InstallTool(context.Background(), provider.ToolRequest{
+		ToolName:           "node",
+		UnparsedVersion:    "20",
+		ResolutionStrategy: provider.ResolutionStrategyLatestReleased,
+		PostInstall:        []string{"corepack enable"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "20.5.0", result.ConcreteVersion)
+	require.False(t, result.IsAlreadyInstalled)
+	calls, err := os.ReadFile(callsPath)
+	require.NoError(t, err)
+	require.Contains(t, string(calls), "exec node@20.5.0 -- bash -c corepack enable")
+
+	// A version with a failed post-install command is uninstalled, so that the next run installs it again
+	_, err = m.InstallTool(context.Background(), provider.ToolRequest{
+		ToolName:           "node",
+		UnparsedVersion:    "20",
+		ResolutionStrategy: provider.ResolutionStrategyLatestReleased,
+		PostInstall:        []string{"exit 1"},
+	})
+	var installErr provider.ToolInstallError
+	require.ErrorAs(t, err, &installErr)
+	calls, err = os.ReadFile(callsPath)
+	require.NoError(t, err)
+	require.Contains(t, string(calls), "uninstall node@20.5.0")
+}
+
+func TestInstallToolFallbackErrors(t *testing.T) {
+	installDir := t.TempDir()
+	callsPath := filepath.Join(installDir, "calls")
+	// 1.22.0 is released but fails to build, 9.9.9 doesn't exist, and foo is unknown to mise.
+	script := `#!/bin/sh
+echo "$*" >>` + callsPath + `
+case "$1 $3" in
+"install golang@1.22.0") echo "mise ERROR make: *** [all] Error 2" >&2; exit 1 ;;
+"install golang@9.9.9") echo "mise ERROR no versions found for golang matching 9.9.9" >&2; exit 1 ;;
+"install foo@1.0.0") echo "mise ERROR foo not found in mise tool registry" >&2; exit 1 ;;
+esac
+case "$1" in
+ls-remote) echo "1.21.0 1.22.0" ;;
+ls) echo "[]" ;;
+latest) echo "" ;;
+esac
+`
+	require.NoError(t, os.MkdirAll(filepath.Join(installDir, "bin"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(installDir, "bin", "mise"), []byte(script), 0755))
+	m := &MiseToolProvider{ExecEnv: execenv.ExecEnv{InstallDir: installDir, ExtraEnvs: map[string]string{}}}
+	ctx := context.Background()
+
+	_, err := m.InstallTool(ctx, provider.ToolRequest{ToolName: "golang", UnparsedVersion: "9.9.9", ResolutionStrategy: provider.ResolutionStrategyStrict})
+	require.ErrorIs(t, err, provider.ErrVersionNotFound)
+	require.True(t, provider.IsFallbackError(err))
+
+	_, err = m.InstallTool(ctx, provider.ToolRequest{ToolName: "foo", UnparsedVersion: "1.0.0", ResolutionStrategy: provider.ResolutionStrategyStrict})
+	require.ErrorIs(t, err, provider.ErrToolNotFound)
+
+	require.NoError(t, os.Remove(callsPath))
+	_, err = m.InstallTool(ctx, provider.ToolRequest{ToolName: "golang", UnparsedVersion: "1.22.0", ResolutionStrategy: provider.ResolutionStrategyStrict})
+	require.Error(t, err)
+	require.False(t, provider.IsFallbackError(err))
+	calls, err := os.ReadFile(callsPath)
+	require.NoError(t, err)
+	require.NotContains(t, string(calls), "ls-remote", "a build failure isn't a missing version")
+
+	_, err = m.PlanInstall(ctx, provider.ToolRequest{ToolName: "golang", UnparsedVersion: "9.9", ResolutionStrategy: provider.ResolutionStrategyLatestReleased})
+	require.ErrorIs(t, err, provider.ErrVersionNotFound)
+}
@@ -1,21 +1,26 @@
 package mise
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/bitrise-io/toolprovider/provider"
 	"github.com/bitrise-io/toolprovider/provider/mise/execenv"
 )
 
-// We pin one Mise version because:
+// We pin one Mise version by default (see MiseToolProvider.PinnedVersion) because:
 // - Mise doesn't follow SemVer, there are breaking changes in regular releases sometimes
 // - We depend on the exact layout of the release .tar.gz archive in Bootstrap(), this is probably not stable
 const miseVersion = "v2025.7.18"
 
 type MiseToolProvider struct {
 	ExecEnv execenv.ExecEnv
+	// PinnedVersion is the mise version installed by Bootstrap(), with a v prefix.
+	PinnedVersion string
 }
 
 func NewToolProvider(installDir string, dataDir string) (*MiseToolProvider, error) {
@@ -27,6 +32,7 @@ func NewToolProvider(installDir string, dataDir string) (*MiseToolProvider, erro
 	}
 
 	return &MiseToolProvider{
+		PinnedVersion: miseVersion,
 		ExecEnv: execenv.ExecEnv{
 			InstallDir: installDir,
 
@@ -47,11 +53,12 @@ func (m *MiseToolProvider) ID() string {
 	return "mise"
 }
 
-func (m *MiseToolProvider) Bootstrap() error {
-	fmt.Printf("Installing Mise %s...", miseVersion)
-	fmt.Println()
+func (m *MiseToolProvider) Version() (string, error) {
+	return strings.TrimPrefix(m.PinnedVersion, "v"), nil
+}
 
-	err := installReleaseBinary(miseVersion, m.ExecEnv.InstallDir)
+func (m *MiseToolProvider) Bootstrap(ctx context.Context) error {
+	err := installReleaseBinary(ctx, m.PinnedVersion, m.ExecEnv.InstallDir)
 	if err != nil {
 		return fmt.Errorf("bootstrap mise: %w", err)
 	}
@@ -59,34 +66,82 @@ func (m *MiseToolProvider) Bootstrap() error {
 	return nil
 }
 
-func (m *MiseToolProvider) InstallTool(tool provider.ToolRequest) (provider.ToolInstallResult, error) {
-	isAlreadyInstalled, err := isAlreadyInstalled(tool, m.resolveToLatestInstalled)
+// SupportsConcurrentInstalls is true, because mise locks the install directory of each tool version itself.
+func (m *MiseToolProvider) SupportsConcurrentInstalls() bool {
+	return true
+}
+
+func (m *MiseToolProvider) InstallTool(ctx context.Context, tool provider.ToolRequest) (provider.ToolInstallResult, error) {
+	// Use a copy, so that the dependency env only affects the commands of this install.
+	withDependencyEnv := *m
+	withDependencyEnv.ExecEnv.ExtraEnvs = tool.DependencyEnv.Apply(m.ExecEnv.ExtraEnvs)
+	m = &withDependencyEnv
+
+	resolveStart := time.Now()
+	provider.Emit(ctx, provider.Event{Kind: provider.EventResolveStarted, ProviderID: m.ID(), ToolName: tool.ToolName, Version: tool.UnparsedVersion})
+	tool = normalizeKeywords(tool)
+	if tool.ResolutionStrategy == provider.ResolutionStrategyConstraint {
+		resolvedTool, err := m.resolveConstraint(ctx, tool)
+		if err != nil {
+			return provider.ToolInstallResult{}, fmt.Errorf("resolve version constraint: %w", err)
+		}
+		tool = resolvedTool
+	}
+	if tool.ResolutionStrategy == provider.ResolutionStrategyAuto {
+		resolvedTool, err := m.resolveAuto(ctx, tool)
+		if err != nil {
+			return provider.ToolInstallResult{}, fmt.Errorf("resolve version: %w", err)
+		}
+		tool = resolvedTool
+	}
+
+	installedBefore, err := m.listInstalled(ctx, tool.ToolName)
 	if err != nil {
 		return provider.ToolInstallResult{}, err
 	}
+	resolveDuration := time.Since(resolveStart)
 
-	err = m.installToolVersion(tool)
+	err = m.installToolVersion(ctx, tool)
 	if err != nil {
 		return provider.ToolInstallResult{}, err
 	}
 
-	concreteVersion, err := m.resolveToConcreteVersionAfterInstall(tool)
+	resolveStart = time.Now()
+	concreteVersion, err := m.resolveToConcreteVersionAfterInstall(ctx, tool)
 	if err != nil {
 		return provider.ToolInstallResult{}, fmt.Errorf("resolve exact version after install: %w", err)
 	}
+	resolveDuration += time.Since(resolveStart)
+	provider.Emit(ctx, provider.Event{Kind: provider.EventResolveFinished, ProviderID: m.ID(), ToolName: tool.ToolName, Version: concreteVersion})
+	isAlreadyInstalled := isAlreadyInstalled(concreteVersion, installedBefore)
 
-	return provider.ToolInstallResult{
+	result := provider.ToolInstallResult{
 		ToolName:           tool.ToolName,
 		IsAlreadyInstalled: isAlreadyInstalled,
 		ConcreteVersion:    concreteVersion,
-	}, nil
+		ResolveDuration:    resolveDuration,
+	}
+	if !isAlreadyInstalled {
+		err = m.runPostInstall(ctx, tool, result)
+		if err != nil {
+			return provider.ToolInstallResult{}, provider.UninstallAfterFailedPostInstall(ctx, m, result, err)
+		}
+	}
+	return result, nil
 }
 
-func (m *MiseToolProvider) ActivateEnv(result provider.ToolInstallResult) (provider.EnvironmentActivation, error) {
-	envs, err := m.envVarsForTool(result)
-	if err != nil {
-		return provider.EnvironmentActivation{}, fmt.Errorf("get mise env: %w", err)
+func (m *MiseToolProvider) ActivateEnv(ctx context.Context, result provider.ToolInstallResult) (provider.EnvironmentActivation, error) {
+	activation := provider.EnvironmentActivation{}
+	if result.NativeTool != nil {
+		activation = result.NativeTool.Activation()
+	} else {
+		envs, err := m.envVarsForTool(ctx, result)
+		if err != nil {
+			return provider.EnvironmentActivation{}, fmt.Errorf("get mise env: %w", err)
+		}
+		activation = processEnvOutput(envs)
 	}
 
-	return processEnvOutput(envs), nil
+	provider.Emit(ctx, provider.Event{Kind: provider.EventActivated, ProviderID: m.ID(), ToolName: result.ToolName, Version: result.ConcreteVersion})
+	return activation, nil
 }
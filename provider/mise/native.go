@@ -0,0 +1,22 @@
+package mise
+
+import (
+	"context"
+	"os"
+
+	"github.com/bitrise-io/toolprovider/provider"
+)
+
+// IsInstalledNative looks for the tool in $PATH and in the known stack locations.
+// The mise binary's install dir and the mise data dir (installs and shims) are skipped.
+func (m *MiseToolProvider) IsInstalledNative(ctx context.Context, tool provider.ToolRequest) (provider.NativeTool, bool, error) {
+	var excludedDirs []string
+	for _, dir := range []string{m.ExecEnv.InstallDir, m.ExecEnv.ExtraEnvs["MISE_DATA_DIR"]} {
+		if dir != "" {
+			excludedDirs = append(excludedDirs, dir)
+		}
+	}
+
+	native, ok := provider.FindNativeTool(ctx, tool, provider.NativeSearchDirs(tool.ToolName, os.Getenv("PATH")), excludedDirs)
+	return native, ok, nil
+}
@@ -0,0 +1,63 @@
+package mise
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/bitrise-io/toolprovider/provider"
+)
+
+// PlanInstall resolves the requested version the same way as InstallTool() and resolveToConcreteVersionAfterInstall()
+// without running `mise install`.
+func (m *MiseToolProvider) PlanInstall(ctx context.Context, tool provider.ToolRequest) (provider.ToolInstallPlan, error) {
+	plan := provider.ToolInstallPlan{
+		ToolName:           tool.ToolName,
+		RequestedVersion:   tool.UnparsedVersion,
+		ResolutionStrategy: tool.ResolutionStrategy,
+	}
+	tool = normalizeKeywords(tool)
+
+	if tool.ResolutionStrategy == provider.ResolutionStrategyConstraint {
+		resolvedTool, err := m.resolveConstraint(ctx, tool)
+		if err != nil {
+			return provider.ToolInstallPlan{}, fmt.Errorf("resolve version constraint: %w", err)
+		}
+		tool = resolvedTool
+	}
+	if tool.ResolutionStrategy == provider.ResolutionStrategyAuto {
+		resolvedTool, err := m.resolveAuto(ctx, tool)
+		if err != nil {
+			return provider.ToolInstallPlan{}, fmt.Errorf("resolve version: %w", err)
+		}
+		tool = resolvedTool
+	}
+
+	if tool.ResolutionStrategy == provider.ResolutionStrategyLatestInstalled {
+		// See miseVersionString(): the latest installed version is used if there is one, otherwise it falls back
+		// to the latest released version.
+		v, err := m.resolveToLatestInstalled(ctx, tool.ToolName, tool.UnparsedVersion)
+		if err == nil {
+			plan.ResolvedVersion = v
+			plan.IsInstalled = true
+			return plan, nil
+		}
+		if !errors.Is(err, errNoMatchingVersion) {
+			return provider.ToolInstallPlan{}, fmt.Errorf("resolve %s %s to latest installed version: %w", tool.ToolName, tool.UnparsedVersion, err)
+		}
+	}
+
+	v, err := m.resolveToLatestReleased(ctx, tool.ToolName, tool.UnparsedVersion)
+	if err != nil {
+		return provider.ToolInstallPlan{}, fmt.Errorf("resolve %s %s to latest released version: %w", tool.ToolName, tool.UnparsedVersion, err)
+	}
+	installedVersions, err := m.listInstalled(ctx, tool.ToolName)
+	if err != nil {
+		return provider.ToolInstallPlan{}, err
+	}
+
+	plan.ResolvedVersion = v
+	plan.IsInstalled = slices.Contains(installedVersions, v)
+	return plan, nil
+}
@@ -0,0 +1,29 @@
+package mise
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bitrise-io/toolprovider/provider"
+)
+
+// runPostInstall runs the post-install commands of the tool, one by one, with the freshly installed version activated.
+// It stops at the first failing command.
+func (m *MiseToolProvider) runPostInstall(ctx context.Context, tool provider.ToolRequest, installResult provider.ToolInstallResult) error {
+	toolVersion := fmt.Sprintf("%s@%s", installResult.ToolName, installResult.ConcreteVersion)
+	onLine := provider.OutputLineEmitter(ctx, m.ID(), tool.ToolName)
+	for _, command := range tool.PostInstall {
+		// `mise exec` runs the command in the same environment that `mise env` would activate.
+		out, err := m.ExecEnv.StreamMise(ctx, onLine, "exec", toolVersion, "--", "bash", "-c", command)
+		if err != nil {
+			return provider.ToolInstallError{
+				ToolName:         tool.ToolName,
+				RequestedVersion: tool.UnparsedVersion,
+				Cause:            fmt.Sprintf("post-install command failed: %s", command),
+				Recommendation:   "Check the post_install commands of the tool declaration in bitrise.yml.",
+				RawOutput:        out,
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package mise
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/toolprovider/provider"
+)
+
+func init() {
+	provider.Register("mise", newFromOptions)
+}
+
+// newFromOptions creates a provider with the given options. The directories default to ~/.bitrise/tools/mise
+// and ~/.bitrise/tools/mise-data, the version to the one this provider is tested with.
+func newFromOptions(opts provider.ProviderOptions) (provider.ToolProvider, error) {
+	if opts.ShellInit != "" {
+		return nil, errors.New("shell_init is not supported by mise")
+	}
+
+	if opts.InstallDir == "" || opts.DataDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("get user home dir: %w", err)
+		}
+		if opts.InstallDir == "" {
+			opts.InstallDir = filepath.Join(home, ".bitrise", "tools", "mise")
+		}
+		if opts.DataDir == "" {
+			opts.DataDir = filepath.Join(home, ".bitrise", "tools", "mise-data")
+		}
+	}
+
+	p, err := NewToolProvider(opts.InstallDir, opts.DataDir)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Version != "" {
+		p.PinnedVersion = "v" + strings.TrimPrefix(opts.Version, "v")
+	}
+	return p, nil
+}
@@ -0,0 +1,34 @@
+package mise
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/toolprovider/provider"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFromOptions(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	p, err := newFromOptions(provider.ProviderOptions{})
+	require.NoError(t, err)
+	m := p.(*MiseToolProvider)
+	require.Equal(t, filepath.Join(home, ".bitrise", "tools", "mise"), m.ExecEnv.InstallDir)
+	require.Equal(t, filepath.Join(home, ".bitrise", "tools", "mise-data"), m.ExecEnv.ExtraEnvs["MISE_DATA_DIR"])
+	require.Equal(t, miseVersion, m.PinnedVersion)
+
+	p, err = newFromOptions(provider.ProviderOptions{InstallDir: "/opt/mise", DataDir: "/opt/mise-data", Version: "2025.1.0"})
+	require.NoError(t, err)
+	m = p.(*MiseToolProvider)
+	require.Equal(t, "/opt/mise", m.ExecEnv.InstallDir)
+	require.Equal(t, "/opt/mise-data", m.ExecEnv.ExtraEnvs["MISE_DATA_DIR"])
+	require.Equal(t, "v2025.1.0", m.PinnedVersion)
+	v, err := m.Version()
+	require.NoError(t, err)
+	require.Equal(t, "2025.1.0", v)
+
+	_, err = newFromOptions(provider.ProviderOptions{ShellInit: "eval"})
+	require.EqualError(t, err, "shell_init is not supported by mise")
+}
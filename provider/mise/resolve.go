@@ -1,34 +1,59 @@
 package mise
 
 import (
-	"errors"
+	"context"
+	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/bitrise-io/toolprovider/provider"
 )
 
-var errNoMatchingVersion = errors.New("no matching version found")
+// errNoMatchingVersion is provider.ErrVersionNotFound, so that a fallback chain can try the next provider.
+var errNoMatchingVersion = fmt.Errorf("%w found", provider.ErrVersionNotFound)
 
-func (m *MiseToolProvider) resolveToConcreteVersionAfterInstall(tool provider.ToolRequest) (string, error) {
+// normalizeKeywords turns the latest and installed keywords (and an empty version) into an empty version with the
+// resolution strategy they stand for, the same way as the asdf provider. Mise would take them for version prefixes.
+// A strategy other than strict and auto is kept, the keyword only means any version then.
+func normalizeKeywords(tool provider.ToolRequest) provider.ToolRequest {
+	if tool.ResolutionStrategy == provider.ResolutionStrategyConstraint {
+		return tool
+	}
+	v := strings.TrimSpace(tool.UnparsedVersion)
+	if v != "" && v != "latest" && v != "installed" {
+		return tool
+	}
+
+	tool.UnparsedVersion = ""
+	if tool.ResolutionStrategy == provider.ResolutionStrategyStrict || tool.ResolutionStrategy == provider.ResolutionStrategyAuto {
+		tool.ResolutionStrategy = provider.ResolutionStrategyLatestReleased
+		if v == "installed" {
+			tool.ResolutionStrategy = provider.ResolutionStrategyLatestInstalled
+		}
+	}
+	return tool
+}
+
+func (m *MiseToolProvider) resolveToConcreteVersionAfterInstall(ctx context.Context, tool provider.ToolRequest) (string, error) {
 	// Mise doesn't tell us what version it resolved to when installing the user-provided (and potentially fuzzy) version.
 	// But we can use `mise latest` to find out the concrete version.
 	switch tool.ResolutionStrategy {
 	case provider.ResolutionStrategyLatestInstalled:
-		return m.resolveToLatestInstalled(tool.ToolName, tool.UnparsedVersion)
+		return m.resolveToLatestInstalled(ctx, tool.ToolName, tool.UnparsedVersion)
 	case provider.ResolutionStrategyLatestReleased, provider.ResolutionStrategyStrict:
 		// Mise works with fuzzy versions by default, so it happily installs both node@20 and node@20.19.3.
 		// Therefore, when the Bitrise config contains simply 20 (and not 20:latest), it actually behaves
 		// as "latest released".
-		return m.resolveToLatestReleased(tool.ToolName, tool.UnparsedVersion)
+		return m.resolveToLatestReleased(ctx, tool.ToolName, tool.UnparsedVersion)
 	default:
 		return "", fmt.Errorf("unknown resolution strategy: %v", tool.ResolutionStrategy)
 	}
 }
 
-func (m *MiseToolProvider) resolveToLatestReleased(toolName string, version string) (string, error) {
+func (m *MiseToolProvider) resolveToLatestReleased(ctx context.Context, toolName string, version string) (string, error) {
 	// Even if version is empty string "sometool@" will not cause an error.
-	output, err := m.ExecEnv.RunMise("latest", fmt.Sprintf("%s@%s", toolName, version))
+	output, err := m.ExecEnv.RunMise(ctx, "latest", fmt.Sprintf("%s@%s", toolName, version))
 	if err != nil {
 		return "", fmt.Errorf("mise latest %s@%s: %w", toolName, version, err)
 	}
@@ -41,9 +66,9 @@ func (m *MiseToolProvider) resolveToLatestReleased(toolName string, version stri
 	return v, nil
 }
 
-func (m *MiseToolProvider) resolveToLatestInstalled(toolName string, version string) (string, error) {
+func (m *MiseToolProvider) resolveToLatestInstalled(ctx context.Context, toolName string, version string) (string, error) {
 	// Even if version is empty string "sometool@" will not cause an error.
-	output, err := m.ExecEnv.RunMise("latest", "--installed", fmt.Sprintf("%s@%s", toolName, version))
+	output, err := m.ExecEnv.RunMise(ctx, "latest", "--installed", fmt.Sprintf("%s@%s", toolName, version))
 	if err != nil {
 		return "", fmt.Errorf("mise latest --installed %s@%s: %w", toolName, version, err)
 	}
@@ -55,3 +80,109 @@ func (m *MiseToolProvider) resolveToLatestInstalled(toolName string, version str
 
 	return v, nil
 }
+
+func (m *MiseToolProvider) listReleased(ctx context.Context, toolName string) ([]string, error) {
+	output, err := m.ExecEnv.RunMise(ctx, "ls-remote", toolName)
+	if err != nil {
+		return nil, fmt.Errorf("mise ls-remote %s: %w", toolName, err)
+	}
+	return strings.Fields(output), nil
+}
+
+func (m *MiseToolProvider) listInstalled(ctx context.Context, toolName string) ([]string, error) {
+	// Note: --quiet hides warnings and other plain text lines that would break JSON parsing.
+	output, err := m.ExecEnv.RunMise(ctx, "ls", "--installed", "--quiet", "--json", toolName)
+	if err != nil {
+		return nil, fmt.Errorf("mise ls --installed %s: %w", toolName, err)
+	}
+
+	var installs []struct {
+		Version string `json:"version"`
+	}
+	err = json.Unmarshal([]byte(output), &installs)
+	if err != nil {
+		return nil, fmt.Errorf("parse mise ls output: %w\n%s", err, output)
+	}
+
+	var versions []string
+	for _, install := range installs {
+		versions = append(versions, install.Version)
+	}
+	return versions, nil
+}
+
+// resolveConstraint turns a request with a version constraint into a strict request for a concrete version,
+// because mise itself doesn't understand constraint expressions.
+func (m *MiseToolProvider) resolveConstraint(ctx context.Context, tool provider.ToolRequest) (provider.ToolRequest, error) {
+	releasedVersions, err := m.listReleased(ctx, tool.ToolName)
+	if err != nil {
+		return provider.ToolRequest{}, err
+	}
+	installedVersions, err := m.listInstalled(ctx, tool.ToolName)
+	if err != nil {
+		return provider.ToolRequest{}, err
+	}
+	return constraintToStrictRequest(tool, releasedVersions, installedVersions)
+}
+
+// resolveAuto turns an auto request into a strict request for a concrete version (see provider.ResolveAutoVersion()),
+// because mise's fuzzy matching always prefers the latest released version over an installed one.
+func (m *MiseToolProvider) resolveAuto(ctx context.Context, tool provider.ToolRequest) (provider.ToolRequest, error) {
+	v := strings.TrimSpace(tool.UnparsedVersion)
+	if provider.IsFullySpecifiedVersion(v) {
+		// Mise handles these the same way without listing versions, see miseVersionString().
+		// Keywords are not auto requests anymore, see normalizeKeywords().
+		strictTool := tool
+		strictTool.ResolutionStrategy = provider.ResolutionStrategyStrict
+		return strictTool, nil
+	}
+
+	releasedVersions, err := m.listReleased(ctx, tool.ToolName)
+	if err != nil {
+		return provider.ToolRequest{}, err
+	}
+	installedVersions, err := m.listInstalled(ctx, tool.ToolName)
+	if err != nil {
+		return provider.ToolRequest{}, err
+	}
+	return autoToStrictRequest(tool, releasedVersions, installedVersions)
+}
+
+func autoToStrictRequest(tool provider.ToolRequest, releasedVersions, installedVersions []string) (provider.ToolRequest, error) {
+	v, found := provider.ResolveAutoVersion(tool.UnparsedVersion, releasedVersions, installedVersions)
+	if !found {
+		return provider.ToolRequest{}, provider.ToolInstallError{
+			ToolName:         tool.ToolName,
+			RequestedVersion: tool.UnparsedVersion,
+			Cause:            fmt.Sprintf("No released or installed version of %s matches %s", tool.ToolName, tool.UnparsedVersion),
+			Err:              provider.ErrVersionNotFound,
+		}
+	}
+
+	strictTool := tool
+	strictTool.UnparsedVersion = v
+	strictTool.ResolutionStrategy = provider.ResolutionStrategyStrict
+	return strictTool, nil
+}
+
+func constraintToStrictRequest(tool provider.ToolRequest, releasedVersions, installedVersions []string) (provider.ToolRequest, error) {
+	constraints, err := provider.ParseVersionConstraint(tool.UnparsedVersion)
+	if err != nil {
+		return provider.ToolRequest{}, err
+	}
+
+	v, found := provider.LatestMatchingVersion(constraints, append(slices.Clone(releasedVersions), installedVersions...))
+	if !found {
+		return provider.ToolRequest{}, provider.ToolInstallError{
+			ToolName:         tool.ToolName,
+			RequestedVersion: tool.UnparsedVersion,
+			Cause:            fmt.Sprintf("No released or installed version of %s satisfies %s", tool.ToolName, tool.UnparsedVersion),
+			Err:              provider.ErrVersionNotFound,
+		}
+	}
+
+	strictTool := tool
+	strictTool.UnparsedVersion = v
+	strictTool.ResolutionStrategy = provider.ResolutionStrategyStrict
+	return strictTool, nil
+}
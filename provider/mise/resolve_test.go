@@ -0,0 +1,148 @@
+package mise
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/toolprovider/provider"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConstraintToStrictRequest(t *testing.T) {
+	tests := []struct {
+		name              string
+		constraint        string
+		releasedVersions  []string
+		installedVersions []string
+		want              string
+		wantErr           bool
+	}{
+		{
+			name:             "range",
+			constraint:       ">=1.21 <1.23",
+			releasedVersions: []string{"1.20.14", "1.21.0", "1.22.5", "1.23.0"},
+			want:             "1.22.5",
+		},
+		{
+			name:              "installed version is considered",
+			constraint:        "^20",
+			releasedVersions:  []string{"20.19.2"},
+			installedVersions: []string{"20.19.3"},
+			want:              "20.19.3",
+		},
+		{
+			name:             "no match",
+			constraint:       "^24",
+			releasedVersions: []string{"20.19.2", "22.1.0"},
+			wantErr:          true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := provider.ToolRequest{
+				ToolName:           "node",
+				UnparsedVersion:    tt.constraint,
+				ResolutionStrategy: provider.ResolutionStrategyConstraint,
+			}
+
+			got, err := constraintToStrictRequest(tool, tt.releasedVersions, tt.installedVersions)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, provider.ToolRequest{
+				ToolName:           "node",
+				UnparsedVersion:    tt.want,
+				ResolutionStrategy: provider.ResolutionStrategyStrict,
+			}, got)
+		})
+	}
+}
+
+func TestAutoToStrictRequest(t *testing.T) {
+	tests := []struct {
+		name              string
+		version           string
+		releasedVersions  []string
+		installedVersions []string
+		want              string
+		wantErr           bool
+	}{
+		{
+			name:              "prefix prefers installed version",
+			version:           "20",
+			releasedVersions:  []string{"18.20.0", "20.18.0", "20.19.3", "22.1.0"},
+			installedVersions: []string{"20.18.0", "22.1.0"},
+			want:              "20.18.0",
+		},
+		{
+			name:              "prefix falls back to latest released version",
+			version:           "3.12",
+			releasedVersions:  []string{"3.11.9", "3.12.1", "3.12.10", "3.12.9"},
+			installedVersions: []string{"3.11.9"},
+			want:              "3.12.10",
+		},
+		{
+			name:              "fully specified version is strict",
+			version:           "20.18.0",
+			releasedVersions:  []string{"20.18.0", "20.19.3"},
+			installedVersions: []string{"20.18.1"},
+			want:              "20.18.0",
+		},
+		{
+			name:             "no match",
+			version:          "24",
+			releasedVersions: []string{"20.19.3", "22.1.0"},
+			wantErr:          true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := provider.ToolRequest{
+				ToolName:           "node",
+				UnparsedVersion:    tt.version,
+				ResolutionStrategy: provider.ResolutionStrategyAuto,
+			}
+
+			got, err := autoToStrictRequest(tool, tt.releasedVersions, tt.installedVersions)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, provider.ToolRequest{
+				ToolName:           "node",
+				UnparsedVersion:    tt.want,
+				ResolutionStrategy: provider.ResolutionStrategyStrict,
+			}, got)
+		})
+	}
+}
+
+func TestNormalizeKeywords(t *testing.T) {
+	tests := []struct {
+		name             string
+		version          string
+		strategy         provider.ResolutionStrategy
+		expectedVersion  string
+		expectedStrategy provider.ResolutionStrategy
+	}{
+		{name: "empty version", version: "", strategy: provider.ResolutionStrategyStrict, expectedVersion: "", expectedStrategy: provider.ResolutionStrategyLatestReleased},
+		{name: "latest keyword", version: "latest", strategy: provider.ResolutionStrategyStrict, expectedVersion: "", expectedStrategy: provider.ResolutionStrategyLatestReleased},
+		{name: "installed keyword", version: "installed", strategy: provider.ResolutionStrategyStrict, expectedVersion: "", expectedStrategy: provider.ResolutionStrategyLatestInstalled},
+		{name: "installed keyword with auto strategy", version: "installed", strategy: provider.ResolutionStrategyAuto, expectedVersion: "", expectedStrategy: provider.ResolutionStrategyLatestInstalled},
+		{name: "latest keyword with explicit strategy", version: "latest", strategy: provider.ResolutionStrategyLatestInstalled, expectedVersion: "", expectedStrategy: provider.ResolutionStrategyLatestInstalled},
+		{name: "installed keyword with explicit strategy", version: "installed", strategy: provider.ResolutionStrategyLatestReleased, expectedVersion: "", expectedStrategy: provider.ResolutionStrategyLatestReleased},
+		{name: "version is kept", version: "20", strategy: provider.ResolutionStrategyAuto, expectedVersion: "20", expectedStrategy: provider.ResolutionStrategyAuto},
+		{name: "constraint is kept", version: ">=20", strategy: provider.ResolutionStrategyConstraint, expectedVersion: ">=20", expectedStrategy: provider.ResolutionStrategyConstraint},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := normalizeKeywords(provider.ToolRequest{ToolName: "node", UnparsedVersion: tt.version, ResolutionStrategy: tt.strategy})
+			require.Equal(t, provider.ToolRequest{ToolName: "node", UnparsedVersion: tt.expectedVersion, ResolutionStrategy: tt.expectedStrategy}, got)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package mise
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/bitrise-io/toolprovider/provider"
+	"golang.org/x/exp/maps"
+)
+
+// ListInstalled returns the installed versions of every tool, sorted by tool name.
+func (m *MiseToolProvider) ListInstalled(ctx context.Context) ([]provider.InstalledVersion, error) {
+	// Note: --quiet hides warnings and other plain text lines that would break JSON parsing.
+	output, err := m.ExecEnv.RunMise(ctx, "ls", "--installed", "--quiet", "--json")
+	if err != nil {
+		return nil, fmt.Errorf("mise ls --installed: %w", err)
+	}
+	return parseInstalledVersions(output)
+}
+
+func (m *MiseToolProvider) UninstallTool(ctx context.Context, toolName string, version string) error {
+	_, err := m.ExecEnv.RunMise(ctx, "uninstall", fmt.Sprintf("%s@%s", toolName, version))
+	if err != nil {
+		return fmt.Errorf("mise uninstall %s@%s: %w", toolName, version, err)
+	}
+	return nil
+}
+
+func parseInstalledVersions(output string) ([]provider.InstalledVersion, error) {
+	var installsByTool map[string][]struct {
+		Version     string `json:"version"`
+		InstallPath string `json:"install_path"`
+	}
+	err := json.Unmarshal([]byte(output), &installsByTool)
+	if err != nil {
+		return nil, fmt.Errorf("parse mise ls output: %w\n%s", err, output)
+	}
+
+	toolNames := maps.Keys(installsByTool)
+	slices.Sort(toolNames)
+	installed := []provider.InstalledVersion{}
+	for _, toolName := range toolNames {
+		for _, install := range installsByTool[toolName] {
+			installed = append(installed, provider.InstalledVersion{
+				ToolName: toolName,
+				Version:  install.Version,
+				Path:     install.InstallPath,
+			})
+		}
+	}
+	return installed, nil
+}
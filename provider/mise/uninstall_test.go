@@ -0,0 +1,35 @@
+package mise
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/toolprovider/provider"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInstalledVersions(t *testing.T) {
+	output := `{
+  "python": [
+    {"version": "3.12.1", "install_path": "/data/installs/python/3.12.1", "installed": true}
+  ],
+  "node": [
+    {"version": "20.18.0", "install_path": "/data/installs/node/20.18.0", "installed": true},
+    {"version": "22.1.0", "install_path": "/data/installs/node/22.1.0", "installed": true}
+  ]
+}`
+
+	installed, err := parseInstalledVersions(output)
+	require.NoError(t, err)
+	require.Equal(t, []provider.InstalledVersion{
+		{ToolName: "node", Version: "20.18.0", Path: "/data/installs/node/20.18.0"},
+		{ToolName: "node", Version: "22.1.0", Path: "/data/installs/node/22.1.0"},
+		{ToolName: "python", Version: "3.12.1", Path: "/data/installs/python/3.12.1"},
+	}, installed)
+
+	installed, err = parseInstalledVersions("{}")
+	require.NoError(t, err)
+	require.Empty(t, installed)
+
+	_, err = parseInstalledVersions("WARN something\n{}")
+	require.Error(t, err)
+}
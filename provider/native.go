@@ -0,0 +1,229 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+// NativeTool is a tool version that's already on the system outside of any tool provider,
+// e.g. the Java, Python, Ruby and Node installs of a Bitrise stack.
+type NativeTool struct {
+	ToolName string
+	Version  string
+	// Executable is the path of the probed executable with symlinks resolved.
+	Executable string
+	// Home is the root of the install for tools that are configured through an env var (e.g. $JAVA_HOME), otherwise empty.
+	Home string
+
+	homeEnv string
+}
+
+// Activation puts the directory of the native executable first in $PATH, so that it takes precedence over other installs.
+func (t NativeTool) Activation() EnvironmentActivation {
+	activation := EnvironmentActivation{
+		ContributedEnvVars: map[string]string{},
+		ContributedPaths:   []string{filepath.Dir(t.Executable)},
+	}
+	if t.homeEnv != "" && t.Home != "" {
+		activation.ContributedEnvVars[t.homeEnv] = t.Home
+	}
+	return activation
+}
+
+// nativeProbe describes how to find a tool on the system and how to ask for its version.
+type nativeProbe struct {
+	executable     string
+	versionArgs    []string
+	versionPattern *regexp.Regexp
+	// homeEnv is set to the root of the install, as reported by home. The location of the executable is not enough,
+	// e.g. /usr/bin/java is a stub on macOS, and the go of Homebrew has its GOROOT in libexec.
+	homeEnv string
+	home    func(ctx context.Context, executable string) (string, error)
+	// stackDirs are glob patterns of directories where stacks install the tool outside of $PATH.
+	stackDirs []string
+}
+
+var nativeProbes = map[string]nativeProbe{
+	"nodejs": {
+		executable:     "node",
+		versionArgs:    []string{"--version"},
+		versionPattern: regexp.MustCompile(`v(\d+\.\d+\.\d+)`),
+		stackDirs:      []string{"/usr/local/bin", "/opt/homebrew/bin", "/opt/homebrew/opt/node@*/bin"},
+	},
+	"java": {
+		executable: "java",
+		// java -version prints to stderr, e.g. openjdk version "17.0.10" 2024-01-16
+		versionArgs:    []string{"-version"},
+		versionPattern: regexp.MustCompile(`version "([^"]+)"`),
+		homeEnv:        "JAVA_HOME",
+		home:           javaHome,
+		stackDirs:      []string{"/usr/lib/jvm/*/bin", "/Library/Java/JavaVirtualMachines/*/Contents/Home/bin"},
+	},
+	"python": {
+		executable:     "python3",
+		versionArgs:    []string{"--version"},
+		versionPattern: regexp.MustCompile(`Python (\d+\.\d+\.\d+)`),
+		stackDirs:      []string{"/usr/local/bin", "/opt/homebrew/bin", "/opt/homebrew/opt/python@*/bin"},
+	},
+	"ruby": {
+		executable:     "ruby",
+		versionArgs:    []string{"--version"},
+		versionPattern: regexp.MustCompile(`ruby (\d+\.\d+\.\d+)`),
+		stackDirs:      []string{"/usr/local/bin", "/opt/homebrew/opt/ruby/bin"},
+	},
+	"golang": {
+		executable:     "go",
+		versionArgs:    []string{"version"},
+		versionPattern: regexp.MustCompile(`go(\d+\.\d+(?:\.\d+)?)`),
+		homeEnv:        "GOROOT",
+		home:           goRoot,
+		stackDirs:      []string{"/usr/local/go/bin"},
+	},
+}
+
+// NativeSearchDirs returns the directories where FindNativeTool() should look for the tool: the ones in pathEnv,
+// then the known stack locations of the tool.
+func NativeSearchDirs(toolName string, pathEnv string) []string {
+	dirs := filepath.SplitList(pathEnv)
+	for _, pattern := range nativeProbes[GetCanonicalToolName(toolName)].stackDirs {
+		matches, _ := filepath.Glob(pattern)
+		dirs = append(dirs, matches...)
+	}
+	return dirs
+}
+
+// FindNativeTool looks for a native install of the tool that satisfies the request in dirs, in order.
+// Directories inside excludedDirs (e.g. the provider's own installs and shims) are skipped.
+// Only tools with a known version probe are detected, and requests for the latest released version never match,
+// because a native install can't tell whether it's the latest.
+func FindNativeTool(ctx context.Context, tool ToolRequest, dirs []string, excludedDirs []string) (NativeTool, bool) {
+	toolName := GetCanonicalToolName(tool.ToolName)
+	probe, ok := nativeProbes[toolName]
+	if !ok || tool.ResolutionStrategy == ResolutionStrategyLatestReleased {
+		return NativeTool{}, false
+	}
+
+	var probed []string
+	for _, dir := range dirs {
+		if dir == "" || isInside(dir, excludedDirs) {
+			continue
+		}
+		executable, err := filepath.EvalSymlinks(filepath.Join(dir, probe.executable))
+		if err != nil || slices.Contains(probed, executable) || isInside(executable, excludedDirs) {
+			continue
+		}
+		probed = append(probed, executable)
+		if info, err := os.Stat(executable); err != nil || info.IsDir() || info.Mode().Perm()&0111 == 0 {
+			continue
+		}
+
+		out, err := exec.CommandContext(ctx, executable, probe.versionArgs...).CombinedOutput()
+		if err != nil {
+			continue
+		}
+		matches := probe.versionPattern.FindStringSubmatch(string(out))
+		if matches == nil || !nativeVersionMatches(tool, matches[1]) {
+			continue
+		}
+
+		native := NativeTool{ToolName: toolName, Version: matches[1], Executable: executable, homeEnv: probe.homeEnv}
+		if probe.home != nil {
+			// The tool works without its home env var, so it's still used if the home can't be found
+			if home, err := probe.home(ctx, executable); err == nil {
+				native.Home = home
+			}
+		}
+		return native, true
+	}
+	return NativeTool{}, false
+}
+
+// nativeVersionMatches reports whether a native version satisfies the request. Strict requests match exactly.
+// Latest installed requests match versions that start with the requested version components, e.g. 3.3 matches 3.3.0,
+// but not 3.30.0. Auto requests match the same way, except that a fully specified version must match exactly.
+func nativeVersionMatches(tool ToolRequest, nativeVersion string) bool {
+	requested := strings.TrimSpace(tool.UnparsedVersion)
+	switch tool.ResolutionStrategy {
+	case ResolutionStrategyConstraint:
+		constraints, err := ParseVersionConstraint(requested)
+		if err != nil {
+			return false
+		}
+		_, ok := LatestMatchingVersion(constraints, []string{nativeVersion})
+		return ok
+	case ResolutionStrategyLatestInstalled:
+		if requested == "" || requested == "installed" || requested == "latest" {
+			return true
+		}
+		if _, err := version.NewVersion(requested); err != nil {
+			return false
+		}
+		return nativeVersion == requested || strings.HasPrefix(nativeVersion, requested+".")
+	case ResolutionStrategyStrict:
+		return nativeVersion == requested
+	case ResolutionStrategyAuto:
+		if requested == "" || requested == "installed" || requested == "latest" {
+			// Same as the special cases of the providers: installed prefers installs, the others the latest release.
+			return requested == "installed"
+		}
+		if IsFullySpecifiedVersion(requested) {
+			return nativeVersion == requested
+		}
+		return MatchesVersionPrefix(nativeVersion, requested)
+	default:
+		return false
+	}
+}
+
+// javaHomePattern matches the java.home line of `java -XshowSettings:properties -version`.
+var javaHomePattern = regexp.MustCompile(`(?m)^\s*java\.home = (.+)$`)
+
+// javaHome asks the JVM for its home, which is also correct for the /usr/bin/java stub of macOS.
+func javaHome(ctx context.Context, executable string) (string, error) {
+	out, err := exec.CommandContext(ctx, executable, "-XshowSettings:properties", "-version").CombinedOutput()
+	if err != nil {
+		return "", err
+	}
+	matches := javaHomePattern.FindStringSubmatch(string(out))
+	if matches == nil {
+		return "", errors.New("java.home is not in the output of java -XshowSettings:properties")
+	}
+	home := strings.TrimSpace(matches[1])
+	// Java 8 reports the jre directory inside the JDK
+	if filepath.Base(home) == "jre" {
+		if _, err := os.Stat(filepath.Join(filepath.Dir(home), "bin", "java")); err == nil {
+			return filepath.Dir(home), nil
+		}
+	}
+	return home, nil
+}
+
+func goRoot(ctx context.Context, executable string) (string, error) {
+	out, err := exec.CommandContext(ctx, executable, "env", "GOROOT").Output()
+	if err != nil {
+		return "", err
+	}
+	root := strings.TrimSpace(string(out))
+	if root == "" {
+		return "", errors.New("go env GOROOT is empty")
+	}
+	return root, nil
+}
+
+func isInside(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		rel, err := filepath.Rel(dir, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, "../") {
+			return true
+		}
+	}
+	return false
+}
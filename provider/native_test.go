@@ -0,0 +1,156 @@
+package provider_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/toolprovider/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeExecutable creates an executable script in a new directory that prints the given output.
+func writeExecutable(t *testing.T, name, output string) string {
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho '" + output + "' >&2\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(script), 0755))
+	return dir
+}
+
+func TestFindNativeTool(t *testing.T) {
+	node18Dir := writeExecutable(t, "node", "v18.19.0")
+	node20Dir := writeExecutable(t, "node", "v20.11.1")
+	javaDir := writeExecutable(t, "java", `openjdk version "17.0.10" 2024-01-16`)
+	dirs := []string{node18Dir, node20Dir, javaDir}
+
+	tests := []struct {
+		name            string
+		tool            provider.ToolRequest
+		excludedDirs    []string
+		expectedVersion string
+		expectedDir     string
+	}{
+		{
+			name:            "first matching version in $PATH",
+			tool:            provider.ToolRequest{ToolName: "node", UnparsedVersion: "20", ResolutionStrategy: provider.ResolutionStrategyAuto},
+			expectedVersion: "20.11.1",
+			expectedDir:     node20Dir,
+		},
+		{
+			name:            "latest installed",
+			tool:            provider.ToolRequest{ToolName: "nodejs", UnparsedVersion: "18.19", ResolutionStrategy: provider.ResolutionStrategyLatestInstalled},
+			expectedVersion: "18.19.0",
+			expectedDir:     node18Dir,
+		},
+		{
+			name:            "constraint",
+			tool:            provider.ToolRequest{ToolName: "nodejs", UnparsedVersion: ">=19", ResolutionStrategy: provider.ResolutionStrategyConstraint},
+			expectedVersion: "20.11.1",
+			expectedDir:     node20Dir,
+		},
+		{
+			name:            "auto prefix",
+			tool:            provider.ToolRequest{ToolName: "nodejs", UnparsedVersion: "18", ResolutionStrategy: provider.ResolutionStrategyAuto},
+			expectedVersion: "18.19.0",
+			expectedDir:     node18Dir,
+		},
+		{
+			name:            "strict",
+			tool:            provider.ToolRequest{ToolName: "nodejs", UnparsedVersion: "18.19.0", ResolutionStrategy: provider.ResolutionStrategyStrict},
+			expectedVersion: "18.19.0",
+			expectedDir:     node18Dir,
+		},
+		{
+			name: "strict must match exactly",
+			tool: provider.ToolRequest{ToolName: "nodejs", UnparsedVersion: "20", ResolutionStrategy: provider.ResolutionStrategyStrict},
+		},
+		{
+			name: "auto with a fully specified version must match exactly",
+			tool: provider.ToolRequest{ToolName: "nodejs", UnparsedVersion: "20.11.2", ResolutionStrategy: provider.ResolutionStrategyAuto},
+		},
+		{
+			name:         "excluded dir",
+			tool:         provider.ToolRequest{ToolName: "nodejs", UnparsedVersion: "20", ResolutionStrategy: provider.ResolutionStrategyStrict},
+			excludedDirs: []string{node20Dir},
+		},
+		{
+			name: "version components must match",
+			tool: provider.ToolRequest{ToolName: "nodejs", UnparsedVersion: "20.1", ResolutionStrategy: provider.ResolutionStrategyLatestInstalled},
+		},
+		{
+			name: "latest released never matches",
+			tool: provider.ToolRequest{ToolName: "nodejs", UnparsedVersion: "20", ResolutionStrategy: provider.ResolutionStrategyLatestReleased},
+		},
+		{
+			name: "no version probe for the tool",
+			tool: provider.ToolRequest{ToolName: "flutter", UnparsedVersion: "3", ResolutionStrategy: provider.ResolutionStrategyStrict},
+		},
+		{
+			name:            "java -version output",
+			tool:            provider.ToolRequest{ToolName: "java", UnparsedVersion: "17", ResolutionStrategy: provider.ResolutionStrategyAuto},
+			expectedVersion: "17.0.10",
+			expectedDir:     javaDir,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			native, ok := provider.FindNativeTool(context.Background(), tt.tool, dirs, tt.excludedDirs)
+			if tt.expectedVersion == "" {
+				assert.False(t, ok, "found %s %s", native.Executable, native.Version)
+				return
+			}
+			require.True(t, ok)
+			assert.Equal(t, tt.expectedVersion, native.Version)
+			assert.Equal(t, tt.expectedDir, filepath.Dir(native.Executable))
+		})
+	}
+}
+
+func TestNativeToolActivation(t *testing.T) {
+	// The home is reported by the tool, e.g. /usr/bin/java is only a stub on macOS
+	javaDir := writeExecutable(t, "java", `openjdk version "21.0.2" 2024-01-16
+    java.home = /Library/Java/JavaVirtualMachines/temurin-21.jdk/Contents/Home`)
+	native, ok := provider.FindNativeTool(context.Background(), provider.ToolRequest{ToolName: "java", UnparsedVersion: "21", ResolutionStrategy: provider.ResolutionStrategyAuto}, []string{javaDir}, nil)
+	require.True(t, ok)
+
+	assert.Equal(t, provider.EnvironmentActivation{
+		ContributedEnvVars: map[string]string{"JAVA_HOME": "/Library/Java/JavaVirtualMachines/temurin-21.jdk/Contents/Home"},
+		ContributedPaths:   []string{javaDir},
+	}, native.Activation())
+}
+
+func TestNativeToolHome(t *testing.T) {
+	goDir := t.TempDir()
+	script := `#!/bin/sh
+case "$1" in
+  version) echo "go version go1.22.5 darwin/arm64" ;;
+  env) echo "/opt/homebrew/Cellar/go/1.22.5/libexec" ;;
+esac
+`
+	require.NoError(t, os.WriteFile(filepath.Join(goDir, "go"), []byte(script), 0755))
+	native, ok := provider.FindNativeTool(context.Background(), provider.ToolRequest{ToolName: "golang", UnparsedVersion: "1.22", ResolutionStrategy: provider.ResolutionStrategyAuto}, []string{goDir}, nil)
+	require.True(t, ok)
+	assert.Equal(t, "/opt/homebrew/Cellar/go/1.22.5/libexec", native.Home)
+
+	// Java 8 reports the jre directory of the JDK
+	jdkDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(jdkDir, "bin"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(jdkDir, "bin", "java"), []byte("#!/bin/sh\necho 'openjdk version \"1.8.0_402\"' >&2\necho '    java.home = "+jdkDir+"/jre' >&2\n"), 0755))
+	native, ok = provider.FindNativeTool(context.Background(), provider.ToolRequest{ToolName: "java", UnparsedVersion: "1.8", ResolutionStrategy: provider.ResolutionStrategyAuto}, []string{filepath.Join(jdkDir, "bin")}, nil)
+	require.True(t, ok)
+	assert.Equal(t, jdkDir, native.Home)
+
+	// The tool is still found without a home
+	javaDir := writeExecutable(t, "java", `openjdk version "17.0.10" 2024-01-16`)
+	native, ok = provider.FindNativeTool(context.Background(), provider.ToolRequest{ToolName: "java", UnparsedVersion: "17", ResolutionStrategy: provider.ResolutionStrategyAuto}, []string{javaDir}, nil)
+	require.True(t, ok)
+	assert.Empty(t, native.Home)
+}
+
+func TestNativeSearchDirs(t *testing.T) {
+	dirs := provider.NativeSearchDirs("go", "/usr/bin:/bin")
+	assert.Equal(t, []string{"/usr/bin", "/bin"}, dirs[:2], "$PATH comes first")
+}
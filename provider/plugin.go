@@ -0,0 +1,32 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PluginSourceSeparator separates the plugin name from the git clone URL in ToolRequest.PluginIdentifier.
+// Unlikely to conflict with any plugin name or URL, but clearly separates the plugin name and URL.
+const PluginSourceSeparator = "::"
+
+// ParsePluginIdentifier parses a plugin identifier of the format "pluginName::[gitCloneURL]", where gitCloneURL
+// is optional. Only plugin-based providers use the identifier, but its syntax is validated with the tool declarations.
+func ParsePluginIdentifier(pluginIdentifier string) (pluginName string, gitCloneURL string, err error) {
+	parts := strings.Split(pluginIdentifier, PluginSourceSeparator)
+	if len(parts) > 2 {
+		return "", "", fmt.Errorf("invalid plugin identifier format: %s, expected format is 'pluginName%s[gitCloneURL]'", pluginIdentifier, PluginSourceSeparator)
+	}
+
+	pluginName = strings.TrimSpace(parts[0])
+	if pluginName == "" {
+		return "", "", fmt.Errorf("plugin name cannot be empty in identifier: %s", pluginIdentifier)
+	}
+	if strings.HasPrefix(pluginName, "http://") || strings.HasPrefix(pluginName, "https://") {
+		return "", "", fmt.Errorf("plugin name should not contain URL: %s", pluginName)
+	}
+
+	if len(parts) > 1 {
+		gitCloneURL = strings.TrimSpace(parts[1])
+	}
+	return pluginName, gitCloneURL, nil
+}
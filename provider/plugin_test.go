@@ -0,0 +1,37 @@
+package provider_test
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/toolprovider/provider"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePluginIdentifier(t *testing.T) {
+	tests := []struct {
+		identifier string
+		wantName   string
+		wantURL    string
+		wantErr    bool
+	}{
+		{identifier: "nodejs::https://github.com/asdf-vm/asdf-nodejs.git", wantName: "nodejs", wantURL: "https://github.com/asdf-vm/asdf-nodejs.git"},
+		{identifier: "nodejs", wantName: "nodejs"},
+		{identifier: "nodejs::", wantName: "nodejs"},
+		{identifier: "::https://github.com/asdf-vm/asdf-nodejs.git", wantErr: true},
+		{identifier: "https://github.com/asdf-vm/asdf-nodejs.git", wantErr: true},
+		{identifier: "nodejs::latest::https://github.com/asdf-vm/asdf-nodejs.git", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.identifier, func(t *testing.T) {
+			name, url, err := provider.ParsePluginIdentifier(tt.identifier)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantName, name)
+			require.Equal(t, tt.wantURL, url)
+		})
+	}
+}
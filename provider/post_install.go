@@ -0,0 +1,24 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// uninstallTimeout limits UninstallAfterFailedPostInstall(), which doesn't stop when the install's context is done.
+const uninstallTimeout = time.Minute
+
+// UninstallAfterFailedPostInstall uninstalls the version whose post-install commands failed with postInstallErr.
+// Otherwise the next install would find the version installed and skip the post-install commands.
+// The uninstall runs even if ctx is done, e.g. because a post-install command timed out.
+func UninstallAfterFailedPostInstall(ctx context.Context, p ToolProvider, result ToolInstallResult, postInstallErr error) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), uninstallTimeout)
+	defer cancel()
+	err := p.UninstallTool(ctx, result.ToolName, result.ConcreteVersion)
+	if err != nil {
+		return errors.Join(postInstallErr, fmt.Errorf("uninstall %s %s after the failed post-install: %w", result.ToolName, result.ConcreteVersion, err))
+	}
+	return postInstallErr
+}
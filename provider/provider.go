@@ -1,16 +1,65 @@
 package provider
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
 
 type ResolutionStrategy int
 
 const (
-	// TODO: ResolutionStrategyAuto?
 	ResolutionStrategyStrict ResolutionStrategy = iota
 	ResolutionStrategyLatestInstalled
 	ResolutionStrategyLatestReleased
+	// ResolutionStrategyConstraint treats the version as a constraint expression (see ParseVersionConstraint)
+	// and resolves to the highest released or installed version that satisfies it.
+	ResolutionStrategyConstraint
+	// ResolutionStrategyAuto is the default for bare versions: a fully specified version is strict, otherwise
+	// it's a prefix that prefers an installed version and falls back to the latest released one, see ResolveAutoVersion().
+	ResolutionStrategyAuto
 )
 
+func (s ResolutionStrategy) String() string {
+	switch s {
+	case ResolutionStrategyStrict:
+		return "strict"
+	case ResolutionStrategyLatestInstalled:
+		return "closest_installed"
+	case ResolutionStrategyLatestReleased:
+		return "closest_released"
+	case ResolutionStrategyConstraint:
+		return "constraint"
+	case ResolutionStrategyAuto:
+		return "auto"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// ParseResolutionStrategy is the inverse of ResolutionStrategy.String().
+func ParseResolutionStrategy(s string) (ResolutionStrategy, error) {
+	switch s {
+	case "strict":
+		return ResolutionStrategyStrict, nil
+	case "closest_installed":
+		return ResolutionStrategyLatestInstalled, nil
+	case "closest_released":
+		return ResolutionStrategyLatestReleased, nil
+	case "constraint":
+		return ResolutionStrategyConstraint, nil
+	case "auto":
+		return ResolutionStrategyAuto, nil
+	default:
+		return 0, fmt.Errorf("unknown resolution strategy: %s", s)
+	}
+}
+
 type ToolRequest struct {
 	ToolName string
 	// UnparsedVersion is the version string as provided by the user.
@@ -19,7 +68,19 @@ type ToolRequest struct {
 	ResolutionStrategy ResolutionStrategy
 	// PluginIdentifier is an optional identifier for the tool plugin.
 	PluginIdentifier *string
-	// TODO: PostInstall script
+	// ProviderID is the ID of the provider that should install this tool. Empty means the default provider.
+	// A comma-separated list of IDs is a fallback chain, see FallbackProvider.
+	ProviderID string
+	// PostInstall is an optional list of shell commands that run in order after a new version is installed,
+	// in an environment where the installed version is activated. A single script is represented as one item.
+	PostInstall []string
+	// DependsOn lists the canonical names of the tools that must be installed and activated before this one.
+	DependsOn []string
+	// DependencyEnv is the activated environment of the tools in DependsOn (and their dependencies).
+	// Providers apply it to every command they run while installing the tool, see EnvironmentActivation.Apply().
+	DependencyEnv EnvironmentActivation
+	// Optional tools don't fail the build when they fail to install. They are left out of the environment activation instead.
+	Optional bool
 }
 
 type ToolInstallResult struct {
@@ -29,8 +90,22 @@ type ToolInstallResult struct {
 	// It may differ from the requested version if the requested version was not a concrete version.
 	// This value may or may not be a valid semantic version.
 	ConcreteVersion string
+	// ResolveDuration is the part of the InstallTool call that was spent resolving the requested version.
+	ResolveDuration time.Duration
+	// NativeTool is set if a native install of the tool was used instead of the provider's, see ToolProvider.IsInstalledNative().
+	NativeTool *NativeTool
+	// ProviderID is the provider that served the request if it's not the one InstallTool was called on,
+	// e.g. a member of a FallbackProvider. Empty otherwise.
+	ProviderID string
 }
 
+var (
+	// ErrToolNotFound means that the provider can't install the tool at all, e.g. because there's no plugin for it.
+	ErrToolNotFound = errors.New("tool not found")
+	// ErrVersionNotFound means that no released or installed version of the tool matches the request.
+	ErrVersionNotFound = errors.New("no matching version")
+)
+
 type ToolInstallError struct {
 	ToolName         string
 	RequestedVersion string
@@ -39,6 +114,8 @@ type ToolInstallError struct {
 	RawOutput      string
 	Cause          string
 	Recommendation string
+	// Err classifies the failure (e.g. ErrToolNotFound), so that callers can tell it apart with errors.Is().
+	Err error
 }
 
 func (e ToolInstallError) Error() string {
@@ -59,6 +136,43 @@ func (e ToolInstallError) Error() string {
 	return msg
 }
 
+func (e ToolInstallError) Unwrap() error {
+	return e.Err
+}
+
+// ToolInstallPlan describes what InstallTool would do with a request, without installing anything.
+type ToolInstallPlan struct {
+	ToolName           string
+	RequestedVersion   string
+	ResolutionStrategy ResolutionStrategy
+	// ResolvedVersion is the concrete version the request resolves to at the moment.
+	ResolvedVersion string
+	IsInstalled     bool
+	// IsNative is true if a native install would be used, see ToolProvider.IsInstalledNative().
+	IsNative bool
+	// ProviderID is the provider that would serve the request, see ToolInstallResult.ProviderID.
+	ProviderID string
+}
+
+// PlannedAction is a short, human-readable description of the step InstallTool would take.
+func (p ToolInstallPlan) PlannedAction() string {
+	if p.IsNative {
+		return "use native"
+	}
+	if p.IsInstalled {
+		return "use installed"
+	}
+	return "install"
+}
+
+// InstalledVersion is a tool version installed by a provider, see ToolProvider.ListInstalled().
+type InstalledVersion struct {
+	ToolName string
+	Version  string
+	// Path is the install directory of the version, used to measure its disk usage. Empty if unknown.
+	Path string
+}
+
 // TODO: Mise merges envs and $PATH changes into one output, maybe we should do the same for asdf?
 // It would simplify the activation process.
 type EnvironmentActivation struct {
@@ -66,14 +180,52 @@ type EnvironmentActivation struct {
 	ContributedPaths   []string
 }
 
+// Apply returns a copy of env with the activation applied: env vars are overridden and paths are prepended to $PATH.
+// If env has no $PATH, the process' $PATH is extended.
+func (a EnvironmentActivation) Apply(env map[string]string) map[string]string {
+	applied := maps.Clone(env)
+	if applied == nil {
+		applied = make(map[string]string)
+	}
+	maps.Copy(applied, a.ContributedEnvVars)
+	if len(a.ContributedPaths) > 0 {
+		pathEnv, ok := applied["PATH"]
+		if !ok {
+			pathEnv = os.Getenv("PATH")
+		}
+		applied["PATH"] = strings.Join(slices.Concat(a.ContributedPaths, []string{pathEnv}), ":")
+	}
+	return applied
+}
+
+// ToolProvider installs and activates tools with an underlying tool manager.
+// Methods that take a context stop when it's done, and kill the processes they started.
 type ToolProvider interface {
 	ID() string
 
-	Bootstrap() error
+	// Version returns the version of the underlying tool manager, e.g. for reports.
+	Version() (string, error)
+
+	Bootstrap(ctx context.Context) error
+
+	// SupportsConcurrentInstalls reports whether InstallTool and PlanInstall can be called for different tools
+	// at the same time. Callers use the provider for one tool at a time otherwise.
+	SupportsConcurrentInstalls() bool
+
+	InstallTool(ctx context.Context, tool ToolRequest) (ToolInstallResult, error)
+
+	// PlanInstall resolves the requested version the same way as InstallTool, but never installs the tool.
+	PlanInstall(ctx context.Context, tool ToolRequest) (ToolInstallPlan, error)
+
+	ActivateEnv(ctx context.Context, result ToolInstallResult) (EnvironmentActivation, error)
 
-	InstallTool(tool ToolRequest) (ToolInstallResult, error)
+	// IsInstalledNative looks for a native install of the tool that satisfies the request (see FindNativeTool()).
+	// The provider's own installs and shims are not native.
+	IsInstalledNative(ctx context.Context, tool ToolRequest) (NativeTool, bool, error)
 
-	ActivateEnv(result ToolInstallResult) (EnvironmentActivation, error)
+	// ListInstalled returns every installed version of every tool managed by the provider.
+	ListInstalled(ctx context.Context) ([]InstalledVersion, error)
 
-	// TODO: IsInstalledNative(tool ToolRequest) (bool, error)
+	// UninstallTool removes an installed version of a tool. The version must be a concrete version from ListInstalled().
+	UninstallTool(ctx context.Context, toolName string, version string) error
 }
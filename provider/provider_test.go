@@ -0,0 +1,26 @@
+package provider_test
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/toolprovider/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvironmentActivationApply(t *testing.T) {
+	activation := provider.EnvironmentActivation{
+		ContributedEnvVars: map[string]string{"JAVA_HOME": "/opt/java/21"},
+		ContributedPaths:   []string{"/opt/java/21/bin", "/opt/node/20/bin"},
+	}
+	env := map[string]string{"JAVA_HOME": "/opt/java/17", "PATH": "/usr/bin:/bin"}
+
+	applied := activation.Apply(env)
+
+	assert.Equal(t, map[string]string{
+		"JAVA_HOME": "/opt/java/21",
+		"PATH":      "/opt/java/21/bin:/opt/node/20/bin:/usr/bin:/bin",
+	}, applied)
+	assert.Equal(t, "/opt/java/17", env["JAVA_HOME"], "the original env is not modified")
+
+	assert.Equal(t, env, provider.EnvironmentActivation{}.Apply(env))
+}
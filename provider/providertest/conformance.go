@@ -0,0 +1,181 @@
+package providertest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/bitrise-io/toolprovider/provider"
+)
+
+// Case is a version request against the released and installed versions of a tool, and its expected resolution.
+type Case struct {
+	Name             string
+	Strategy         provider.ResolutionStrategy
+	RequestedVersion string
+	Installed        []string
+	Released         []string
+	// ExpectedVersion is the concrete version the request resolves to. Empty means that no version matches,
+	// and the provider must fail with provider.ErrVersionNotFound.
+	ExpectedVersion string
+	// ExpectedInstalled is whether ExpectedVersion is installed before the install.
+	ExpectedInstalled bool
+}
+
+// FullName identifies the case in Conformance.Deviations and in the name of its subtest, e.g. "strict/Nonexistent version".
+func (c Case) FullName() string {
+	return c.Strategy.String() + "/" + c.Name
+}
+
+// Conformance is a test suite that holds every provider to the same version resolution semantics.
+// Each case runs against a new provider: PlanInstall and InstallTool must resolve the request to the expected version,
+// and a second install of the resolved version must find it installed.
+type Conformance struct {
+	// ToolName is the tool that the cases are requested for. The provider must know it, e.g. asdf needs a plugin for it.
+	ToolName string
+	// NewProvider creates a provider with the released and installed versions of ToolName.
+	NewProvider func(t *testing.T, released, installed []string) provider.ToolProvider
+	// Deviations are the cases that the provider is known to resolve differently, by Case.FullName(), with the reason.
+	// They are skipped.
+	Deviations map[string]string
+}
+
+// Run runs every case of Cases as a subtest.
+func (c Conformance) Run(t *testing.T) {
+	for _, tc := range Cases {
+		t.Run(tc.FullName(), func(t *testing.T) {
+			if reason, ok := c.Deviations[tc.FullName()]; ok {
+				t.Skip(reason)
+			}
+			c.runCase(t, tc)
+		})
+	}
+}
+
+func (c Conformance) runCase(t *testing.T, tc Case) {
+	ctx := context.Background()
+	p := c.NewProvider(t, tc.Released, tc.Installed)
+	request := provider.ToolRequest{ToolName: c.ToolName, UnparsedVersion: tc.RequestedVersion, ResolutionStrategy: tc.Strategy}
+
+	plan, planErr := p.PlanInstall(ctx, request)
+	result, installErr := p.InstallTool(ctx, request)
+	if tc.ExpectedVersion == "" {
+		for method, err := range map[Method]error{MethodPlanInstall: planErr, MethodInstallTool: installErr} {
+			if !errors.Is(err, provider.ErrVersionNotFound) {
+				t.Errorf("%s: expected an error matching provider.ErrVersionNotFound, got: %v", method, err)
+			}
+		}
+		return
+	}
+
+	if planErr != nil {
+		t.Errorf("PlanInstall: %v", planErr)
+	} else if plan.ResolvedVersion != tc.ExpectedVersion || plan.IsInstalled != tc.ExpectedInstalled {
+		t.Errorf("PlanInstall: expected %s (installed: %t), got %s (installed: %t)", tc.ExpectedVersion, tc.ExpectedInstalled, plan.ResolvedVersion, plan.IsInstalled)
+	}
+	if installErr != nil {
+		t.Fatalf("InstallTool: %v", installErr)
+	}
+	if result.ConcreteVersion != tc.ExpectedVersion || result.IsAlreadyInstalled != tc.ExpectedInstalled {
+		t.Fatalf("InstallTool: expected %s (installed: %t), got %s (installed: %t)", tc.ExpectedVersion, tc.ExpectedInstalled, result.ConcreteVersion, result.IsAlreadyInstalled)
+	}
+
+	result, err := p.InstallTool(ctx, provider.ToolRequest{ToolName: c.ToolName, UnparsedVersion: tc.ExpectedVersion, ResolutionStrategy: provider.ResolutionStrategyStrict})
+	if err != nil {
+		t.Fatalf("InstallTool of the resolved version: %v", err)
+	}
+	if result.ConcreteVersion != tc.ExpectedVersion || !result.IsAlreadyInstalled {
+		t.Errorf("InstallTool of the resolved version: expected %s to be installed, got %s (installed: %t)", tc.ExpectedVersion, result.ConcreteVersion, result.IsAlreadyInstalled)
+	}
+}
+
+var (
+	semverReleases    = []string{"1.0.0", "1.0.1", "1.1.0"}
+	goReleases        = []string{"1.18", "1.18.1", "1.18.2", "1.18.3", "1.19", "1.19.1", "1.19.5", "1.20", "1.20.1"}
+	nodeReleases      = []string{"18.6.3", "20.0.0", "20.1.0", "20.2.0", "20.5.0", "21.0.0", "22.0.0"}
+	javaInstalls      = []string{"openjdk-21", "oracle-21", "temurin-11.0.15+10", "temurin-17.0.4+101"}
+	javaReleases      = slices.Concat(javaInstalls, []string{"temurin-21.0.0+35.0.LTS"})
+	javaLTSInstalls   = slices.Concat(javaInstalls, []string{"temurin-21.0.0+33.0.LTS"})
+	javaLTSReleases   = slices.Concat(javaLTSInstalls, []string{"temurin-21.0.0+35.0.LTS"})
+	temurin11Releases = []string{"temurin-11.0.15+10", "temurin-11.0.15+101", "temurin-11.0.15+100"}
+	prereleases       = []string{"1.0.0", "2.4.5", "3.24.4", "3.24.5-prerelease.rc3", "3.24.5"}
+	keywordInstalls   = []string{"18.2.0", "20.1.0"}
+	keywordReleases   = []string{"18.2.0", "20.1.0", "22.0.0"}
+)
+
+// Cases are the requests of every resolution strategy and version keyword, see Conformance.
+var Cases = []Case{
+	// Strict
+	{Name: "Exact match with installed version", Strategy: provider.ResolutionStrategyStrict, RequestedVersion: "1.0.0", Installed: semverReleases, Released: semverReleases, ExpectedVersion: "1.0.0", ExpectedInstalled: true},
+	{Name: "Exact version but not installed", Strategy: provider.ResolutionStrategyStrict, RequestedVersion: "1.0.0", Installed: []string{"1.0.1", "1.1.0"}, Released: semverReleases, ExpectedVersion: "1.0.0"},
+	{Name: "Nonexistent version", Strategy: provider.ResolutionStrategyStrict, RequestedVersion: "2.0.0", Installed: []string{"1.0.1", "1.1.0"}, Released: semverReleases},
+	{Name: "Old Golang versioning scheme", Strategy: provider.ResolutionStrategyStrict, RequestedVersion: "1.19", Installed: []string{"1.18", "1.18.3", "1.20"}, Released: goReleases, ExpectedVersion: "1.19"},
+	{Name: "Non-semver tool, exact match with installed version", Strategy: provider.ResolutionStrategyStrict, RequestedVersion: "temurin-21.0.0+35.0.LTS", Installed: javaReleases, Released: javaReleases, ExpectedVersion: "temurin-21.0.0+35.0.LTS", ExpectedInstalled: true},
+	{Name: "Non-semver tool, exact match but not installed", Strategy: provider.ResolutionStrategyStrict, RequestedVersion: "temurin-21.0.0+35.0.LTS", Installed: javaInstalls, Released: javaReleases, ExpectedVersion: "temurin-21.0.0+35.0.LTS"},
+	{Name: "Non-semver tool, nonexistent version", Strategy: provider.ResolutionStrategyStrict, RequestedVersion: "temurin-21.0.0+39.0", Installed: javaInstalls, Released: javaReleases},
+	{Name: "Non-semver tool, requested version is semver", Strategy: provider.ResolutionStrategyStrict, RequestedVersion: "21.0.0", Installed: javaInstalls, Released: javaReleases},
+	{Name: "latest installed version requested", Strategy: provider.ResolutionStrategyStrict, RequestedVersion: "installed", Installed: javaInstalls, Released: javaReleases, ExpectedVersion: "temurin-17.0.4+101", ExpectedInstalled: true},
+	{Name: "latest released version requested", Strategy: provider.ResolutionStrategyStrict, RequestedVersion: "latest", Installed: javaInstalls, Released: javaReleases, ExpectedVersion: "temurin-21.0.0+35.0.LTS"},
+	{Name: "latest released version requested with empty version field", Strategy: provider.ResolutionStrategyStrict, RequestedVersion: "", Installed: javaInstalls, Released: javaReleases, ExpectedVersion: "temurin-21.0.0+35.0.LTS"},
+	{Name: "latest installed version requested with nothing installed", Strategy: provider.ResolutionStrategyStrict, RequestedVersion: "installed", Released: keywordReleases, ExpectedVersion: "22.0.0"},
+
+	// Latest installed
+	{Name: "Exact version but not installed", Strategy: provider.ResolutionStrategyLatestInstalled, RequestedVersion: "1.0.0", Installed: []string{"1.0.1", "1.1.0"}, Released: semverReleases, ExpectedVersion: "1.0.0"},
+	{Name: "Exact version and installed", Strategy: provider.ResolutionStrategyLatestInstalled, RequestedVersion: "1.0.0", Installed: semverReleases, Released: semverReleases, ExpectedVersion: "1.0.0", ExpectedInstalled: true},
+	{Name: "Partial match with installed version", Strategy: provider.ResolutionStrategyLatestInstalled, RequestedVersion: "20", Installed: []string{"18.6.3", "20.0.0", "20.1.0", "20.2.0", "21.0.0"}, Released: nodeReleases, ExpectedVersion: "20.2.0", ExpectedInstalled: true},
+	{Name: "Partial match doesn't match a longer version component", Strategy: provider.ResolutionStrategyLatestInstalled, RequestedVersion: "3.1", Installed: []string{"3.1.3", "3.12.0"}, Released: []string{"3.1.3", "3.1.4", "3.12.0", "3.12.1"}, ExpectedVersion: "3.1.3", ExpectedInstalled: true},
+	{Name: "Old Golang versioning scheme", Strategy: provider.ResolutionStrategyLatestInstalled, RequestedVersion: "1.19", Installed: []string{"1.18", "1.18.3", "1.19.5", "1.20"}, Released: goReleases, ExpectedVersion: "1.19.5", ExpectedInstalled: true},
+	{Name: "No partial match for installed version, fallback to released version match", Strategy: provider.ResolutionStrategyLatestInstalled, RequestedVersion: "20.3", Installed: []string{"18.6.3", "20.0.0", "20.1.0", "20.2.0", "21.0.0"}, Released: []string{"18.6.3", "20.0.0", "20.1.0", "20.2.0", "20.3.0", "20.5.0"}, ExpectedVersion: "20.3.0"},
+	{Name: "Nonexistent version", Strategy: provider.ResolutionStrategyLatestInstalled, RequestedVersion: "2.0.0", Installed: []string{"1.0.1", "1.1.0"}, Released: semverReleases},
+	{Name: "Non-semver tool, exact match with installed version", Strategy: provider.ResolutionStrategyLatestInstalled, RequestedVersion: "temurin-21.0.0+35.0.LTS", Installed: javaReleases, Released: javaReleases, ExpectedVersion: "temurin-21.0.0+35.0.LTS", ExpectedInstalled: true},
+	{Name: "Non-semver tool, exact match but not installed", Strategy: provider.ResolutionStrategyLatestInstalled, RequestedVersion: "temurin-21.0.0+35.0.LTS", Installed: javaInstalls, Released: javaReleases, ExpectedVersion: "temurin-21.0.0+35.0.LTS"},
+	{Name: "Non-semver tool, partial match with installed version", Strategy: provider.ResolutionStrategyLatestInstalled, RequestedVersion: "temurin-21", Installed: javaReleases, Released: javaReleases, ExpectedVersion: "temurin-21.0.0+35.0.LTS", ExpectedInstalled: true},
+	{Name: "Non-semver tool, partial match but not installed", Strategy: provider.ResolutionStrategyLatestInstalled, RequestedVersion: "temurin-21", Installed: javaInstalls, Released: javaReleases, ExpectedVersion: "temurin-21.0.0+35.0.LTS"},
+	{Name: "Non-semver tool, partial match with correct natural ordering", Strategy: provider.ResolutionStrategyLatestInstalled, RequestedVersion: "temurin-11.0.15", Installed: temurin11Releases, Released: temurin11Releases, ExpectedVersion: "temurin-11.0.15+101", ExpectedInstalled: true},
+	{Name: "Non-semver tool, nonexistent version", Strategy: provider.ResolutionStrategyLatestInstalled, RequestedVersion: "temurin-21.0.0+39.0", Installed: javaInstalls, Released: javaReleases},
+	{Name: "Non-semver tool, requested version is semver", Strategy: provider.ResolutionStrategyLatestInstalled, RequestedVersion: "21.0.0", Installed: javaInstalls, Released: javaReleases},
+	{Name: "Non-semver tool with prerelease versions", Strategy: provider.ResolutionStrategyLatestInstalled, RequestedVersion: "3.24", Installed: []string{"1.0.0", "2.4.5"}, Released: slices.Concat(prereleases, []string{"absolutely-not-semver-compatible-release"}), ExpectedVersion: "3.24.5"},
+	{Name: "Semver tool, request prelease version", Strategy: provider.ResolutionStrategyLatestInstalled, RequestedVersion: "3.24.5-prerelease.rc3", Installed: []string{"1.0.0", "2.4.5", "3.24.5-prerelease.rc3"}, Released: prereleases, ExpectedVersion: "3.24.5-prerelease.rc3", ExpectedInstalled: true},
+	{Name: "Semver tool, request prelease version which is not installed", Strategy: provider.ResolutionStrategyLatestInstalled, RequestedVersion: "3.24.5-prerelease.rc3", Installed: []string{"1.0.0", "2.4.5"}, Released: prereleases, ExpectedVersion: "3.24.5-prerelease.rc3"},
+
+	// Latest released
+	{Name: "Partial match with installed latest version", Strategy: provider.ResolutionStrategyLatestReleased, RequestedVersion: "20", Installed: []string{"18.6.3", "20.0.0", "20.1.0", "20.2.0", "20.5.0", "21.0.0"}, Released: nodeReleases, ExpectedVersion: "20.5.0", ExpectedInstalled: true},
+	{Name: "Partial match with both installed and non-installed versions", Strategy: provider.ResolutionStrategyLatestReleased, RequestedVersion: "20", Installed: []string{"18.6.3", "20.0.0", "20.1.0", "20.2.0", "21.0.0"}, Released: nodeReleases, ExpectedVersion: "20.5.0"},
+	{Name: "Partial match doesn't match a longer version component", Strategy: provider.ResolutionStrategyLatestReleased, RequestedVersion: "3.1", Installed: []string{"3.12.0"}, Released: []string{"3.1.3", "3.1.4", "3.12.0", "3.12.1"}, ExpectedVersion: "3.1.4"},
+	{Name: "Partial match with released version only", Strategy: provider.ResolutionStrategyLatestReleased, RequestedVersion: "20", Installed: []string{"18.6.3", "21.0.0"}, Released: nodeReleases, ExpectedVersion: "20.5.0"},
+	{Name: "Exact version matches installed version", Strategy: provider.ResolutionStrategyLatestReleased, RequestedVersion: "18.6.3", Installed: []string{"18.6.3", "21.0.0"}, Released: nodeReleases, ExpectedVersion: "18.6.3", ExpectedInstalled: true},
+	{Name: "Nonexistent version", Strategy: provider.ResolutionStrategyLatestReleased, RequestedVersion: "2.0.0", Installed: []string{"1.0.1", "1.1.0"}, Released: semverReleases},
+	{Name: "Non-semver tool, exact match with both installed and released versions", Strategy: provider.ResolutionStrategyLatestReleased, RequestedVersion: "temurin-21.0.0+35.0.LTS", Installed: javaLTSReleases, Released: javaLTSReleases, ExpectedVersion: "temurin-21.0.0+35.0.LTS", ExpectedInstalled: true},
+	{Name: "Non-semver tool, exact match but not installed", Strategy: provider.ResolutionStrategyLatestReleased, RequestedVersion: "temurin-21.0.0+35.0.LTS", Installed: javaLTSInstalls, Released: javaLTSReleases, ExpectedVersion: "temurin-21.0.0+35.0.LTS"},
+	{Name: "Non-semver tool, partial match with installed version", Strategy: provider.ResolutionStrategyLatestReleased, RequestedVersion: "temurin-21", Installed: javaLTSReleases, Released: javaLTSReleases, ExpectedVersion: "temurin-21.0.0+35.0.LTS", ExpectedInstalled: true},
+	{Name: "Non-semver tool, partial match but not installed", Strategy: provider.ResolutionStrategyLatestReleased, RequestedVersion: "temurin-21", Installed: javaInstalls, Released: slices.Concat(javaLTSReleases, []string{"temurin-23.0.0+35.0.LTS"}), ExpectedVersion: "temurin-21.0.0+35.0.LTS"},
+	{Name: "Non-semver tool, partial match with correct natural ordering", Strategy: provider.ResolutionStrategyLatestReleased, RequestedVersion: "temurin-11.0.15", Installed: temurin11Releases, Released: temurin11Releases, ExpectedVersion: "temurin-11.0.15+101", ExpectedInstalled: true},
+	{Name: "Non-semver tool, nonexistent version", Strategy: provider.ResolutionStrategyLatestReleased, RequestedVersion: "temurin-21.0.0+39.0", Installed: javaInstalls, Released: javaReleases},
+	{Name: "Non-semver tool, requested version is semver", Strategy: provider.ResolutionStrategyLatestReleased, RequestedVersion: "21.0.0", Installed: javaInstalls, Released: javaReleases},
+	{Name: "Non-semver tool with prerelease versions", Strategy: provider.ResolutionStrategyLatestReleased, RequestedVersion: "3.24", Installed: []string{"1.0.0", "2.4.5"}, Released: slices.Concat(prereleases, []string{"absolutely-not-semver-compatible-release"}), ExpectedVersion: "3.24.5"},
+	{Name: "Semver tool, request could also match prerelease version", Strategy: provider.ResolutionStrategyLatestReleased, RequestedVersion: "3.24.5", Installed: []string{"1.0.0", "2.4.5", "3.24.5-prerelease.rc3"}, Released: prereleases, ExpectedVersion: "3.24.5"},
+	{Name: "Semver tool, request prerelease version which is not installed", Strategy: provider.ResolutionStrategyLatestReleased, RequestedVersion: "3.24.5-prerelease", Installed: []string{"1.0.0", "2.4.5"}, Released: slices.Concat(prereleases, []string{"3.24.5-prerelease.rc5"}), ExpectedVersion: "3.24.5-prerelease.rc5"},
+
+	// Constraint
+	{Name: "Range matches released version", Strategy: provider.ResolutionStrategyConstraint, RequestedVersion: ">=1.21 <1.23", Installed: []string{"1.20.14", "1.21.0"}, Released: []string{"1.20.14", "1.21.0", "1.22.0", "1.22.5", "1.23.0"}, ExpectedVersion: "1.22.5"},
+	{Name: "Caret matches installed version", Strategy: provider.ResolutionStrategyConstraint, RequestedVersion: "^20", Installed: []string{"18.20.0", "20.19.3"}, Released: []string{"18.20.0", "20.19.2", "20.19.3", "22.1.0"}, ExpectedVersion: "20.19.3", ExpectedInstalled: true},
+	{Name: "Installed version that is not released anymore", Strategy: provider.ResolutionStrategyConstraint, RequestedVersion: "~> 3.2", Installed: []string{"3.4.0-custom"}, Released: []string{"3.1.0", "3.2.0"}, ExpectedVersion: "3.2.0"},
+	{Name: "Exclusion", Strategy: provider.ResolutionStrategyConstraint, RequestedVersion: "!=3.12.1", Released: []string{"3.12.0", "3.12.1"}, ExpectedVersion: "3.12.0"},
+	{Name: "Non-semver versions never match", Strategy: provider.ResolutionStrategyConstraint, RequestedVersion: ">=21", Released: []string{"temurin-21.0.0+35.0.LTS", "openjdk-21"}},
+
+	// Auto
+	{Name: "Prefix prefers installed version", Strategy: provider.ResolutionStrategyAuto, RequestedVersion: "20", Installed: []string{"20.18.0", "22.1.0"}, Released: []string{"18.20.0", "20.18.0", "20.19.3", "22.1.0"}, ExpectedVersion: "20.18.0", ExpectedInstalled: true},
+	{Name: "Prefix falls back to latest released version", Strategy: provider.ResolutionStrategyAuto, RequestedVersion: "3.12", Installed: []string{"3.11.9"}, Released: []string{"3.11.9", "3.12.1", "3.12.10", "3.12.9"}, ExpectedVersion: "3.12.10"},
+	{Name: "Fully specified version is strict", Strategy: provider.ResolutionStrategyAuto, RequestedVersion: "20.18.0", Installed: []string{"20.18.1"}, Released: []string{"20.18.0", "20.19.3"}, ExpectedVersion: "20.18.0"},
+	{Name: "Latest is resolved to latest released version", Strategy: provider.ResolutionStrategyAuto, RequestedVersion: "latest", Installed: []string{"20.18.0"}, Released: []string{"20.18.0", "20.19.3"}, ExpectedVersion: "20.19.3"},
+	{Name: "No match", Strategy: provider.ResolutionStrategyAuto, RequestedVersion: "24", Released: []string{"20.19.3", "22.1.0"}},
+
+	// Version keywords with the other strategies, e.g. ":latest" or "latest:installed"
+	{Name: "Empty version", Strategy: provider.ResolutionStrategyLatestReleased, RequestedVersion: "", Installed: keywordInstalls, Released: keywordReleases, ExpectedVersion: "22.0.0"},
+	{Name: "Empty version", Strategy: provider.ResolutionStrategyLatestInstalled, RequestedVersion: "", Installed: keywordInstalls, Released: keywordReleases, ExpectedVersion: "20.1.0", ExpectedInstalled: true},
+	{Name: "Empty version", Strategy: provider.ResolutionStrategyAuto, RequestedVersion: "", Installed: keywordInstalls, Released: keywordReleases, ExpectedVersion: "22.0.0"},
+	{Name: "latest keyword", Strategy: provider.ResolutionStrategyLatestInstalled, RequestedVersion: "latest", Installed: keywordInstalls, Released: keywordReleases, ExpectedVersion: "20.1.0", ExpectedInstalled: true},
+	{Name: "installed keyword", Strategy: provider.ResolutionStrategyLatestReleased, RequestedVersion: "installed", Installed: keywordInstalls, Released: keywordReleases, ExpectedVersion: "22.0.0"},
+	{Name: "installed keyword", Strategy: provider.ResolutionStrategyAuto, RequestedVersion: "installed", Installed: keywordInstalls, Released: keywordReleases, ExpectedVersion: "20.1.0", ExpectedInstalled: true},
+}
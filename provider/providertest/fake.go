@@ -0,0 +1,312 @@
+// Package providertest implements helpers for testing tool providers and the code that uses them: an in-memory
+// FakeProvider, a Conformance suite that every provider must pass, and fake tool manager CLIs (see RunFakeCLI()).
+package providertest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/bitrise-io/toolprovider/provider"
+	"golang.org/x/exp/maps"
+)
+
+// Method is a method of provider.ToolProvider, see FakeProvider.FailWith() and Call.
+type Method string
+
+const (
+	MethodBootstrap         Method = "Bootstrap"
+	MethodInstallTool       Method = "InstallTool"
+	MethodPlanInstall       Method = "PlanInstall"
+	MethodActivateEnv       Method = "ActivateEnv"
+	MethodIsInstalledNative Method = "IsInstalledNative"
+	MethodListInstalled     Method = "ListInstalled"
+	MethodUninstallTool     Method = "UninstallTool"
+)
+
+// Call is a recorded call of a FakeProvider. Version is the requested version for InstallTool and PlanInstall,
+// and the concrete version for ActivateEnv and UninstallTool.
+type Call struct {
+	Method   Method
+	ToolName string
+	Version  string
+}
+
+// FakeProvider is an in-memory provider.ToolProvider. It resolves requests against the released and installed
+// versions of each tool the same way as the real providers (see Conformance), and installing a version adds it to
+// the installed ones. A tool without any released or installed version fails with provider.ErrToolNotFound.
+// Errors can be injected into every method with FailWith(), and every call is recorded. It's safe for concurrent use.
+type FakeProvider struct {
+	id string
+
+	mu        sync.Mutex
+	released  map[string][]string
+	installed map[string][]string
+	errs      map[Method]map[string]error
+	calls     []Call
+}
+
+func NewFakeProvider(id string) *FakeProvider {
+	return &FakeProvider{
+		id:        id,
+		released:  map[string][]string{},
+		installed: map[string][]string{},
+		errs:      map[Method]map[string]error{},
+	}
+}
+
+// SetReleased replaces the released versions of the tool.
+func (f *FakeProvider) SetReleased(toolName string, versions ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.released[toolName] = slices.Clone(versions)
+}
+
+// SetInstalled replaces the installed versions of the tool.
+func (f *FakeProvider) SetInstalled(toolName string, versions ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.installed[toolName] = slices.Clone(versions)
+}
+
+// Installed returns the installed versions of the tool, in the order they were installed.
+func (f *FakeProvider) Installed(toolName string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.installed[toolName])
+}
+
+// FailWith makes the calls of method fail with err for the tool, or for every tool if toolName is empty.
+// A nil err removes the injected error.
+func (f *FakeProvider) FailWith(method Method, toolName string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.errs[method] == nil {
+		f.errs[method] = map[string]error{}
+	}
+	if err == nil {
+		delete(f.errs[method], toolName)
+		return
+	}
+	f.errs[method][toolName] = err
+}
+
+// Calls returns the calls received so far, in order.
+func (f *FakeProvider) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.calls)
+}
+
+func (f *FakeProvider) ID() string {
+	return f.id
+}
+
+func (f *FakeProvider) Version() (string, error) {
+	return "1.0.0", nil
+}
+
+func (f *FakeProvider) Bootstrap(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.record(MethodBootstrap, "", "")
+}
+
+func (f *FakeProvider) SupportsConcurrentInstalls() bool {
+	return true
+}
+
+func (f *FakeProvider) InstallTool(ctx context.Context, tool provider.ToolRequest) (provider.ToolInstallResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record(MethodInstallTool, tool.ToolName, tool.UnparsedVersion); err != nil {
+		return provider.ToolInstallResult{}, err
+	}
+	provider.Emit(ctx, provider.Event{Kind: provider.EventResolveStarted, ProviderID: f.id, ToolName: tool.ToolName, Version: tool.UnparsedVersion})
+
+	version, isInstalled, err := f.resolve(tool)
+	if err != nil {
+		return provider.ToolInstallResult{}, err
+	}
+	provider.Emit(ctx, provider.Event{Kind: provider.EventResolveFinished, ProviderID: f.id, ToolName: tool.ToolName, Version: version})
+	if !isInstalled {
+		f.installed[tool.ToolName] = append(f.installed[tool.ToolName], version)
+	}
+	return provider.ToolInstallResult{ToolName: tool.ToolName, IsAlreadyInstalled: isInstalled, ConcreteVersion: version}, nil
+}
+
+func (f *FakeProvider) PlanInstall(ctx context.Context, tool provider.ToolRequest) (provider.ToolInstallPlan, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record(MethodPlanInstall, tool.ToolName, tool.UnparsedVersion); err != nil {
+		return provider.ToolInstallPlan{}, err
+	}
+
+	version, isInstalled, err := f.resolve(tool)
+	if err != nil {
+		return provider.ToolInstallPlan{}, err
+	}
+	return provider.ToolInstallPlan{
+		ToolName:           tool.ToolName,
+		RequestedVersion:   tool.UnparsedVersion,
+		ResolutionStrategy: tool.ResolutionStrategy,
+		ResolvedVersion:    version,
+		IsInstalled:        isInstalled,
+	}, nil
+}
+
+// ActivateEnv activates an installed version as $<TOOL>_VERSION and /<provider ID>/<tool>/<version>/bin.
+func (f *FakeProvider) ActivateEnv(ctx context.Context, result provider.ToolInstallResult) (provider.EnvironmentActivation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record(MethodActivateEnv, result.ToolName, result.ConcreteVersion); err != nil {
+		return provider.EnvironmentActivation{}, err
+	}
+
+	activation := provider.EnvironmentActivation{}
+	if result.NativeTool != nil {
+		activation = result.NativeTool.Activation()
+	} else {
+		if !slices.Contains(f.installed[result.ToolName], result.ConcreteVersion) {
+			return provider.EnvironmentActivation{}, fmt.Errorf("%s %s is not installed", result.ToolName, result.ConcreteVersion)
+		}
+		activation = provider.EnvironmentActivation{
+			ContributedEnvVars: map[string]string{strings.ToUpper(result.ToolName) + "_VERSION": result.ConcreteVersion},
+			ContributedPaths:   []string{fmt.Sprintf("/%s/%s/%s/bin", f.id, result.ToolName, result.ConcreteVersion)},
+		}
+	}
+	provider.Emit(ctx, provider.Event{Kind: provider.EventActivated, ProviderID: f.id, ToolName: result.ToolName, Version: result.ConcreteVersion})
+	return activation, nil
+}
+
+// IsInstalledNative never finds a native install, unless it fails with an injected error.
+func (f *FakeProvider) IsInstalledNative(ctx context.Context, tool provider.ToolRequest) (provider.NativeTool, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return provider.NativeTool{}, false, f.record(MethodIsInstalledNative, tool.ToolName, tool.UnparsedVersion)
+}
+
+// ListInstalled returns the installed versions of every tool, sorted by tool name.
+func (f *FakeProvider) ListInstalled(ctx context.Context) ([]provider.InstalledVersion, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record(MethodListInstalled, "", ""); err != nil {
+		return nil, err
+	}
+
+	toolNames := maps.Keys(f.installed)
+	slices.Sort(toolNames)
+	var installed []provider.InstalledVersion
+	for _, toolName := range toolNames {
+		for _, v := range f.installed[toolName] {
+			installed = append(installed, provider.InstalledVersion{ToolName: toolName, Version: v})
+		}
+	}
+	return installed, nil
+}
+
+func (f *FakeProvider) UninstallTool(ctx context.Context, toolName string, version string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record(MethodUninstallTool, toolName, version); err != nil {
+		return err
+	}
+
+	i := slices.Index(f.installed[toolName], version)
+	if i == -1 {
+		return fmt.Errorf("%s %s is not installed", toolName, version)
+	}
+	f.installed[toolName] = slices.Delete(f.installed[toolName], i, i+1)
+	return nil
+}
+
+// record records the call and returns the error injected into it, if any. f.mu must be held.
+func (f *FakeProvider) record(method Method, toolName, version string) error {
+	f.calls = append(f.calls, Call{Method: method, ToolName: toolName, Version: version})
+	if err, ok := f.errs[method][toolName]; ok {
+		return err
+	}
+	return f.errs[method][""]
+}
+
+// resolve resolves the request against the versions of the tool and reports whether the version is installed. f.mu must be held.
+func (f *FakeProvider) resolve(tool provider.ToolRequest) (string, bool, error) {
+	released, installed := f.released[tool.ToolName], f.installed[tool.ToolName]
+	if len(released) == 0 && len(installed) == 0 {
+		return "", false, provider.ToolInstallError{
+			ToolName:         tool.ToolName,
+			RequestedVersion: tool.UnparsedVersion,
+			Cause:            fmt.Sprintf("%s is unknown to %s", tool.ToolName, f.id),
+			Err:              provider.ErrToolNotFound,
+		}
+	}
+
+	version, found, err := resolveVersion(tool, released, installed)
+	if err != nil {
+		return "", false, err
+	}
+	if !found {
+		return "", false, provider.ToolInstallError{
+			ToolName:         tool.ToolName,
+			RequestedVersion: tool.UnparsedVersion,
+			Cause:            fmt.Sprintf("no match for requested version %s", tool.UnparsedVersion),
+			Err:              provider.ErrVersionNotFound,
+		}
+	}
+	return version, slices.Contains(installed, version), nil
+}
+
+// resolveVersion resolves a request the way Conformance expects it: "", latest and installed are the latest released
+// or installed version, a strict version must exist as it is, and a prefix matches whole version components.
+// The latest installed version falls back to the latest released one.
+func resolveVersion(tool provider.ToolRequest, released, installed []string) (string, bool, error) {
+	requested := strings.TrimSpace(tool.UnparsedVersion)
+	strategy := tool.ResolutionStrategy
+	switch requested {
+	case "", "latest", "installed":
+		if strategy == provider.ResolutionStrategyStrict || strategy == provider.ResolutionStrategyAuto {
+			strategy = provider.ResolutionStrategyLatestReleased
+			if requested == "installed" {
+				strategy = provider.ResolutionStrategyLatestInstalled
+			}
+		}
+		requested = ""
+	}
+
+	switch strategy {
+	case provider.ResolutionStrategyStrict:
+		return requested, slices.Contains(installed, requested) || slices.Contains(released, requested), nil
+	case provider.ResolutionStrategyAuto:
+		version, found := provider.ResolveAutoVersion(requested, released, installed)
+		return version, found, nil
+	case provider.ResolutionStrategyConstraint:
+		constraints, err := provider.ParseVersionConstraint(requested)
+		if err != nil {
+			return "", false, err
+		}
+		version, found := provider.LatestMatchingVersion(constraints, slices.Concat(released, installed))
+		return version, found, nil
+	case provider.ResolutionStrategyLatestInstalled:
+		if version, found := latestWithPrefix(installed, requested); found {
+			return version, true, nil
+		}
+		version, found := latestWithPrefix(released, requested)
+		return version, found, nil
+	case provider.ResolutionStrategyLatestReleased:
+		version, found := latestWithPrefix(released, requested)
+		return version, found, nil
+	default:
+		return "", false, fmt.Errorf("unknown resolution strategy: %v", tool.ResolutionStrategy)
+	}
+}
+
+func latestWithPrefix(versions []string, prefix string) (string, bool) {
+	for _, v := range provider.LogicallySortedVersions(versions) {
+		if provider.MatchesVersionPrefix(v, prefix) {
+			return v, true
+		}
+	}
+	return "", false
+}
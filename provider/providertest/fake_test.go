@@ -0,0 +1,70 @@
+package providertest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bitrise-io/toolprovider/provider"
+	"github.com/bitrise-io/toolprovider/provider/providertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConformance(t *testing.T) {
+	providertest.Conformance{
+		ToolName: "golang",
+		NewProvider: func(t *testing.T, released, installed []string) provider.ToolProvider {
+			p := providertest.NewFakeProvider("fake")
+			p.SetReleased("golang", released...)
+			p.SetInstalled("golang", installed...)
+			return p
+		},
+	}.Run(t)
+}
+
+func TestFakeProvider(t *testing.T) {
+	ctx := context.Background()
+	p := providertest.NewFakeProvider("fake")
+	p.SetReleased("nodejs", "20.18.0", "22.1.0")
+	p.SetInstalled("ruby", "3.3.6")
+
+	result, err := p.InstallTool(ctx, provider.ToolRequest{ToolName: "nodejs", UnparsedVersion: "22", ResolutionStrategy: provider.ResolutionStrategyLatestReleased})
+	require.NoError(t, err)
+	assert.Equal(t, provider.ToolInstallResult{ToolName: "nodejs", ConcreteVersion: "22.1.0"}, result)
+	activation, err := p.ActivateEnv(ctx, result)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/fake/nodejs/22.1.0/bin"}, activation.ContributedPaths)
+
+	_, err = p.InstallTool(ctx, provider.ToolRequest{ToolName: "swift", UnparsedVersion: "6"})
+	assert.ErrorIs(t, err, provider.ErrToolNotFound)
+
+	compileErr := errors.New("compile ruby: exit status 1")
+	p.FailWith(providertest.MethodInstallTool, "ruby", compileErr)
+	_, err = p.InstallTool(ctx, provider.ToolRequest{ToolName: "ruby", UnparsedVersion: "3.3.6"})
+	assert.Equal(t, compileErr, err)
+	p.FailWith(providertest.MethodInstallTool, "ruby", nil)
+	_, err = p.InstallTool(ctx, provider.ToolRequest{ToolName: "ruby", UnparsedVersion: "3.3.6"})
+	assert.NoError(t, err)
+
+	p.FailWith(providertest.MethodListInstalled, "", compileErr)
+	_, err = p.ListInstalled(ctx)
+	assert.Equal(t, compileErr, err)
+	p.FailWith(providertest.MethodListInstalled, "", nil)
+
+	require.NoError(t, p.UninstallTool(ctx, "ruby", "3.3.6"))
+	installed, err := p.ListInstalled(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []provider.InstalledVersion{{ToolName: "nodejs", Version: "22.1.0"}}, installed)
+
+	assert.Equal(t, []providertest.Call{
+		{Method: providertest.MethodInstallTool, ToolName: "nodejs", Version: "22"},
+		{Method: providertest.MethodActivateEnv, ToolName: "nodejs", Version: "22.1.0"},
+		{Method: providertest.MethodInstallTool, ToolName: "swift", Version: "6"},
+		{Method: providertest.MethodInstallTool, ToolName: "ruby", Version: "3.3.6"},
+		{Method: providertest.MethodInstallTool, ToolName: "ruby", Version: "3.3.6"},
+		{Method: providertest.MethodListInstalled},
+		{Method: providertest.MethodUninstallTool, ToolName: "ruby", Version: "3.3.6"},
+		{Method: providertest.MethodListInstalled},
+	}, p.Calls())
+}
@@ -0,0 +1,125 @@
+package providertest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"al.essio.dev/pkg/shellescape"
+)
+
+// VersionDir keeps the released and installed versions of tools on disk: released versions in released/<tool>,
+// one per line, and installed versions as installs/<tool>/<version> directories. Fake CLIs run in their own processes
+// (see RunFakeCLI()), so they share their state with the test through a VersionDir.
+type VersionDir string
+
+// NewVersionDir creates a VersionDir in a temp dir with the released and installed versions of the tool.
+func NewVersionDir(t *testing.T, toolName string, released, installed []string) VersionDir {
+	t.Helper()
+	dir := VersionDir(t.TempDir())
+	if err := dir.SetReleased(toolName, released...); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range installed {
+		if err := dir.Install(toolName, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// SetReleased replaces the released versions of the tool.
+func (d VersionDir) SetReleased(toolName string, versions ...string) error {
+	path := filepath.Join(string(d), "released", toolName)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(strings.Join(versions, "\n")), 0644)
+}
+
+// Released returns the released versions of the tool, or nil if the tool is unknown.
+func (d VersionDir) Released(toolName string) ([]string, error) {
+	content, err := os.ReadFile(filepath.Join(string(d), "released", toolName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(content)), nil
+}
+
+// Installed returns the installed versions of the tool, sorted by name.
+func (d VersionDir) Installed(toolName string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(string(d), "installs", toolName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var versions []string
+	for _, entry := range entries {
+		versions = append(versions, entry.Name())
+	}
+	return versions, nil
+}
+
+// InstallPath is the directory of an installed version.
+func (d VersionDir) InstallPath(toolName, version string) string {
+	return filepath.Join(string(d), "installs", toolName, version)
+}
+
+// Install installs a version of the tool.
+func (d VersionDir) Install(toolName, version string) error {
+	return os.MkdirAll(d.InstallPath(toolName, version), 0755)
+}
+
+// Uninstall removes an installed version of the tool.
+func (d VersionDir) Uninstall(toolName, version string) error {
+	return os.RemoveAll(d.InstallPath(toolName, version))
+}
+
+const (
+	fakeCLIEnv    = "PROVIDERTEST_FAKE_CLI"
+	versionDirEnv = "PROVIDERTEST_VERSION_DIR"
+)
+
+// FakeCLIMain is the main function of a fake CLI: it runs the command with the arguments on the versions of dir,
+// and returns the exit code. The name is the base name of the executable, e.g. "asdf".
+type FakeCLIMain func(name string, args []string, dir VersionDir, stdout, stderr io.Writer) int
+
+// WriteFakeCLI writes an executable to path that runs the current test binary as a fake CLI on dir.
+// The test binary must call RunFakeCLI() at the start of TestMain.
+func WriteFakeCLI(t *testing.T, path string, dir VersionDir) {
+	t.Helper()
+	testBinary, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := fmt.Sprintf("#!/bin/sh\n%s=%s %s=%s exec %s \"$@\"\n",
+		fakeCLIEnv, shellescape.Quote(filepath.Base(path)),
+		versionDirEnv, shellescape.Quote(string(dir)),
+		shellescape.Quote(testBinary))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// RunFakeCLI runs main and exits if the test binary was started by an executable of WriteFakeCLI(),
+// and returns otherwise. Call it at the start of TestMain, before m.Run().
+func RunFakeCLI(main FakeCLIMain) {
+	name := os.Getenv(fakeCLIEnv)
+	if name == "" {
+		return
+	}
+	os.Exit(main(name, os.Args[1:], VersionDir(os.Getenv(versionDirEnv)), os.Stdout, os.Stderr))
+}
@@ -0,0 +1,89 @@
+package provider
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/exp/maps"
+)
+
+// ProviderOptions configure a provider, see tool_config.provider_options. Every option is optional, providers
+// use their own defaults for the unset ones and fail to be created with options they don't support.
+type ProviderOptions struct {
+	// DataDir is where the provider keeps the installed tools (and plugins).
+	DataDir string
+	// InstallDir is where the tool manager itself is installed.
+	InstallDir string
+	// Version pins the version of the tool manager.
+	Version string
+	// ShellInit is a shell command that initializes the tool manager in a shell session.
+	ShellInit string
+	// Manifest is the path of the file that describes the downloadable tools, for providers that need one.
+	Manifest string
+}
+
+// Factory creates a provider with the given options.
+type Factory func(opts ProviderOptions) (ToolProvider, error)
+
+// Registry holds the factories of the available providers by provider ID. It's safe for concurrent use.
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[string]Factory
+}
+
+// DefaultRegistry is where the providers of this module register themselves when their package is imported,
+// the same way as database/sql drivers. Import the provider packages (e.g. for side effects only) to use them.
+var DefaultRegistry = NewRegistry()
+
+func NewRegistry() *Registry {
+	return &Registry{factories: map[string]Factory{}}
+}
+
+// Register adds a provider to DefaultRegistry, see Registry.Register().
+func Register(providerID string, factory Factory) {
+	DefaultRegistry.Register(providerID, factory)
+}
+
+// Register makes a provider available by its ID. It panics if the ID is empty or already registered,
+// because that's a programming error.
+func (r *Registry) Register(providerID string, factory Factory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if providerID == "" || factory == nil {
+		panic("provider: Register called with an empty provider ID or a nil factory")
+	}
+	if _, ok := r.factories[providerID]; ok {
+		panic(fmt.Sprintf("provider: Register called twice for %s", providerID))
+	}
+	r.factories[providerID] = factory
+}
+
+// IDs returns the IDs of the registered providers, sorted.
+func (r *Registry) IDs() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ids := maps.Keys(r.factories)
+	slices.Sort(ids)
+	return ids
+}
+
+// New creates a registered provider with the given options.
+func (r *Registry) New(providerID string, opts ProviderOptions) (ToolProvider, error) {
+	r.mu.RLock()
+	factory, ok := r.factories[providerID]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported tool provider: %s, expected one of: %s", providerID, strings.Join(r.IDs(), ", "))
+	}
+	return factory(opts)
+}
+
+// ProviderFactory returns a factory for a Dispatcher that creates the registered providers
+// with their options, keyed by provider ID. Providers without options get the zero ProviderOptions.
+func (r *Registry) ProviderFactory(options map[string]ProviderOptions) ProviderFactory {
+	return func(providerID string) (ToolProvider, error) {
+		return r.New(providerID, options[providerID])
+	}
+}
@@ -0,0 +1,35 @@
+package provider_test
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/toolprovider/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := provider.NewRegistry()
+	var created []provider.ProviderOptions
+	for _, id := range []string{"mise", "asdf"} {
+		registry.Register(id, func(opts provider.ProviderOptions) (provider.ToolProvider, error) {
+			created = append(created, opts)
+			return stubProvider{id: id}, nil
+		})
+	}
+	assert.Equal(t, []string{"asdf", "mise"}, registry.IDs())
+	assert.Panics(t, func() {
+		registry.Register("asdf", func(provider.ProviderOptions) (provider.ToolProvider, error) { return nil, nil })
+	})
+
+	newProvider := registry.ProviderFactory(map[string]provider.ProviderOptions{"mise": {DataDir: "/opt/mise-data"}})
+	p, err := newProvider("mise")
+	require.NoError(t, err)
+	assert.Equal(t, "mise", p.ID())
+	_, err = newProvider("asdf")
+	require.NoError(t, err)
+	assert.Equal(t, []provider.ProviderOptions{{DataDir: "/opt/mise-data"}, {}}, created)
+
+	_, err = newProvider("nix")
+	assert.EqualError(t, err, "unsupported tool provider: nix, expected one of: asdf, mise")
+}
@@ -0,0 +1,104 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Markdown renders a short summary of the report: a table of the tools and the details of the failure, if any.
+func (r Report) Markdown() string {
+	var b strings.Builder
+
+	status := "✅ Succeeded"
+	if !r.Success {
+		status = "❌ Failed"
+	}
+	fmt.Fprintf(&b, "## Tool setup: %s in %s\n\n", status, formatDuration(r.DurationMs))
+
+	if len(r.Providers) > 0 {
+		providers := make([]string, 0, len(r.Providers))
+		for _, p := range r.Providers {
+			providers = append(providers, fmt.Sprintf("%s %s", p.ID, p.Version))
+		}
+		fmt.Fprintf(&b, "Providers: %s\n\n", strings.Join(providers, ", "))
+	}
+
+	if len(r.Tools) > 0 {
+		b.WriteString("| Tool | Requested | Version | Provider | Status | Time |\n")
+		b.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+		for _, t := range r.Tools {
+			fmt.Fprintf(&b, "| %s | %s (%s) | %s | %s | %s | %s |\n",
+				t.Request.ToolName, orDash(t.Request.UnparsedVersion), t.strategy(), orDash(t.ConcreteVersion), t.ProviderID,
+				t.status(), formatDuration(t.DurationsMs.Resolve+t.DurationsMs.Install+t.DurationsMs.Activate))
+		}
+		b.WriteString("\n")
+	}
+
+	for _, t := range r.Tools {
+		if t.Error == nil {
+			continue
+		}
+		fmt.Fprintf(&b, "### %s failed\n\n", t.Request.ToolName)
+		if t.Error.Cause != "" {
+			fmt.Fprintf(&b, "**Cause:** %s\n\n", t.Error.Cause)
+		} else {
+			fmt.Fprintf(&b, "**Error:** %s\n\n", t.Error.Message)
+		}
+		if t.Error.Recommendation != "" {
+			fmt.Fprintf(&b, "**Recommendation:** %s\n\n", t.Error.Recommendation)
+		}
+		if t.Error.RawOutput != "" {
+			fmt.Fprintf(&b, "<details><summary>Output</summary>\n\n```\n%s\n```\n\n</details>\n\n", strings.TrimRight(t.Error.RawOutput, "\n"))
+		}
+	}
+
+	if !r.Success && !r.hasToolError() {
+		fmt.Fprintf(&b, "**Error:** %s\n", r.Error)
+	}
+
+	return b.String()
+}
+
+func (t Tool) status() string {
+	switch {
+	case t.Error != nil && t.Request.Optional:
+		return "failed (optional)"
+	case t.Error != nil:
+		return "failed"
+	case t.NativeExecutable != "":
+		return "native"
+	case t.IsAlreadyInstalled:
+		return "already installed"
+	default:
+		return "installed"
+	}
+}
+
+// strategy shows the effective strategy next to the requested one if they differ, e.g. auto: closest_installed.
+func (t Tool) strategy() string {
+	if t.EffectiveStrategy == "" || t.EffectiveStrategy == t.Request.ResolutionStrategy {
+		return t.Request.ResolutionStrategy
+	}
+	return fmt.Sprintf("%s: %s", t.Request.ResolutionStrategy, t.EffectiveStrategy)
+}
+
+func (r Report) hasToolError() bool {
+	for _, t := range r.Tools {
+		if t.Error != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func formatDuration(ms int64) string {
+	return (time.Duration(ms) * time.Millisecond).Round(100 * time.Millisecond).String()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
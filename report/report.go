@@ -0,0 +1,177 @@
+// Package report turns the outcome of an install run into a JSON report (e.g. for build artifacts and dashboards)
+// and a markdown summary for humans.
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bitrise-io/toolprovider/pipeline"
+	"github.com/bitrise-io/toolprovider/provider"
+)
+
+const (
+	// DefaultJSONFileName and DefaultMarkdownFileName are used when the reports are written to $BITRISE_DEPLOY_DIR.
+	DefaultJSONFileName     = "toolprovider-report.json"
+	DefaultMarkdownFileName = "toolprovider-summary.md"
+)
+
+type Report struct {
+	StartedAt time.Time `json:"started_at"`
+	// DurationMs is the duration of the whole run in milliseconds.
+	DurationMs int64          `json:"duration_ms"`
+	Success    bool           `json:"success"`
+	Error      string         `json:"error,omitempty"`
+	Providers  []ProviderInfo `json:"providers"`
+	Tools      []Tool         `json:"tools"`
+}
+
+type ProviderInfo struct {
+	ID      string `json:"id"`
+	Version string `json:"version"`
+}
+
+type Tool struct {
+	Request         Request `json:"request"`
+	ProviderID      string  `json:"provider"`
+	ConcreteVersion string  `json:"concrete_version,omitempty"`
+	// EffectiveStrategy is the strategy the version was resolved with, see provider.ToolRequest.EffectiveStrategy().
+	EffectiveStrategy  string `json:"effective_strategy,omitempty"`
+	IsAlreadyInstalled bool   `json:"is_already_installed"`
+	// NativeExecutable is set if a native install of the tool was used, see provider.ToolInstallResult.NativeTool.
+	NativeExecutable string            `json:"native_executable,omitempty"`
+	DurationsMs      Durations         `json:"durations_ms"`
+	EnvVars          map[string]string `json:"env_vars,omitempty"`
+	Paths            []string          `json:"paths,omitempty"`
+	Events           []Event           `json:"events,omitempty"`
+	Error            *ToolError        `json:"error,omitempty"`
+}
+
+// Event is a progress event of the provider while it set up the tool, see provider.Event.
+type Event struct {
+	Time    time.Time `json:"time"`
+	Kind    string    `json:"kind"`
+	Version string    `json:"version,omitempty"`
+	Message string    `json:"message,omitempty"`
+	// Bytes and TotalBytes are set for download progress. TotalBytes is -1 if the size of the download is unknown.
+	Bytes      int64 `json:"bytes,omitempty"`
+	TotalBytes int64 `json:"total_bytes,omitempty"`
+}
+
+type Request struct {
+	ToolName           string  `json:"name"`
+	UnparsedVersion    string  `json:"unparsed_version"`
+	ResolutionStrategy string  `json:"resolution_strategy"`
+	PluginIdentifier   *string `json:"plugin,omitempty"`
+	Optional           bool    `json:"optional,omitempty"`
+}
+
+type Durations struct {
+	Resolve  int64 `json:"resolve"`
+	Install  int64 `json:"install"`
+	Activate int64 `json:"activate"`
+}
+
+// ToolError contains the structured fields of provider.ToolInstallError when the failure has them,
+// otherwise only Message is set.
+type ToolError struct {
+	Message        string `json:"message"`
+	Cause          string `json:"cause,omitempty"`
+	Recommendation string `json:"recommendation,omitempty"`
+	RawOutput      string `json:"raw_output,omitempty"`
+}
+
+// New creates the report of a run that started at startedAt. installed and err are the return values of
+// pipeline.Pipeline.Install(), providers are the ones that were used in the run.
+func New(startedAt time.Time, installed []pipeline.InstalledTool, providers []provider.ToolProvider, err error) Report {
+	r := Report{
+		StartedAt:  startedAt,
+		DurationMs: time.Since(startedAt).Milliseconds(),
+		Success:    err == nil,
+		Providers:  []ProviderInfo{},
+		Tools:      []Tool{},
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+
+	for _, p := range providers {
+		v, versionErr := p.Version()
+		if versionErr != nil {
+			v = "unknown"
+		}
+		r.Providers = append(r.Providers, ProviderInfo{ID: p.ID(), Version: v})
+	}
+
+	for _, t := range installed {
+		tool := Tool{
+			Request: Request{
+				ToolName:           t.Request.ToolName,
+				UnparsedVersion:    t.Request.UnparsedVersion,
+				ResolutionStrategy: t.Request.ResolutionStrategy.String(),
+				PluginIdentifier:   t.Request.PluginIdentifier,
+				Optional:           t.Request.Optional,
+			},
+			ProviderID:         t.ProviderID,
+			ConcreteVersion:    t.Result.ConcreteVersion,
+			IsAlreadyInstalled: t.Result.IsAlreadyInstalled,
+			DurationsMs: Durations{
+				Resolve:  t.Timings.Resolve.Milliseconds(),
+				Install:  t.Timings.Install.Milliseconds(),
+				Activate: t.Timings.Activate.Milliseconds(),
+			},
+			EnvVars: t.Activation.ContributedEnvVars,
+			Paths:   t.Activation.ContributedPaths,
+		}
+		for _, e := range t.Events {
+			tool.Events = append(tool.Events, Event{Time: e.Time, Kind: e.Kind.String(), Version: e.Version, Message: e.Message, Bytes: e.Bytes, TotalBytes: e.TotalBytes})
+		}
+		if t.Result.NativeTool != nil {
+			tool.NativeExecutable = t.Result.NativeTool.Executable
+		}
+		if t.Err != nil {
+			tool.Error = newToolError(t.Err)
+		} else {
+			tool.EffectiveStrategy = t.Request.EffectiveStrategy(t.Result.IsAlreadyInstalled).String()
+		}
+		r.Tools = append(r.Tools, tool)
+	}
+
+	return r
+}
+
+func newToolError(err error) *ToolError {
+	var installErr provider.ToolInstallError
+	if errors.As(err, &installErr) {
+		return &ToolError{
+			Message:        err.Error(),
+			Cause:          installErr.Cause,
+			Recommendation: installErr.Recommendation,
+			RawOutput:      installErr.RawOutput,
+		}
+	}
+	return &ToolError{Message: err.Error()}
+}
+
+func (r Report) WriteJSON(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("serialize report: %w", err)
+	}
+	err = os.WriteFile(path, append(data, '\n'), 0644)
+	if err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+	return nil
+}
+
+func (r Report) WriteMarkdown(path string) error {
+	err := os.WriteFile(path, []byte(r.Markdown()), 0644)
+	if err != nil {
+		return fmt.Errorf("write report summary: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package report_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/toolprovider/pipeline"
+	"github.com/bitrise-io/toolprovider/provider"
+	"github.com/bitrise-io/toolprovider/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestReport() report.Report {
+	installErr := provider.ToolInstallError{
+		ToolName:         "ruby",
+		RequestedVersion: "3.3",
+		Cause:            "compilation failed",
+		Recommendation:   "Install libyaml",
+		RawOutput:        "error: yaml.h not found\n",
+	}
+	installed := []pipeline.InstalledTool{
+		{
+			Request:    provider.ToolRequest{ToolName: "nodejs", UnparsedVersion: "20", ResolutionStrategy: provider.ResolutionStrategyAuto},
+			ProviderID: "asdf",
+			Result:     provider.ToolInstallResult{ToolName: "nodejs", ConcreteVersion: "20.11.0", IsAlreadyInstalled: true},
+			Activation: provider.EnvironmentActivation{ContributedPaths: []string{"/nodejs/bin"}},
+			Timings:    pipeline.Timings{Resolve: 120 * time.Millisecond, Activate: 30 * time.Millisecond},
+		},
+		{
+			Request:    provider.ToolRequest{ToolName: "ruby", UnparsedVersion: "3.3"},
+			ProviderID: "asdf",
+			Timings:    pipeline.Timings{Install: 2 * time.Second},
+			Err:        installErr,
+		},
+	}
+	eventTime := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	installed[1].Events = []provider.Event{
+		{Kind: provider.EventResolveStarted, Time: eventTime, ProviderID: "asdf", ToolName: "ruby", Version: "3.3"},
+		{Kind: provider.EventOutput, Time: eventTime, ProviderID: "asdf", ToolName: "ruby", Message: "Downloading ruby-3.3.6.tar.gz..."},
+	}
+	err := pipeline.StageError{Stage: pipeline.StageInstall, Err: installErr}
+	return report.New(time.Now(), installed, nil, err)
+}
+
+func TestWriteJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), report.DefaultJSONFileName)
+	require.NoError(t, newTestReport().WriteJSON(path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var r report.Report
+	require.NoError(t, json.Unmarshal(data, &r))
+
+	assert.False(t, r.Success)
+	assert.Equal(t, []report.ProviderInfo{}, r.Providers)
+	require.Len(t, r.Tools, 2)
+
+	assert.Equal(t, report.Request{ToolName: "nodejs", UnparsedVersion: "20", ResolutionStrategy: "auto"}, r.Tools[0].Request)
+	assert.Equal(t, "closest_installed", r.Tools[0].EffectiveStrategy)
+	assert.Equal(t, "20.11.0", r.Tools[0].ConcreteVersion)
+	assert.True(t, r.Tools[0].IsAlreadyInstalled)
+	assert.Equal(t, report.Durations{Resolve: 120, Activate: 30}, r.Tools[0].DurationsMs)
+	assert.Equal(t, []string{"/nodejs/bin"}, r.Tools[0].Paths)
+	assert.Nil(t, r.Tools[0].Error)
+
+	assert.Empty(t, r.Tools[0].Events)
+
+	assert.Empty(t, r.Tools[1].EffectiveStrategy)
+	assert.Equal(t, []report.Event{
+		{Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Kind: "resolve_started", Version: "3.3"},
+		{Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Kind: "output", Message: "Downloading ruby-3.3.6.tar.gz..."},
+	}, r.Tools[1].Events)
+
+	require.NotNil(t, r.Tools[1].Error)
+	assert.Equal(t, "compilation failed", r.Tools[1].Error.Cause)
+	assert.Equal(t, "Install libyaml", r.Tools[1].Error.Recommendation)
+	assert.Equal(t, "error: yaml.h not found\n", r.Tools[1].Error.RawOutput)
+}
+
+func TestNewWithPlainError(t *testing.T) {
+	installed := []pipeline.InstalledTool{
+		{Request: provider.ToolRequest{ToolName: "golang"}, Err: errors.New("no provider")},
+	}
+	r := report.New(time.Now(), installed, nil, installed[0].Err)
+
+	require.NotNil(t, r.Tools[0].Error)
+	assert.Equal(t, &report.ToolError{Message: "no provider"}, r.Tools[0].Error)
+}
+
+func TestMarkdown(t *testing.T) {
+	md := newTestReport().Markdown()
+
+	assert.Contains(t, md, "## Tool setup: ❌ Failed")
+	assert.Contains(t, md, "| nodejs | 20 (auto: closest_installed) | 20.11.0 | asdf | already installed | 200ms |")
+	assert.Contains(t, md, "| ruby | 3.3 (strict) | - | asdf | failed | 2s |")
+	assert.Contains(t, md, "**Cause:** compilation failed")
+	assert.Contains(t, md, "**Recommendation:** Install libyaml")
+	assert.Contains(t, md, "```\nerror: yaml.h not found\n```")
+}